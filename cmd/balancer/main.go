@@ -10,6 +10,7 @@ import (
 	"load-balancer/internal/api"
 	"load-balancer/internal/config"
 	"load-balancer/internal/health"
+	"load-balancer/internal/l4"
 	"load-balancer/internal/logger"
 )
 
@@ -23,22 +24,20 @@ func main() {
 
 	// Initialize health checker
 	healthChecker := health.NewHealthChecker()
+	healthChecker.SetHistorySize(cfg.HealthHistorySize)
 
 	// Create server
-	server := api.NewServer(
-		cfg.Backends,
+	server := api.NewServerFromConfig(
+		cfg,
 		healthChecker,
-		cfg.RateLimit.Capacity,
-		cfg.RateLimit.Rate,
-		cfg.ClientConfigs,
-		"redis:6379",          // Redis address
+		cfg.Redis.Addr,        // Redis address
 		"configs/config.json", // Path to config.json
 	)
 
 	// Start health checker
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	healthChecker.Start(ctx, cfg, cfg.HealthCheckInterval)
+	healthChecker.Start(ctx, server.ConfigSnapshot, cfg.HealthCheckInterval)
 
 	// Start server
 	go func() {
@@ -48,15 +47,57 @@ func main() {
 		}
 	}()
 
+	// Start HTTPS listener if configured
+	if cfg.TLS.Enabled {
+		go func() {
+			if err := server.StartTLS(cfg.TLS.Port); err != nil && err != http.ErrServerClosed {
+				logger.ErrorKV("TLS server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Start admin server on its own listener if configured
+	if cfg.AdminPort != "" {
+		go func() {
+			if err := server.StartAdmin(cfg.AdminPort); err != nil && err != http.ErrServerClosed {
+				logger.ErrorKV("Admin server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Start layer-4 TCP listeners
+	for _, l := range cfg.TCPListeners {
+		go func() {
+			if err := server.StartTCP(l.Name); err != nil && err != l4.ErrServerClosed {
+				logger.ErrorKV("TCP listener failed", "name", l.Name, "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Start layer-4 UDP listeners
+	for _, l := range cfg.UDPListeners {
+		go func() {
+			if err := server.StartUDP(l.Name); err != nil && err != l4.ErrServerClosed {
+				logger.ErrorKV("UDP listener failed", "name", l.Name, "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Wait for shutdown signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
-	// Shutdown server
-	if err := server.Shutdown(ctx); err != nil {
+	// Shutdown server, then stop health checks once no requests are left
+	if err := server.Shutdown(context.Background()); err != nil {
 		logger.ErrorKV("Server shutdown failed", "error", err)
+		cancel()
 		os.Exit(1)
 	}
+	cancel()
 	logger.Info("Server stopped")
 }
@@ -17,16 +17,48 @@ const docTemplate = `{
     "paths": {
         "/": {
             "get": {
-                "description": "Forwards an incoming HTTP request to a healthy backend using round-robin balancing.",
+                "description": "Forwards an incoming HTTP request to a healthy backend of the pool selected by the routes, or of the default pool if no route matches. gRPC calls (Content-Type application/grpc) get errors as a gRPC status in the grpc-status and grpc-message headers instead of a JSON body.",
                 "produces": [
                     "text/plain"
                 ],
                 "summary": "Forward request to backend",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID to reuse; a UUID is generated when absent or invalid",
+                        "name": "X-Request-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shorter upstream deadline, e.g. 2s or 2000 (milliseconds); capped by the configured request timeout",
+                        "name": "X-Request-Timeout",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Response from backend",
                         "schema": {
                             "type": "string"
+                        },
+                        "headers": {
+                            "X-Request-ID": {
+                                "type": "string",
+                                "description": "ID of the request, also sent to the backend"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "The route requires a client certificate",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body exceeds server.max_body_bytes",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "429": {
@@ -46,13 +78,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "504": {
+                        "description": "Upstream timeout expired",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/backends": {
             "get": {
-                "description": "Get, add, or delete backend servers.",
+                "description": "Get, add, delete backend servers or change their state (active, draining, maintenance).",
                 "consumes": [
                     "application/json"
                 ],
@@ -71,7 +109,7 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "description": "Backend URL (required for POST, e.g., {\\",
+                        "description": "Backend URL (http, https or unix:///path/to.sock), optional weight, Host header choice, upstream TLS settings and protocol (required for POST, e.g., {\\",
                         "name": "body",
                         "in": "body",
                         "schema": {
@@ -81,11 +119,11 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of backends (GET)",
+                        "description": "List of backends (GET) or the updated backend (PATCH)",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Backend"
+                                "$ref": "#/definitions/api.BackendStatus"
                             }
                         }
                     },
@@ -128,7 +166,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Get, add, or delete backend servers.",
+                "description": "Get, add, delete backend servers or change their state (active, draining, maintenance).",
                 "consumes": [
                     "application/json"
                 ],
@@ -147,7 +185,7 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "description": "Backend URL (required for POST, e.g., {\\",
+                        "description": "Backend URL (http, https or unix:///path/to.sock), optional weight, Host header choice, upstream TLS settings and protocol (required for POST, e.g., {\\",
                         "name": "body",
                         "in": "body",
                         "schema": {
@@ -157,11 +195,11 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of backends (GET)",
+                        "description": "List of backends (GET) or the updated backend (PATCH)",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Backend"
+                                "$ref": "#/definitions/api.BackendStatus"
                             }
                         }
                     },
@@ -204,7 +242,83 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Get, add, or delete backend servers.",
+                "description": "Get, add, delete backend servers or change their state (active, draining, maintenance).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Backends"
+                ],
+                "summary": "Manage backends",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Backend URL (required for DELETE)",
+                        "name": "url",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Backend URL (http, https or unix:///path/to.sock), optional weight, Host header choice, upstream TLS settings and protocol (required for POST, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of backends (GET) or the updated backend (PATCH)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.BackendStatus"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Backend added (POST)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "Backend deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Backend not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Backend already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Get, add, delete backend servers or change their state (active, draining, maintenance).",
                 "consumes": [
                     "application/json"
                 ],
@@ -223,7 +337,7 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "description": "Backend URL (required for POST, e.g., {\\",
+                        "description": "Backend URL (http, https or unix:///path/to.sock), optional weight, Host header choice, upstream TLS settings and protocol (required for POST, e.g., {\\",
                         "name": "body",
                         "in": "body",
                         "schema": {
@@ -233,11 +347,11 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of backends (GET)",
+                        "description": "List of backends (GET) or the updated backend (PATCH)",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Backend"
+                                "$ref": "#/definitions/api.BackendStatus"
                             }
                         }
                     },
@@ -280,6 +394,79 @@ const docTemplate = `{
                 }
             }
         },
+        "/backends/{id}/check": {
+            "post": {
+                "description": "Runs the backend's configured health probe immediately, updates its status and returns the result.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Backends"
+                ],
+                "summary": "Check backend health now",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Backend ID (e.g. backend1)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Probe result",
+                        "schema": {
+                            "$ref": "#/definitions/health.ProbeResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Backend not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/backends/{id}/health": {
+            "get": {
+                "description": "Returns the last probe results of a backend, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Backends"
+                ],
+                "summary": "Get backend health history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Backend ID (e.g. backend1)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Probe history",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/health.ProbeResult"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Backend not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/clients": {
             "get": {
                 "description": "Get, add, or delete client-specific rate-limiting configurations.",
@@ -510,9 +697,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/ratelimit": {
-            "patch": {
-                "description": "Update the global rate-limiting parameters (capacity and rate).",
+        "/livez": {
+            "get": {
+                "description": "Returns 200 while the process is up and the health check loop is making progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Probes"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Alive",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Health check loop is stuck or stopped",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pools": {
+            "get": {
+                "description": "Get, create, replace or delete named backend pools that routes send traffic to.",
                 "consumes": [
                     "application/json"
                 ],
@@ -520,84 +733,1409 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "RateLimit"
+                    "Routing"
                 ],
-                "summary": "Update global rate limit",
+                "summary": "Manage backend pools",
                 "parameters": [
                     {
-                        "description": "Rate limit parameters (e.g., {\\",
+                        "type": "string",
+                        "description": "Pool name (required for PUT and DELETE)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Pool definition (required for POST and PUT, e.g., {\\",
                         "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
                             "type": "object"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "List of pools (GET) or the updated pool (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.PoolStatus"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Pool created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/api.PoolStatus"
+                        }
+                    },
                     "204": {
-                        "description": "Rate limit updated",
+                        "description": "Pool deleted (DELETE)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Pool not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Pool already exists, is used by a route or was changed concurrently",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Failed to save configuration",
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.Backend": {
-            "type": "object",
-            "properties": {
-                "healthy": {
-                    "type": "boolean"
-                },
-                "lastChecked": {
-                    "type": "string"
-                },
-                "loggedHealthy": {
-                    "description": "Tracks if healthy status was logged",
-                    "type": "boolean"
-                },
-                "url": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.ClientConfig": {
-            "type": "object",
-            "properties": {
-                "capacity": {
-                    "type": "integer"
-                },
-                "client_id": {
-                    "type": "string"
-                },
-                "rate": {
-                    "type": "number"
+            },
+            "put": {
+                "description": "Get, create, replace or delete named backend pools that routes send traffic to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage backend pools",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pool name (required for PUT and DELETE)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Pool definition (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of pools (GET) or the updated pool (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.PoolStatus"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Pool created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/api.PoolStatus"
+                        }
+                    },
+                    "204": {
+                        "description": "Pool deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Pool not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Pool already exists, is used by a route or was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Get, create, replace or delete named backend pools that routes send traffic to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage backend pools",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pool name (required for PUT and DELETE)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Pool definition (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of pools (GET) or the updated pool (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.PoolStatus"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Pool created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/api.PoolStatus"
+                        }
+                    },
+                    "204": {
+                        "description": "Pool deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Pool not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Pool already exists, is used by a route or was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Get, create, replace or delete named backend pools that routes send traffic to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage backend pools",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pool name (required for PUT and DELETE)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Pool definition (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of pools (GET) or the updated pool (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.PoolStatus"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Pool created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/api.PoolStatus"
+                        }
+                    },
+                    "204": {
+                        "description": "Pool deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Pool not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Pool already exists, is used by a route or was changed concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ratelimit": {
+            "patch": {
+                "description": "Update the global rate-limiting parameters (capacity and rate).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "RateLimit"
+                ],
+                "summary": "Update global rate limit",
+                "parameters": [
+                    {
+                        "description": "Rate limit parameters (e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Rate limit updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save configuration",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Returns 200 when the configuration is loaded, the first round of health checks is done, at least one backend is healthy and Redis is reachable if required. Returns 503 during graceful shutdown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Probes"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ProbeResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/routes": {
+            "get": {
+                "description": "Get, create, replace or delete routing rules. Routes are evaluated in order and the first match selects the pool; requests matching no route go to the default pool.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID (required for PUT and DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Route (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of routes (GET) or the updated route (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Route"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Route created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/models.Route"
+                        }
+                    },
+                    "204": {
+                        "description": "Route deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Route not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Route already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Get, create, replace or delete routing rules. Routes are evaluated in order and the first match selects the pool; requests matching no route go to the default pool.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID (required for PUT and DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Route (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of routes (GET) or the updated route (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Route"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Route created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/models.Route"
+                        }
+                    },
+                    "204": {
+                        "description": "Route deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Route not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Route already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Get, create, replace or delete routing rules. Routes are evaluated in order and the first match selects the pool; requests matching no route go to the default pool.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID (required for PUT and DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Route (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of routes (GET) or the updated route (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Route"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Route created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/models.Route"
+                        }
+                    },
+                    "204": {
+                        "description": "Route deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Route not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Route already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Get, create, replace or delete routing rules. Routes are evaluated in order and the first match selects the pool; requests matching no route go to the default pool.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID (required for PUT and DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Route (required for POST and PUT, e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of routes (GET) or the updated route (PUT)",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Route"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Route created (POST)",
+                        "schema": {
+                            "$ref": "#/definitions/models.Route"
+                        }
+                    },
+                    "204": {
+                        "description": "Route deleted (DELETE)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Route not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Route already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/routes/{id}/split": {
+            "get": {
+                "description": "Get the traffic split of a route with the canary requests and failures counted at the current weight, or change it live. PATCH fields are optional and merged into the current split; a route without a split needs pool. Changing the weight of a rolled back split makes it active again unless state is given. Every change restarts the rollout interval and the error counters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage the traffic split of a route",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Split changes (PATCH), e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Traffic split of the route",
+                        "schema": {
+                            "$ref": "#/definitions/api.SplitStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Route not found or has no split",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Get the traffic split of a route with the canary requests and failures counted at the current weight, or change it live. PATCH fields are optional and merged into the current split; a route without a split needs pool. Changing the weight of a rolled back split makes it active again unless state is given. Every change restarts the rollout interval and the error counters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routing"
+                ],
+                "summary": "Manage the traffic split of a route",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Split changes (PATCH), e.g., {\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Traffic split of the route",
+                        "schema": {
+                            "$ref": "#/definitions/api.SplitStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Route not found or has no split",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BackendStatus": {
+            "type": "object",
+            "properties": {
+                "connections": {
+                    "description": "Open connections of the TCP listeners",
+                    "type": "integer"
+                },
+                "effectiveWeight": {
+                    "description": "Weight after slow-start ramp-up",
+                    "type": "number"
+                },
+                "healthCheck": {
+                    "description": "Optional probe settings, HTTP GET when nil",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.HealthCheckConfig"
+                        }
+                    ]
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "healthySince": {
+                    "description": "When the backend last became healthy, starts the slow-start window",
+                    "type": "string"
+                },
+                "hostHeader": {
+                    "description": "client (default) or backend, the Host header sent to the backend",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Stable identifier used by the admin API, e.g. \"backend1\"",
+                    "type": "string"
+                },
+                "inFlight": {
+                    "description": "Requests currently being proxied to the backend, including upgraded connections",
+                    "type": "integer"
+                },
+                "lastChecked": {
+                    "type": "string"
+                },
+                "loggedHealthy": {
+                    "description": "Tracks if healthy status was logged",
+                    "type": "boolean"
+                },
+                "protocol": {
+                    "description": "http1, h2c or empty for the default, overrides that of the pool",
+                    "type": "string"
+                },
+                "sessions": {
+                    "description": "Open sessions of the UDP listeners",
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "active (default), draining or maintenance",
+                    "type": "string"
+                },
+                "tls": {
+                    "description": "Upstream TLS settings, override those of the pool",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UpstreamTLSConfig"
+                        }
+                    ]
+                },
+                "upgraded": {
+                    "description": "Open WebSocket and other upgraded connections",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Relative share of traffic for weighted strategies, 1 when zero",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PoolStatus": {
+            "type": "object",
+            "properties": {
+                "backends": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BackendStatus"
+                    }
+                },
+                "health_check_interval": {
+                    "description": "Config.HealthCheckInterval when zero",
+                    "type": "string"
+                },
+                "health_check_path": {
+                    "description": "Config.HealthCheckPath when empty",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "protocol": {
+                    "description": "Upstream protocol of backends without their own",
+                    "type": "string"
+                },
+                "slow_start": {
+                    "description": "Config.SlowStart when zero",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SlowStartConfig"
+                        }
+                    ]
+                },
+                "strategy": {
+                    "description": "Config.Strategy when empty",
+                    "type": "string"
+                },
+                "timeouts": {
+                    "description": "Set fields override Config.Timeouts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TimeoutsConfig"
+                        }
+                    ]
+                },
+                "tls": {
+                    "description": "Upstream TLS settings of backends without their own",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UpstreamTLSConfig"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.ProbeCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ProbeResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.ProbeCheck"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SplitStatus": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "Of them, answered with 5xx or a gRPC server error, or not forwarded at all",
+                    "type": "integer"
+                },
+                "nextStepAt": {
+                    "description": "When the rollout moves to the next weight",
+                    "type": "string"
+                },
+                "pool": {
+                    "description": "Canary pool",
+                    "type": "string"
+                },
+                "requests": {
+                    "description": "Requests sent to the canary pool at the current weight",
+                    "type": "integer"
+                },
+                "rollout": {
+                    "$ref": "#/definitions/models.RolloutConfig"
+                },
+                "route": {
+                    "description": "ID of the route",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "active, paused or rolled_back",
+                    "type": "string"
+                },
+                "sticky_cookie": {
+                    "description": "Cookie set on the first response that keeps the client on its side",
+                    "type": "string"
+                },
+                "sticky_header": {
+                    "description": "Request header, e.g. X-User-ID, whose value assigns the client to a side",
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Percentage of requests sent to Pool, 0-100",
+                    "type": "integer"
+                }
+            }
+        },
+        "health.ProbeResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Backend": {
+            "type": "object",
+            "properties": {
+                "healthCheck": {
+                    "description": "Optional probe settings, HTTP GET when nil",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.HealthCheckConfig"
+                        }
+                    ]
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "healthySince": {
+                    "description": "When the backend last became healthy, starts the slow-start window",
+                    "type": "string"
+                },
+                "hostHeader": {
+                    "description": "client (default) or backend, the Host header sent to the backend",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Stable identifier used by the admin API, e.g. \"backend1\"",
+                    "type": "string"
+                },
+                "lastChecked": {
+                    "type": "string"
+                },
+                "loggedHealthy": {
+                    "description": "Tracks if healthy status was logged",
+                    "type": "boolean"
+                },
+                "protocol": {
+                    "description": "http1, h2c or empty for the default, overrides that of the pool",
+                    "type": "string"
+                },
+                "tls": {
+                    "description": "Upstream TLS settings, override those of the pool",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UpstreamTLSConfig"
+                        }
+                    ]
+                },
+                "url": {
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Relative share of traffic for weighted strategies, 1 when zero",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ClientConfig": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.HeaderRules": {
+            "type": "object",
+            "properties": {
+                "add": {
+                    "description": "Appended to the existing values",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "remove": {
+                    "description": "Header names to delete",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "set": {
+                    "description": "Replaces the existing values",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.HeadersConfig": {
+            "type": "object",
+            "properties": {
+                "request": {
+                    "$ref": "#/definitions/models.HeaderRules"
+                },
+                "response": {
+                    "$ref": "#/definitions/models.HeaderRules"
+                }
+            }
+        },
+        "models.HealthCheckConfig": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "host:port for tcp, udp and grpc checks, defaults to the backend URL host",
+                    "type": "string"
+                },
+                "command": {
+                    "description": "command and arguments for exec checks",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expect": {
+                    "description": "substring expected in the TCP or UDP reply",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "HTTP path, overrides Config.HealthCheckPath",
+                    "type": "string"
+                },
+                "send": {
+                    "description": "TCP payload written after connect, or the UDP datagram",
+                    "type": "string"
+                },
+                "service": {
+                    "description": "service name for grpc.health.v1.Health/Check",
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "per-probe timeout, 5s by default",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "http (default), tcp, udp, grpc or exec",
+                    "type": "string"
+                }
+            }
+        },
+        "models.RewriteConfig": {
+            "type": "object",
+            "properties": {
+                "add_prefix": {
+                    "description": "Prepended to the path",
+                    "type": "string"
+                },
+                "host": {
+                    "description": "Host header sent to the backend",
+                    "type": "string"
+                },
+                "regex": {
+                    "description": "RE2 expression matched against the escaped path",
+                    "type": "string"
+                },
+                "replacement": {
+                    "description": "Replacement for Regex, may reference capture groups as $1 or ${name}",
+                    "type": "string"
+                },
+                "strip_prefix": {
+                    "description": "Removed at a segment boundary: \"/billing\" turns \"/billing/v1/x\" into \"/v1/x\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.RolloutConfig": {
+            "type": "object",
+            "properties": {
+                "interval": {
+                    "description": "Time at each weight before the next step",
+                    "type": "string"
+                },
+                "max_error_rate": {
+                    "description": "Share of canary requests (0-1) answered with 5xx or a gRPC server error that rolls the split back; no rollback when zero",
+                    "type": "number"
+                },
+                "min_requests": {
+                    "description": "Canary requests at the current weight before the error rate is judged, 20 by default",
+                    "type": "integer"
+                },
+                "steps": {
+                    "description": "Increasing weights applied in order, e.g. [5, 25, 50, 100]",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.Route": {
+            "type": "object",
+            "properties": {
+                "flush_interval": {
+                    "description": "FlushInterval delays sending streaming responses (Server-Sent Events, chunked bodies)\nto the client by at most this long to batch small writes. Zero flushes every write.",
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "Applied after the global header rules",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.HeadersConfig"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "match": {
+                    "$ref": "#/definitions/models.RouteMatch"
+                },
+                "pool": {
+                    "type": "string"
+                },
+                "require_client_cert": {
+                    "description": "RequireClientCert rejects requests without a verified client certificate with 403,\nsee TLSConfig.ClientAuth. Requests on the plain port never carry one.",
+                    "type": "boolean"
+                },
+                "rewrite": {
+                    "$ref": "#/definitions/models.RewriteConfig"
+                },
+                "split": {
+                    "description": "Split sends a share of the matching requests to another pool, e.g. a canary release.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TrafficSplit"
+                        }
+                    ]
+                },
+                "timeouts": {
+                    "description": "Set fields override the timeouts of the global settings and the pool",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TimeoutsConfig"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.RouteMatch": {
+            "type": "object",
+            "properties": {
+                "headers": {
+                    "description": "Exact header values, an empty value only requires presence",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "host": {
+                    "description": "Exact host or \"*.example.com\", port is ignored",
+                    "type": "string"
+                },
+                "methods": {
+                    "description": "Any of the listed methods",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "path_prefix": {
+                    "description": "Matches at segment boundaries: \"/api\" matches \"/api\" and \"/api/x\" but not \"/apix\"",
+                    "type": "string"
+                },
+                "path_regex": {
+                    "description": "RE2 expression matched against the request path",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Exact query parameter values, an empty value only requires presence",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.SlowStartConfig": {
+            "type": "object",
+            "properties": {
+                "aggression": {
+                    "description": "Curve exponent, 1 is linear, larger values ramp up faster",
+                    "type": "number"
+                },
+                "min_weight_percent": {
+                    "description": "Weight share at the start of the window, 10 by default",
+                    "type": "number"
+                },
+                "window": {
+                    "description": "Ramp-up duration, disabled when zero",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TimeoutsConfig": {
+            "type": "object",
+            "properties": {
+                "connect": {
+                    "description": "Establishing the TCP connection",
+                    "type": "string"
+                },
+                "idle_read": {
+                    "description": "Longest wait for the next chunk of the response body",
+                    "type": "string"
+                },
+                "request": {
+                    "description": "Whole upstream exchange including the body",
+                    "type": "string"
+                },
+                "response_header": {
+                    "description": "From sending the request to receiving the response headers",
+                    "type": "string"
+                },
+                "tls_handshake": {
+                    "description": "TLS handshake with an https backend",
+                    "type": "string"
+                },
+                "upgrade_idle": {
+                    "description": "Connections that switched protocols (WebSocket and other Upgrade requests) are not bound\nby Request and IdleRead, these two limits apply instead.",
+                    "type": "string"
+                },
+                "upgrade_lifetime": {
+                    "description": "Maximum age of the connection",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TrafficSplit": {
+            "type": "object",
+            "properties": {
+                "pool": {
+                    "description": "Canary pool",
+                    "type": "string"
+                },
+                "rollout": {
+                    "$ref": "#/definitions/models.RolloutConfig"
+                },
+                "state": {
+                    "description": "active, paused or rolled_back",
+                    "type": "string"
+                },
+                "sticky_cookie": {
+                    "description": "Cookie set on the first response that keeps the client on its side",
+                    "type": "string"
+                },
+                "sticky_header": {
+                    "description": "Request header, e.g. X-User-ID, whose value assigns the client to a side",
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Percentage of requests sent to Pool, 0-100",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UpstreamTLSConfig": {
+            "type": "object",
+            "properties": {
+                "ca_file": {
+                    "description": "PEM bundle trusted instead of the system roots",
+                    "type": "string"
+                },
+                "cert_file": {
+                    "description": "Client certificate presented for mutual TLS",
+                    "type": "string"
+                },
+                "insecure_skip_verify": {
+                    "description": "Accept any server certificate, for development only",
+                    "type": "boolean"
+                },
+                "key_file": {
+                    "description": "Key of CertFile",
+                    "type": "string"
+                },
+                "server_name": {
+                    "description": "SNI and verified name, the URL host when empty",
+                    "type": "string"
                 }
             }
         }
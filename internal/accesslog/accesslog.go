@@ -0,0 +1,186 @@
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/statuswriter"
+)
+
+// Решения rate limiter, записываемые в Entry.RateLimit.
+const (
+	RateLimitAllowed  = "allowed"
+	RateLimitRejected = "rejected"
+)
+
+// Entry — запись access-лога об одном завершенном запросе.
+// Поля бэкенда и rate limiter заполняет обработчик через FromContext.
+type Entry struct {
+	Time            time.Time
+	RequestID       string
+	ClientIP        string
+	Method          string
+	Path            string
+	Proto           string
+	Host            string
+	Status          int
+	BytesIn         int64
+	BytesOut        int64
+	Upstream        string        // URL бэкенда, пусто, если запрос не дошел до бэкенда
+	Pool            string        // Пул бэкенда
+	UpstreamLatency time.Duration // Время обращения к бэкенду
+	Latency         time.Duration // Полное время обработки запроса
+	RateLimit       string        // allowed или rejected, пусто, если лимит не проверялся
+	UserAgent       string
+	Referer         string
+}
+
+type ctxKey struct{}
+
+// FromContext возвращает запись access-лога текущего запроса или nil, если лог выключен.
+func FromContext(ctx context.Context) *Entry {
+	e, _ := ctx.Value(ctxKey{}).(*Entry)
+	return e
+}
+
+// Logger пишет записи access-лога в выбранном формате. Nil Logger ничего не пишет.
+type Logger struct {
+	mu      sync.Mutex
+	format  formatter
+	out     io.WriteCloser
+	failing bool // Последняя запись не удалась; ошибка уже попала в журнал приложения
+}
+
+// New создает access-лог по настройкам. Для выключенного лога возвращает nil.
+func New(cfg models.AccessLogConfig) (*Logger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	format, err := newFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	out, err := newOutput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{format: format, out: out}, nil
+}
+
+// Validate проверяет формат, шаблон и вывод access-лога, не открывая файлов и соединений.
+func Validate(cfg models.AccessLogConfig) error {
+	if _, err := newFormatter(cfg); err != nil {
+		return err
+	}
+	switch cfg.Output {
+	case "", models.AccessLogStdout, models.AccessLogSyslog:
+	case models.AccessLogFile:
+		if cfg.Path == "" {
+			return fmt.Errorf("file access log requires a path")
+		}
+	default:
+		return fmt.Errorf("unknown access log output %q", cfg.Output)
+	}
+	if cfg.MaxSizeMB < 0 || cfg.MaxBackups < 0 {
+		return fmt.Errorf("access log rotation settings must not be negative")
+	}
+	return nil
+}
+
+// Log записывает одну запись.
+func (l *Logger) Log(e *Entry) {
+	if l == nil {
+		return
+	}
+	line := l.format(e)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Ошибка пишется в журнал приложения один раз до восстановления: при сломанном диске
+	// это была бы строка на каждый запрос
+	if _, err := l.out.Write(line); err != nil {
+		if !l.failing {
+			logger.ErrorKV("Failed to write access log", "error", err)
+		}
+		l.failing = true
+	} else if l.failing {
+		logger.Info("Access log writes resumed")
+		l.failing = false
+	}
+}
+
+// Close закрывает файл или соединение с syslog.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out.Close()
+}
+
+// Middleware пишет запись после завершения обработки каждого запроса.
+// Запись кладется в контекст запроса, чтобы обработчик мог дополнить ее.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		e := &Entry{
+			Time:      start,
+			RequestID: requestid.FromContext(r.Context()),
+			ClientIP:  r.RemoteAddr,
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Proto:     r.Proto,
+			Host:      r.Host,
+			UserAgent: r.UserAgent(),
+			Referer:   r.Referer(),
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			e.ClientIP = host
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, e))
+		var body *countingBody
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
+		rw := statuswriter.New(w)
+		next.ServeHTTP(rw, r)
+
+		e.Status = rw.Status()
+		if e.Status == 0 {
+			e.Status = http.StatusOK
+		}
+		e.BytesOut = rw.Bytes()
+		if body != nil {
+			e.BytesIn = body.bytes.Load()
+		}
+		e.Latency = time.Since(start)
+		l.Log(e)
+	})
+}
+
+// countingBody считает прочитанные байты тела запроса.
+// Тело читает транспорт ReverseProxy в своей горутине, поэтому счетчик атомарный.
+type countingBody struct {
+	io.ReadCloser
+	bytes atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,196 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/requestid"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type bufferCloser struct{ bytes.Buffer }
+
+func (*bufferCloser) Close() error { return nil }
+
+func newTestLogger(t *testing.T, cfg models.AccessLogConfig) (*Logger, *bufferCloser) {
+	t.Helper()
+	format, err := newFormatter(cfg)
+	if err != nil {
+		t.Fatalf("newFormatter failed: %v", err)
+	}
+	out := &bufferCloser{}
+	return &Logger{format: format, out: out}, out
+}
+
+func TestMiddleware_JSON(t *testing.T) {
+	l, out := newTestLogger(t, models.AccessLogConfig{})
+	handler := requestid.Middleware(l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		e := FromContext(r.Context())
+		e.RateLimit, e.Pool, e.Upstream, e.UpstreamLatency = RateLimitAllowed, "default", "http://backend1:80", 5*time.Millisecond
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})))
+
+	req := httptest.NewRequest("POST", "/orders?id=1", strings.NewReader("payload"))
+	req.RemoteAddr = "10.0.0.7:5555"
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("User-Agent", "curl/8.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", out.String(), err)
+	}
+	want := map[string]any{
+		"request_id":          "req-1",
+		"client_ip":           "10.0.0.7",
+		"method":              "POST",
+		"path":                "/orders?id=1",
+		"status":              float64(201),
+		"bytes_in":            float64(7),
+		"bytes_out":           float64(7),
+		"upstream":            "http://backend1:80",
+		"pool":                "default",
+		"upstream_latency_ms": float64(5),
+		"rate_limit":          "allowed",
+		"user_agent":          "curl/8.0",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %s %v, got %v", k, v, got[k])
+		}
+	}
+	if _, ok := got["latency_ms"]; !ok {
+		t.Error("Expected latency_ms in the record")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	e := &Entry{
+		Time:      time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC),
+		RequestID: "req-1",
+		ClientIP:  "10.0.0.7",
+		Method:    "GET",
+		Path:      `/search?q="x"`,
+		Proto:     "HTTP/1.1",
+		Status:    429,
+		Latency:   1500 * time.Microsecond,
+		RateLimit: RateLimitRejected,
+		UserAgent: "curl/8.0",
+	}
+	tests := []struct {
+		name string
+		cfg  models.AccessLogConfig
+		want string
+	}{
+		{
+			name: "Combined",
+			cfg:  models.AccessLogConfig{Format: models.AccessLogCombined},
+			want: `10.0.0.7 - - [01/Mar/2025:12:30:00 +0000] "GET /search?q=\"x\" HTTP/1.1" 429 - "-" "curl/8.0"` + "\n",
+		},
+		{
+			name: "Template",
+			cfg:  models.AccessLogConfig{Format: models.AccessLogTemplate, Template: "{request_id} {status} {rate_limit} {upstream} {latency_ms}ms"},
+			want: "req-1 429 rejected - 1.500ms\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := newFormatter(tt.cfg)
+			if err != nil {
+				t.Fatalf("newFormatter failed: %v", err)
+			}
+			if got := string(format(e)); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []models.AccessLogConfig{
+		{},
+		{Enabled: true, Format: models.AccessLogCombined, Output: models.AccessLogSyslog},
+		{Enabled: true, Output: models.AccessLogFile, Path: "/var/log/lb/access.log", MaxSizeMB: 10},
+	}
+	for _, cfg := range valid {
+		if err := Validate(cfg); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", cfg, err)
+		}
+	}
+	invalid := []models.AccessLogConfig{
+		{Format: "clf"},
+		{Format: models.AccessLogTemplate},
+		{Format: models.AccessLogTemplate, Template: "{client} {status}"},
+		{Output: "kafka"},
+		{Output: models.AccessLogFile},
+		{Output: models.AccessLogFile, Path: "access.log", MaxBackups: -1},
+	}
+	for _, cfg := range invalid {
+		if err := Validate(cfg); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestNew_Disabled(t *testing.T) {
+	l, err := New(models.AccessLogConfig{Format: models.AccessLogCombined})
+	if l != nil || err != nil {
+		t.Errorf("Expected nil logger for disabled access log, got %v, %v", l, err)
+	}
+	// Nil logger passes requests through and ignores Close
+	called := false
+	l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !called || l.Close() != nil {
+		t.Error("Expected nil logger to be a no-op")
+	}
+}
+
+// flakyWriter fails its writes while failing is set.
+type flakyWriter struct {
+	bufferCloser
+	failing bool
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.failing {
+		return 0, errors.New("disk full")
+	}
+	return w.bufferCloser.Write(p)
+}
+
+func TestLogger_WriteErrors(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger.SetLogger(zap.New(core))
+	t.Cleanup(logger.Init)
+
+	format, err := newFormatter(models.AccessLogConfig{})
+	if err != nil {
+		t.Fatalf("newFormatter failed: %v", err)
+	}
+	out := &flakyWriter{failing: true}
+	l := &Logger{format: format, out: out}
+	for i := 0; i < 3; i++ {
+		l.Log(&Entry{Method: "GET", Path: "/"})
+	}
+	if n := logs.FilterMessage("Failed to write access log").Len(); n != 1 {
+		t.Errorf("Expected the failure to be logged once, got %d", n)
+	}
+	out.failing = false
+	l.Log(&Entry{Method: "GET", Path: "/"})
+	if n := logs.FilterMessage("Access log writes resumed").Len(); n != 1 {
+		t.Errorf("Expected the recovery to be logged, got %d", n)
+	}
+}
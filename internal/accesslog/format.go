@@ -0,0 +1,167 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// formatter превращает запись в строку лога, завершенную переводом строки.
+type formatter func(e *Entry) []byte
+
+// placeholderPattern находит подстановки вида {name} в шаблоне.
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// placeholders — поддерживаемые подстановки шаблона.
+var placeholders = map[string]bool{
+	"{time}":                true,
+	"{request_id}":          true,
+	"{client_ip}":           true,
+	"{method}":              true,
+	"{path}":                true,
+	"{proto}":               true,
+	"{host}":                true,
+	"{status}":              true,
+	"{bytes_in}":            true,
+	"{bytes_out}":           true,
+	"{upstream}":            true,
+	"{pool}":                true,
+	"{upstream_latency_ms}": true,
+	"{latency_ms}":          true,
+	"{rate_limit}":          true,
+	"{user_agent}":          true,
+	"{referer}":             true,
+}
+
+// newFormatter выбирает формат по настройкам и проверяет шаблон.
+func newFormatter(cfg models.AccessLogConfig) (formatter, error) {
+	switch cfg.Format {
+	case "", models.AccessLogJSON:
+		return formatJSON, nil
+	case models.AccessLogCombined:
+		return formatCombined, nil
+	case models.AccessLogTemplate:
+		if cfg.Template == "" {
+			return nil, fmt.Errorf("template access log requires a template")
+		}
+		for _, p := range placeholderPattern.FindAllString(cfg.Template, -1) {
+			if !placeholders[p] {
+				return nil, fmt.Errorf("unknown access log placeholder %s", p)
+			}
+		}
+		return templateFormatter(cfg.Template), nil
+	default:
+		return nil, fmt.Errorf("unknown access log format %q", cfg.Format)
+	}
+}
+
+// jsonEntry — запись в формате JSON; длительности в миллисекундах.
+type jsonEntry struct {
+	Time              string  `json:"time"`
+	RequestID         string  `json:"request_id,omitempty"`
+	ClientIP          string  `json:"client_ip"`
+	Method            string  `json:"method"`
+	Path              string  `json:"path"`
+	Proto             string  `json:"proto"`
+	Host              string  `json:"host"`
+	Status            int     `json:"status"`
+	BytesIn           int64   `json:"bytes_in"`
+	BytesOut          int64   `json:"bytes_out"`
+	Upstream          string  `json:"upstream,omitempty"`
+	Pool              string  `json:"pool,omitempty"`
+	UpstreamLatencyMS float64 `json:"upstream_latency_ms,omitempty"`
+	LatencyMS         float64 `json:"latency_ms"`
+	RateLimit         string  `json:"rate_limit,omitempty"`
+	UserAgent         string  `json:"user_agent,omitempty"`
+	Referer           string  `json:"referer,omitempty"`
+}
+
+func formatJSON(e *Entry) []byte {
+	data, _ := json.Marshal(jsonEntry{
+		Time:              e.Time.UTC().Format(time.RFC3339Nano),
+		RequestID:         e.RequestID,
+		ClientIP:          e.ClientIP,
+		Method:            e.Method,
+		Path:              e.Path,
+		Proto:             e.Proto,
+		Host:              e.Host,
+		Status:            e.Status,
+		BytesIn:           e.BytesIn,
+		BytesOut:          e.BytesOut,
+		Upstream:          e.Upstream,
+		Pool:              e.Pool,
+		UpstreamLatencyMS: milliseconds(e.UpstreamLatency),
+		LatencyMS:         milliseconds(e.Latency),
+		RateLimit:         e.RateLimit,
+		UserAgent:         e.UserAgent,
+		Referer:           e.Referer,
+	})
+	return append(data, '\n')
+}
+
+// formatCombined пишет запись в формате Apache combined:
+// %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i".
+func formatCombined(e *Entry) []byte {
+	size := "-"
+	if e.BytesOut > 0 {
+		size = strconv.FormatInt(e.BytesOut, 10)
+	}
+	line := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+		e.ClientIP,
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, escapeQuoted(e.Path), e.Proto,
+		e.Status, size,
+		dash(escapeQuoted(e.Referer)), dash(escapeQuoted(e.UserAgent)),
+	)
+	return []byte(line)
+}
+
+// templateFormatter подставляет поля записи в шаблон; пустые значения записываются как "-".
+func templateFormatter(template string) formatter {
+	return func(e *Entry) []byte {
+		r := strings.NewReplacer(
+			"{time}", e.Time.UTC().Format(time.RFC3339Nano),
+			"{request_id}", dash(e.RequestID),
+			"{client_ip}", dash(e.ClientIP),
+			"{method}", e.Method,
+			"{path}", e.Path,
+			"{proto}", e.Proto,
+			"{host}", dash(e.Host),
+			"{status}", strconv.Itoa(e.Status),
+			"{bytes_in}", strconv.FormatInt(e.BytesIn, 10),
+			"{bytes_out}", strconv.FormatInt(e.BytesOut, 10),
+			"{upstream}", dash(e.Upstream),
+			"{pool}", dash(e.Pool),
+			"{upstream_latency_ms}", strconv.FormatFloat(milliseconds(e.UpstreamLatency), 'f', 3, 64),
+			"{latency_ms}", strconv.FormatFloat(milliseconds(e.Latency), 'f', 3, 64),
+			"{rate_limit}", dash(e.RateLimit),
+			"{user_agent}", dash(e.UserAgent),
+			"{referer}", dash(e.Referer),
+		)
+		// Перевод строки внутри значений сломал бы построчный разбор лога
+		line := strings.ReplaceAll(r.Replace(template), "\n", `\n`)
+		return []byte(line + "\n")
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// escapeQuoted экранирует кавычки и управляющие символы значения в кавычках.
+func escapeQuoted(s string) string {
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
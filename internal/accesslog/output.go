@@ -0,0 +1,143 @@
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"load-balancer/internal/models"
+)
+
+// Значения по умолчанию для ротации файла и syslog.
+const (
+	defaultMaxSizeMB  = 100
+	defaultMaxBackups = 5
+	defaultSyslogTag  = "load-balancer"
+)
+
+// newOutput открывает вывод access-лога.
+func newOutput(cfg models.AccessLogConfig) (io.WriteCloser, error) {
+	switch cfg.Output {
+	case "", models.AccessLogStdout:
+		return stdout{}, nil
+	case models.AccessLogFile:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("file access log requires a path")
+		}
+		maxSize, maxBackups := cfg.MaxSizeMB, cfg.MaxBackups
+		if maxSize == 0 {
+			maxSize = defaultMaxSizeMB
+		}
+		if maxBackups == 0 {
+			maxBackups = defaultMaxBackups
+		}
+		return openRotatingFile(cfg.Path, int64(maxSize)<<20, maxBackups)
+	case models.AccessLogSyslog:
+		tag := cfg.SyslogTag
+		if tag == "" {
+			tag = defaultSyslogTag
+		}
+		return dialSyslog(cfg.SyslogNetwork, cfg.SyslogAddress, tag)
+	default:
+		return nil, fmt.Errorf("unknown access log output %q", cfg.Output)
+	}
+}
+
+// stdout пишет в стандартный вывод и не закрывает его.
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdout) Close() error                { return nil }
+
+// rotatingFile — файл лога, который переименовывается в path.1, path.2, ...
+// при превышении maxSize. Хранится не более maxBackups старых файлов.
+// Вызовы синхронизирует Logger.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open access log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat access log: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.file == nil {
+		// Прошлое открытие не удалось: пробуем снова, чтобы лог возобновился сам
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	var rotateErr error
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if rotateErr = f.rotate(); f.file == nil {
+			return 0, rotateErr
+		}
+	}
+	// После неудачной ротации запись идет в прежний файл: лучше превысить max_size, чем потерять записи
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, rotateErr
+}
+
+// rotate сдвигает старые файлы на один номер, удаляя самый старый, и начинает новый файл.
+// Если сдвиг не удался, path открывается снова для дозаписи; f.file равен nil, только
+// если не удалось и это.
+func (f *rotatingFile) rotate() error {
+	err := f.shift()
+	if oerr := f.open(); oerr != nil {
+		f.file = nil
+		return errors.Join(err, oerr)
+	}
+	return err
+}
+
+func (f *rotatingFile) shift() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close access log: %w", err)
+	}
+	os.Remove(backupName(f.path, f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		os.Rename(backupName(f.path, i), backupName(f.path, i+1))
+	}
+	if err := os.Rename(f.path, backupName(f.path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate access log: %w", err)
+	}
+	return nil
+}
+
+func (f *rotatingFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,88 @@
+package accesslog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile failed: %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", name, content, data, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected at most 2 backups, got %s.3", path)
+	}
+
+	// Reopening appends to the current file
+	f, err = openRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatalf("openRotatingFile failed: %v", err)
+	}
+	f.Write([]byte("fifth\n"))
+	f.Close()
+	if data, _ := os.ReadFile(path); !strings.HasSuffix(string(data), "fourth\nfifth\n") {
+		t.Errorf("Expected appended record, got %q", data)
+	}
+}
+
+func TestRotatingFile_FailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	// A non-empty directory in place of the backup makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "busy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatalf("openRotatingFile failed: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("first\n"))
+	if _, err := f.Write([]byte("second\n")); err == nil {
+		t.Error("Expected the failed rotation to be reported")
+	}
+	if _, err := f.Write([]byte("third\n")); err == nil {
+		t.Error("Expected the rotation to be retried and reported")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first\nsecond\nthird\n" {
+		t.Errorf("Expected the records to be kept in the current file, got %q", data)
+	}
+
+	// The next write after the cause is gone rotates as usual
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("fourth\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "fourth\n" {
+		t.Errorf("Expected a new file after rotation, got %q", data)
+	}
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "first\nsecond\nthird\n" {
+		t.Errorf("Expected the old records in the backup, got %q", data)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package accesslog
+
+import (
+	"fmt"
+	"io"
+)
+
+// dialSyslog: на этой платформе нет пакета log/syslog.
+func dialSyslog(network, addr, tag string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("syslog access log is not supported on this platform")
+}
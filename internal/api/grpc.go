@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// grpcCodes maps the HTTP status of a balancer error to the gRPC status code clients expect.
+var grpcCodes = map[int]codes.Code{
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.Unimplemented,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusBadGateway:            codes.Unavailable,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+	http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+}
+
+// grpcServerErrors are the gRPC status codes that gRPC maps to a 5xx HTTP status. A canary
+// counts them as failures, like a 5xx response, and ignores client errors such as NOT_FOUND.
+var grpcServerErrors = map[codes.Code]bool{
+	codes.Unknown:          true,
+	codes.DeadlineExceeded: true,
+	codes.Unimplemented:    true,
+	codes.Internal:         true,
+	codes.Unavailable:      true,
+	codes.DataLoss:         true,
+}
+
+// isGRPCServerError reports whether a grpc-status value is a gRPC server error.
+func isGRPCServerError(status string) bool {
+	code, err := strconv.Atoi(strings.TrimSpace(status))
+	if err != nil {
+		// gRPC clients fail a call with an unparsable status as well
+		return true
+	}
+	return grpcServerErrors[codes.Code(code)]
+}
+
+// isGRPC reports whether the request is a gRPC call, by its application/grpc content type.
+func isGRPC(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// sendRequestError answers a proxied request with an error: gRPC clients get a gRPC status,
+// since they cannot read a JSON body, everyone else gets an ErrorResponse.
+func (s *Server) sendRequestError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	if isGRPC(r) {
+		sendGRPCError(w, code, message)
+		return
+	}
+	s.sendError(w, code, message)
+}
+
+// sendGRPCError writes a trailers-only gRPC response: HTTP 200 whose headers carry
+// grpc-status and grpc-message, as a gRPC server does for a call that fails immediately.
+func sendGRPCError(w http.ResponseWriter, code int, message string) {
+	status, ok := grpcCodes[code]
+	if !ok {
+		status = codes.Unknown
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(status)))
+	w.Header().Set("Grpc-Message", encodeGRPCMessage(message))
+	w.WriteHeader(http.StatusOK)
+}
+
+// encodeGRPCMessage percent-encodes the message as the gRPC protocol requires for grpc-message.
+func encodeGRPCMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// newGRPCBackend starts a plaintext gRPC backend that implements grpc.health.v1.Health.
+func newGRPCBackend(t *testing.T) (string, *grpchealth.Server) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	healthSrv := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	return ln.Addr().String(), healthSrv
+}
+
+func TestServer_GRPC(t *testing.T) {
+	logger.Init()
+	backendAddr, backendHealth := newGRPCBackend(t)
+	backend := &models.Backend{URL: "http://" + backendAddr, Healthy: true, Protocol: models.ProtocolH2C}
+	cfg := &models.Config{
+		Port:                ":8087",
+		Backends:            []*models.Backend{backend},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 4, Rate: 0.001},
+		Server:              models.ServerConfig{H2C: true},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(ln)
+	defer func() {
+		server.mu.RLock()
+		srv := server.server
+		server.mu.RUnlock()
+		if srv != nil {
+			srv.Close()
+		}
+	}()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Unary call over h2c", func(t *testing.T) {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected SERVING, got %v", resp.Status)
+		}
+	})
+
+	t.Run("Backend status is passed through in trailers", func(t *testing.T) {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound from the backend, got %v", err)
+		}
+	})
+
+	t.Run("Server stream is not buffered", func(t *testing.T) {
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+		for _, want := range []healthpb.HealthCheckResponse_ServingStatus{healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING} {
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Recv failed: %v", err)
+			}
+			if resp.Status != want {
+				t.Fatalf("Expected %v, got %v", want, resp.Status)
+			}
+			// The next message is sent only after this one has arrived through the balancer
+			backendHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+	})
+
+	t.Run("Plain HTTP/1.1 still works on the h2c port", func(t *testing.T) {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/api/unknown")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.ProtoMajor != 1 || resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON error over HTTP/1.1, got %s %q", resp.Proto, resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("No healthy backends is Unavailable", func(t *testing.T) {
+		server.mu.Lock()
+		backend.Healthy = false
+		server.mu.Unlock()
+		defer func() {
+			server.mu.Lock()
+			backend.Healthy = true
+			server.mu.Unlock()
+		}()
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if st := status.Convert(err); st.Code() != codes.Unavailable || st.Message() != "No healthy backends available" {
+			t.Errorf("Expected Unavailable with the balancer message, got %v", err)
+		}
+	})
+
+	t.Run("Rate limit is ResourceExhausted", func(t *testing.T) {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("Expected ResourceExhausted, got %v", err)
+		}
+	})
+}
+
+func TestServer_ShutdownDrainsH2C(t *testing.T) {
+	logger.Init()
+	release := make(chan struct{})
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first "))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+			w.Write([]byte("last"))
+		case <-r.Context().Done():
+		}
+	}))
+	defer backendServer.Close()
+
+	// openStream starts a streaming response over h2c and returns it after its first part
+	openStream := func(drainTimeout time.Duration) (*Server, *http.Response) {
+		cfg := &models.Config{
+			Port:                ":8087",
+			Backends:            []*models.Backend{{URL: backendServer.URL, Healthy: true}},
+			HealthCheckPath:     "/health",
+			HealthCheckInterval: 5 * time.Second,
+			RateLimit:           models.RateLimitConfig{Capacity: 10, Rate: 1},
+			Server:              models.ServerConfig{H2C: true},
+			Shutdown:            models.ShutdownConfig{DrainTimeout: models.Duration(drainTimeout)},
+		}
+		server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		go server.Serve(ln)
+
+		client := &http.Client{Timeout: 5 * time.Second, Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}}
+		resp, err := client.Get("http://" + ln.Addr().String() + "/stream")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		if resp.ProtoMajor != 2 {
+			t.Fatalf("Expected HTTP/2, got %s", resp.Proto)
+		}
+		first := make([]byte, len("first "))
+		if _, err := io.ReadFull(resp.Body, first); err != nil {
+			t.Fatalf("Failed to read the first part: %v", err)
+		}
+		return server, resp
+	}
+	shutdown := func(server *Server) <-chan error {
+		done := make(chan error, 1)
+		go func() { done <- server.Shutdown(context.Background()) }()
+		return done
+	}
+
+	t.Run("Open stream completes", func(t *testing.T) {
+		server, resp := openStream(5 * time.Second)
+		done := shutdown(server)
+		select {
+		case err := <-done:
+			t.Fatalf("Shutdown returned with a stream in flight: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		release <- struct{}{}
+		rest, err := io.ReadAll(resp.Body)
+		if err != nil || string(rest) != "last" {
+			t.Errorf("Expected the rest of the stream, got %q (%v)", rest, err)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Expected clean shutdown, got %v", err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Shutdown did not return after the stream completed")
+		}
+	})
+
+	t.Run("Drain deadline closes stream", func(t *testing.T) {
+		server, resp := openStream(300 * time.Millisecond)
+		select {
+		case err := <-shutdown(server):
+			if err == nil {
+				t.Error("Expected drain deadline error")
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Shutdown did not return after the drain deadline")
+		}
+		if _, err := io.ReadAll(resp.Body); err == nil {
+			t.Error("Expected the stream to be cut after the drain deadline")
+		}
+	})
+}
+
+func TestEncodeGRPCMessage(t *testing.T) {
+	tests := map[string]string{
+		"Rate limit exceeded": "Rate limit exceeded",
+		"100% busy":           "100%25 busy",
+		"line\nbreak":         "line%0Abreak",
+		"привет":              "%D0%BF%D1%80%D0%B8%D0%B2%D0%B5%D1%82",
+	}
+	for message, want := range tests {
+		if got := encodeGRPCMessage(message); got != want {
+			t.Errorf("encodeGRPCMessage(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
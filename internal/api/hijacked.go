@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// hijackedPollInterval is how often Shutdown checks whether the hijacked connections are closed.
+const hijackedPollInterval = 10 * time.Millisecond
+
+// hijackedConns tracks the connections the public http.Server handed over to a handler,
+// e.g. to h2c for HTTP/2 without TLS. The server forgets hijacked connections, so its
+// Shutdown neither waits for nor closes them.
+type hijackedConns struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func newHijackedConns() *hijackedConns {
+	return &hijackedConns{conns: make(map[net.Conn]struct{})}
+}
+
+// listener wraps ln so that its connections stop being tracked once they are closed.
+func (h *hijackedConns) listener(ln net.Listener) net.Listener {
+	return &trackingListener{Listener: ln, hijacked: h}
+}
+
+// connState is the http.Server.ConnState hook that starts tracking hijacked connections.
+func (h *hijackedConns) connState(conn net.Conn, state http.ConnState) {
+	if state != http.StateHijacked {
+		return
+	}
+	h.mu.Lock()
+	h.conns[conn] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *hijackedConns) forget(conn net.Conn) {
+	h.mu.Lock()
+	delete(h.conns, conn)
+	h.mu.Unlock()
+}
+
+func (h *hijackedConns) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns)
+}
+
+// wait returns once every hijacked connection is closed or ctx is done.
+func (h *hijackedConns) wait(ctx context.Context) error {
+	ticker := time.NewTicker(hijackedPollInterval)
+	defer ticker.Stop()
+	for h.count() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// closeAll closes the hijacked connections that are still open.
+func (h *hijackedConns) closeAll() {
+	h.mu.Lock()
+	conns := make([]net.Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// trackingListener hands out connections that report their closing to hijackedConns.
+type trackingListener struct {
+	net.Listener
+	hijacked *hijackedConns
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &trackedConn{Conn: conn, hijacked: l.hijacked}, nil
+}
+
+type trackedConn struct {
+	net.Conn
+	hijacked *hijackedConns
+}
+
+func (c *trackedConn) Close() error {
+	c.hijacked.forget(c)
+	return c.Conn.Close()
+}
+
+// publicServer is the public http.Server together with its hijacked connections. Shutdown
+// also waits for them, e.g. for the streams of h2c connections to finish after GOAWAY, and
+// Close closes them.
+type publicServer struct {
+	*http.Server
+	hijacked *hijackedConns
+}
+
+func (s publicServer) Shutdown(ctx context.Context) error {
+	if err := s.Server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return s.hijacked.wait(ctx)
+}
+
+func (s publicServer) Close() error {
+	err := s.Server.Close()
+	s.hijacked.closeAll()
+	return err
+}
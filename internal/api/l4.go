@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/l4"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+// newL4Proxies creates a proxy for every listener of cfg.TCPListeners and cfg.UDPListeners.
+// TCP connections and UDP sessions are balanced over the listener's pool with its strategy;
+// new TCP connections and every UDP datagram are rate-limited per client IP. Datagrams go
+// through AllowPacket, which neither logs nor persists every packet.
+func (s *Server) newL4Proxies() {
+	allowConn := func(ctx context.Context, clientIP string) bool {
+		return s.rateLimiter.AllowContext(ctx, clientIP)
+	}
+	allowPacket := func(ctx context.Context, clientIP string) bool {
+		return s.rateLimiter.AllowPacket(clientIP)
+	}
+	for _, l := range s.cfg.TCPListeners {
+		s.tcpProxies = append(s.tcpProxies, l4.NewTCPProxy(l, s.poolPicker(l.Pool), allowConn))
+	}
+	for _, l := range s.cfg.UDPListeners {
+		s.udpProxies = append(s.udpProxies, l4.NewUDPProxy(l, s.poolPicker(l.Pool), allowPacket))
+	}
+}
+
+// poolPicker returns a picker of backends from the current balancer of the named pool.
+func (s *Server) poolPicker(pool string) l4.Picker {
+	return func(clientIP string) *models.Backend {
+		lb := s.poolBalancer(pool)
+		if lb == nil {
+			return nil
+		}
+		return balancer.Pick(lb, clientIP)
+	}
+}
+
+// poolBalancer returns the current balancer of the named pool, or nil if there is no such pool.
+func (s *Server) poolBalancer(name string) balancer.BalancerInterface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name == models.DefaultPool {
+		return s.balancer
+	}
+	return s.pools[name]
+}
+
+// tcpProxy returns the TCP proxy of the named listener, or nil.
+func (s *Server) tcpProxy(name string) *l4.TCPProxy {
+	for _, p := range s.tcpProxies {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// StartTCP launches the TCP listener with the given name from cfg.TCPListeners.
+func (s *Server) StartTCP(name string) error {
+	for _, l := range s.cfg.TCPListeners {
+		if l.Name != name {
+			continue
+		}
+		ln, err := net.Listen("tcp", ":"+l.Port)
+		if err != nil {
+			return err
+		}
+		logger.InfoKV("Starting TCP listener", "name", name, "port", l.Port, "pool", l.Pool)
+		return s.ServeTCP(name, ln)
+	}
+	return fmt.Errorf("unknown tcp listener %s", name)
+}
+
+// ServeTCP accepts connections for the named TCP listener until the server is shut down.
+func (s *Server) ServeTCP(name string, ln net.Listener) error {
+	p := s.tcpProxy(name)
+	if p == nil {
+		ln.Close()
+		return fmt.Errorf("unknown tcp listener %s", name)
+	}
+	return p.Serve(ln)
+}
+
+// tcpConnections returns the number of open TCP connections to the backend over all TCP listeners.
+func (s *Server) tcpConnections(backendURL string) int {
+	total := 0
+	for _, p := range s.tcpProxies {
+		total += p.Connections(backendURL)
+	}
+	return total
+}
+
+// closeTCPConnections closes the TCP connections to the backend and returns how many were open.
+func (s *Server) closeTCPConnections(backendURL string) int {
+	total := 0
+	for _, p := range s.tcpProxies {
+		total += p.CloseConnections(backendURL)
+	}
+	return total
+}
+
+// udpProxy returns the UDP proxy of the named listener, or nil.
+func (s *Server) udpProxy(name string) *l4.UDPProxy {
+	for _, p := range s.udpProxies {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// StartUDP launches the UDP listener with the given name from cfg.UDPListeners.
+func (s *Server) StartUDP(name string) error {
+	for _, l := range s.cfg.UDPListeners {
+		if l.Name != name {
+			continue
+		}
+		conn, err := net.ListenPacket("udp", ":"+l.Port)
+		if err != nil {
+			return err
+		}
+		logger.InfoKV("Starting UDP listener", "name", name, "port", l.Port, "pool", l.Pool)
+		return s.ServeUDP(name, conn)
+	}
+	return fmt.Errorf("unknown udp listener %s", name)
+}
+
+// ServeUDP relays datagrams for the named UDP listener until the server is shut down.
+func (s *Server) ServeUDP(name string, conn net.PacketConn) error {
+	p := s.udpProxy(name)
+	if p == nil {
+		conn.Close()
+		return fmt.Errorf("unknown udp listener %s", name)
+	}
+	return p.Serve(conn)
+}
+
+// udpSessions returns the number of UDP sessions with the backend over all UDP listeners.
+func (s *Server) udpSessions(backendURL string) int {
+	total := 0
+	for _, p := range s.udpProxies {
+		total += p.Sessions(backendURL)
+	}
+	return total
+}
+
+// closeUDPSessions closes the UDP sessions with the backend and returns how many were open.
+// The next datagram of each client opens a session with another backend.
+func (s *Server) closeUDPSessions(backendURL string) int {
+	total := 0
+	for _, p := range s.udpProxies {
+		total += p.CloseSessions(backendURL)
+	}
+	return total
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/l4/l4test"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/testutil"
+)
+
+// tcpGreeting connects to addr and returns the connection with the name of the backend
+// that answered, or an empty name if the connection was closed.
+func tcpGreeting(t *testing.T, addr string) (net.Conn, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	return conn, strings.TrimSpace(line)
+}
+
+func TestServer_TCPListener(t *testing.T) {
+	logger.Init()
+	backends := []*models.Backend{
+		l4test.StartTCPBackend(t, "db1"),
+		l4test.StartTCPBackend(t, "db2"),
+	}
+	cfg := &models.Config{
+		Port:                ":8087",
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 3, Rate: 0.001},
+		Shutdown:            models.ShutdownConfig{DrainTimeout: models.Duration(5 * time.Second)},
+		Pools:               []*models.Pool{{Name: "db", Backends: backends, Strategy: models.StrategyLeastConnections}},
+		TCPListeners:        []models.TCPListenerConfig{{Name: "postgres", Port: "5432", Pool: "db"}},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.ServeTCP("postgres", ln)
+	addr := ln.Addr().String()
+
+	connections := func() []int {
+		counts := make([]int, len(backends))
+		for i, b := range backends {
+			counts[i] = server.backendStatus(b).Connections
+		}
+		return counts
+	}
+
+	// Least connections counts open TCP connections, so the two connections land on different backends
+	conn1, first := tcpGreeting(t, addr)
+	_, second := tcpGreeting(t, addr)
+	if first == "" || second == "" || first == second {
+		t.Fatalf("Expected connections on different backends, got %q and %q", first, second)
+	}
+	if counts := connections(); counts[0] != 1 || counts[1] != 1 {
+		t.Fatalf("Expected one connection per backend, got %v", counts)
+	}
+	if status := server.backendStatus(backends[0]); status.InFlight != 1 {
+		t.Errorf("Expected the connection to count as in flight, got %d", status.InFlight)
+	}
+
+	t.Run("Pool in use cannot be deleted", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.handlePools(rr, httptest.NewRequest("DELETE", "/api/pools?name=db", nil))
+		if rr.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Maintenance closes the backend's connections", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		body := `{"url": "` + backends[0].URL + `", "state": "maintenance"}`
+		server.handleBackends(rr, httptest.NewRequest("PATCH", "/api/backends", bytes.NewBufferString(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		if !testutil.WaitFor(func() bool { return backends[0].InFlight() == 0 && connections()[0] == 0 }) {
+			t.Errorf("Expected backend in maintenance to have no connections, got %v", connections())
+		}
+		if counts := connections(); counts[1] != 1 {
+			t.Errorf("Expected the other backend to keep its connection, got %v", counts)
+		}
+	})
+
+	t.Run("Rate limit applies per connection", func(t *testing.T) {
+		if _, name := tcpGreeting(t, addr); name != "db2" {
+			t.Errorf("Expected the third connection on db2, got %q", name)
+		}
+		if _, name := tcpGreeting(t, addr); name != "" {
+			t.Errorf("Expected the connection over the rate limit to be closed, got %q", name)
+		}
+	})
+
+	t.Run("Shutdown waits for open connections", func(t *testing.T) {
+		conn1.Close()
+		done := make(chan error, 1)
+		go func() { done <- server.Shutdown(context.Background()) }()
+		if !testutil.WaitFor(func() bool {
+			c, err := net.Dial("tcp", addr)
+			if err == nil {
+				c.Close()
+			}
+			return err != nil
+		}) {
+			t.Fatal("Expected the TCP listener to be closed")
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("Shutdown returned with open connections: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		server.closeTCPConnections("")
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Expected clean shutdown, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Shutdown did not return after the connections closed")
+		}
+	})
+}
+
+func TestServer_UDPListener(t *testing.T) {
+	logger.Init()
+	backends := []*models.Backend{
+		l4test.StartUDPBackend(t, "dns1", 0),
+		l4test.StartUDPBackend(t, "dns2", 0),
+	}
+	cfg := &models.Config{
+		Port:                ":8087",
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 4, Rate: 0.001},
+		Shutdown:            models.ShutdownConfig{DrainTimeout: models.Duration(5 * time.Second)},
+		Pools:               []*models.Pool{{Name: "dns", Backends: backends, Strategy: models.StrategyLeastConnections}},
+		UDPListeners:        []models.UDPListenerConfig{{Name: "dns", Port: "53", Pool: "dns"}},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.ServeUDP("dns", conn)
+	addr := conn.LocalAddr().String()
+
+	client := func() net.Conn {
+		c, err := net.Dial("udp", addr)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+	sessions := func() []int {
+		counts := make([]int, len(backends))
+		for i, b := range backends {
+			counts[i] = server.backendStatus(b).Sessions
+		}
+		return counts
+	}
+
+	// Least connections counts open sessions, so two clients land on different backends
+	client1, client2 := client(), client()
+	first := l4test.Exchange(client1, "a", 300*time.Millisecond)
+	second := l4test.Exchange(client2, "b", 300*time.Millisecond)
+	if first == "" || second == "" || first == second {
+		t.Fatalf("Expected clients on different backends, got %q and %q", first, second)
+	}
+	if counts := sessions(); counts[0] != 1 || counts[1] != 1 {
+		t.Fatalf("Expected one session per backend, got %v", counts)
+	}
+
+	t.Run("Pool in use cannot be deleted", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.handlePools(rr, httptest.NewRequest("DELETE", "/api/pools?name=dns", nil))
+		if rr.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Maintenance moves the backend's sessions", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		body := `{"url": "` + backends[0].URL + `", "state": "maintenance"}`
+		server.handleBackends(rr, httptest.NewRequest("PATCH", "/api/backends", bytes.NewBufferString(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		if !testutil.WaitFor(func() bool { return backends[0].InFlight() == 0 && sessions()[0] == 0 }) {
+			t.Errorf("Expected backend in maintenance to have no sessions, got %v", sessions())
+		}
+		owner := client1
+		if first != "dns1" {
+			owner = client2
+		}
+		if name := l4test.Exchange(owner, "c", 300*time.Millisecond); name != "dns2" {
+			t.Errorf("Expected the client of dns1 to move to dns2, got %q", name)
+		}
+	})
+
+	t.Run("Rate limit applies per datagram", func(t *testing.T) {
+		if name := l4test.Exchange(client1, "d", 300*time.Millisecond); name != "dns2" {
+			t.Errorf("Expected a reply to the fourth datagram, got %q", name)
+		}
+		if name := l4test.Exchange(client1, "e", 300*time.Millisecond); name != "" {
+			t.Errorf("Expected the datagram over the rate limit to be dropped, got a reply from %q", name)
+		}
+	})
+
+	t.Run("Shutdown ends quiet sessions", func(t *testing.T) {
+		start := time.Now()
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("Shutdown took %v, expected sessions to end after a second of silence", elapsed)
+		}
+		if counts := sessions(); counts[0] != 0 || counts[1] != 0 {
+			t.Errorf("Expected no sessions after shutdown, got %v", counts)
+		}
+	})
+}
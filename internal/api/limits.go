@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+// newHTTPServer builds the public http.Server with the timeouts and header limit of cfg.
+// cfg must already have its defaults applied. Shutdown does not wait for hijacked
+// connections, so the upgraded ones (WebSocket) are closed as soon as it begins.
+func (s *Server) newHTTPServer(handler http.Handler, cfg models.ServerConfig) *http.Server {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout.Std(),
+		ReadTimeout:       cfg.ReadTimeout.Std(),
+		WriteTimeout:      cfg.WriteTimeout.Std(),
+		IdleTimeout:       cfg.IdleTimeout.Std(),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	srv.RegisterOnShutdown(func() {
+		if closed := s.proxy.CloseUpgraded(""); closed > 0 {
+			logger.InfoKV("Closed upgraded connections", "count", closed)
+		}
+	})
+	return srv
+}
+
+// limitBody rejects requests whose declared body exceeds server.max_body_bytes with 413
+// and caps the body of all others, so a chunked body cannot grow past the limit either.
+func (s *Server) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := s.cfg.Server.WithDefaults().MaxBodyBytes
+		if r.ContentLength > limit {
+			s.sendBodyTooLarge(w, r)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sendBodyTooLarge answers 413 for a request body over server.max_body_bytes.
+func (s *Server) sendBodyTooLarge(w http.ResponseWriter, r *http.Request) {
+	s.sendRequestError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", s.cfg.Server.WithDefaults().MaxBodyBytes))
+}
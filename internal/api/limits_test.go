@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+// serveWithLimits starts the public listener with the given limits in front of backendURL
+// and returns its address.
+func serveWithLimits(t *testing.T, backendURL string, limits models.ServerConfig) string {
+	t.Helper()
+	cfg := &models.Config{
+		Port:                ":8087",
+		Backends:            []*models.Backend{{URL: backendURL, Healthy: true}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 100, Rate: 100},
+		Server:              limits,
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() {
+		server.mu.RLock()
+		srv := server.server
+		server.mu.RUnlock()
+		if srv != nil {
+			srv.Close()
+		}
+	})
+	return ln.Addr().String()
+}
+
+// waitClosed reads from conn until the server closes it and fails if that takes longer than limit.
+func waitClosed(t *testing.T, conn net.Conn, limit time.Duration) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(limit))
+	_, err := io.Copy(io.Discard, conn)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatalf("Expected the server to close the connection within %v", limit)
+	}
+}
+
+func TestServer_SlowClients(t *testing.T) {
+	logger.Init()
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	addr := serveWithLimits(t, backendServer.URL, models.ServerConfig{
+		ReadHeaderTimeout: models.Duration(100 * time.Millisecond),
+		ReadTimeout:       models.Duration(300 * time.Millisecond),
+	})
+
+	t.Run("Headers trickling in are cut off", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer conn.Close()
+		// Slowloris: the request line arrives, the rest of the headers never do
+		conn.Write([]byte("GET / HTTP/1.1\r\nHost: lb\r\n"))
+		waitClosed(t, conn, 2*time.Second)
+	})
+
+	t.Run("Stalled body is cut off", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("POST / HTTP/1.1\r\nHost: lb\r\nContent-Length: 100\r\n\r\npartial"))
+		waitClosed(t, conn, 2*time.Second)
+	})
+
+	t.Run("Prompt client is served", func(t *testing.T) {
+		resp, err := http.Get("http://" + addr + "/")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestServer_RequestSizeLimits(t *testing.T) {
+	logger.Init()
+	var received atomic.Int64 // Written by the backend handler, which may still run when the client gets 413
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received.Store(n)
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	addr := serveWithLimits(t, backendServer.URL, models.ServerConfig{MaxHeaderBytes: 1024, MaxBodyBytes: 16})
+
+	tests := []struct {
+		name   string
+		body   io.Reader
+		header string
+		want   int
+	}{
+		{name: "Body within the limit", body: strings.NewReader("small"), want: http.StatusOK},
+		{name: "Declared body over the limit", body: strings.NewReader(strings.Repeat("x", 17)), want: http.StatusRequestEntityTooLarge},
+		// io.MultiReader hides the length, so the body is sent chunked and checked while it is read
+		{name: "Chunked body over the limit", body: io.MultiReader(strings.NewReader(strings.Repeat("x", 64))), want: http.StatusRequestEntityTooLarge},
+		// net/http reads up to 4 KB past max_header_bytes before answering 431
+		{name: "Headers over the limit", body: strings.NewReader(""), header: strings.Repeat("x", 8192), want: http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "http://"+addr+"/", tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Padding", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			if tt.want != http.StatusRequestEntityTooLarge {
+				return
+			}
+			var errResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if errResp.Code != http.StatusRequestEntityTooLarge || errResp.Message != "Request body exceeds 16 bytes" {
+				t.Errorf("Unexpected error response: %+v", errResp)
+			}
+			if n := received.Load(); n > 16 {
+				t.Errorf("Expected the backend to get at most 16 bytes, got %d", n)
+			}
+		})
+	}
+}
+
+func TestServer_MaxConnections(t *testing.T) {
+	logger.Init()
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	addr := serveWithLimits(t, backendServer.URL, models.ServerConfig{MaxConnections: 1})
+
+	first, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer first.Close()
+	if _, err := first.Write([]byte("GET / HTTP/1.1\r\nHost: lb\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(first), nil)
+	if err != nil {
+		t.Fatalf("Failed to read response on the first connection: %v", err)
+	}
+	resp.Body.Close()
+
+	// The first connection stays open, so the second one is not accepted yet
+	second, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer second.Close()
+	second.Write([]byte("GET / HTTP/1.1\r\nHost: lb\r\nConnection: close\r\n\r\n"))
+	reader := bufio.NewReader(second)
+	second.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err := reader.Peek(1); err == nil {
+		t.Fatal("Expected the second connection to wait while the limit is reached")
+	}
+
+	first.Close()
+	second.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err = http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Expected the second connection to be served once the first closed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_StreamingWriteTimeout(t *testing.T) {
+	logger.Init()
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for i := 0; i < 3; i++ {
+				w.Write([]byte("data: tick\n\n"))
+				w.(http.Flusher).Flush()
+				time.Sleep(100 * time.Millisecond)
+			}
+			return
+		}
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Length", "2")
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	addr := serveWithLimits(t, backendServer.URL, models.ServerConfig{WriteTimeout: models.Duration(150 * time.Millisecond)})
+
+	t.Run("Slow regular response is cut off", func(t *testing.T) {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			resp.Body.Close()
+			t.Fatalf("Expected the write timeout to drop the response, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Event stream outlives the write timeout", func(t *testing.T) {
+		resp, err := http.Get("http://" + addr + "/events")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Stream was cut off: %v", err)
+		}
+		if got := strings.Count(string(body), "data: tick"); got != 3 {
+			t.Errorf("Expected 3 events, got %d in %q", got, body)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// redisPingTimeout bounds the Redis check of the readiness probe.
+const redisPingTimeout = time.Second
+
+// ProbeCheck is the result of one condition evaluated by /livez or /readyz.
+type ProbeCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ProbeResponse is the JSON body returned by /livez and /readyz.
+type ProbeResponse struct {
+	Status string                `json:"status"`
+	Checks map[string]ProbeCheck `json:"checks"`
+}
+
+// handleLivez reports whether the balancer process is working.
+// @Summary Liveness probe
+// @Description Returns 200 while the process is up and the health check loop is making progress.
+// @Tags Probes
+// @Produce json
+// @Success 200 {object} ProbeResponse "Alive"
+// @Failure 503 {object} ProbeResponse "Health check loop is stuck or stopped"
+// @Router /livez [get]
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]ProbeCheck{
+		"process": {OK: true},
+	}
+	if alive, heartbeat := s.health.Alive(); heartbeat.IsZero() {
+		checks["health_checker"] = ProbeCheck{OK: false, Detail: "health check loop is not running"}
+	} else {
+		checks["health_checker"] = ProbeCheck{OK: alive, Detail: "last activity " + heartbeat.UTC().Format(time.RFC3339)}
+	}
+	s.writeProbe(w, checks, "alive", "dead")
+}
+
+// handleReadyz reports whether the balancer accepts new traffic.
+// @Summary Readiness probe
+// @Description Returns 200 when the configuration is loaded, the first round of health checks is done, at least one backend is healthy and Redis is reachable if required. Returns 503 during graceful shutdown.
+// @Tags Probes
+// @Produce json
+// @Success 200 {object} ProbeResponse "Ready"
+// @Failure 503 {object} ProbeResponse "Not ready"
+// @Router /readyz [get]
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := make(map[string]ProbeCheck)
+
+	if s.ready.Load() {
+		checks["shutdown"] = ProbeCheck{OK: true}
+	} else {
+		checks["shutdown"] = ProbeCheck{OK: false, Detail: "graceful shutdown in progress"}
+	}
+
+	s.mu.RLock()
+	configLoaded := s.cfg != nil
+	total, healthy := 0, 0
+	if configLoaded {
+		for _, b := range s.allBackendsLocked() {
+			total++
+			if b.Available() {
+				healthy++
+			}
+		}
+	}
+	redisRequired := configLoaded && s.cfg.Redis.Required
+	s.mu.RUnlock()
+
+	checks["config"] = ProbeCheck{OK: configLoaded}
+	if s.health.FirstCheckDone() {
+		checks["first_health_check"] = ProbeCheck{OK: true}
+	} else {
+		checks["first_health_check"] = ProbeCheck{OK: false, Detail: "waiting for the first round of health checks"}
+	}
+	checks["healthy_backends"] = ProbeCheck{OK: healthy > 0, Detail: formatCount(healthy, total)}
+
+	if redisRequired {
+		ctx, cancel := context.WithTimeout(r.Context(), redisPingTimeout)
+		err := s.rateLimiter.Ping(ctx)
+		cancel()
+		if err != nil {
+			checks["redis"] = ProbeCheck{OK: false, Detail: err.Error()}
+		} else {
+			checks["redis"] = ProbeCheck{OK: true}
+		}
+	}
+
+	s.writeProbe(w, checks, "ready", "not_ready")
+}
+
+// writeProbe writes the probe response with 200 if every check passed and 503 otherwise.
+func (s *Server) writeProbe(w http.ResponseWriter, checks map[string]ProbeCheck, okStatus, failStatus string) {
+	resp := ProbeResponse{Status: okStatus, Checks: checks}
+	code := http.StatusOK
+	for _, c := range checks {
+		if !c.OK {
+			resp.Status = failStatus
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// formatCount renders "healthy of total" for the readiness detail.
+func formatCount(healthy, total int) string {
+	return strconv.Itoa(healthy) + " of " + strconv.Itoa(total) + " backends available"
+}
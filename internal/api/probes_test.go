@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+func TestServer_Probes(t *testing.T) {
+	logger.Init()
+
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	healthChecker := health.NewHealthChecker()
+	server := NewServer(
+		[]*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		healthChecker,
+		10, 1,
+		nil, "", filepath.Join(t.TempDir(), "config.json"),
+	)
+	server.cfg.AdminPort = "9090"
+
+	probe := func(handler http.Handler, path string) (int, ProbeResponse) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var resp ProbeResponse
+		if rr.Code != http.StatusNotFound {
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode %s response: %v", path, err)
+			}
+		}
+		return rr.Code, resp
+	}
+	admin := server.AdminHandler()
+
+	t.Run("Probes are served on the admin listener only", func(t *testing.T) {
+		if code, _ := probe(server.Handler(), "/api/backends"); code != http.StatusNotFound {
+			t.Errorf("Expected admin API to be absent from public listener, got %d", code)
+		}
+	})
+
+	t.Run("Liveness is also served on the public listener", func(t *testing.T) {
+		if code, resp := probe(server.Handler(), "/livez"); code == http.StatusNotFound || resp.Checks["process"].OK != true {
+			t.Errorf("Expected /livez on the public listener, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("Not ready before first health check", func(t *testing.T) {
+		code, resp := probe(admin, "/readyz")
+		if code != http.StatusServiceUnavailable || resp.Status != "not_ready" || resp.Checks["first_health_check"].OK {
+			t.Errorf("Expected not ready before first health check, got %d %+v", code, resp)
+		}
+		code, resp = probe(admin, "/livez")
+		if code != http.StatusServiceUnavailable || resp.Checks["health_checker"].OK {
+			t.Errorf("Expected liveness to fail while health checker is not running, got %d %+v", code, resp)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	healthChecker.Start(ctx, server.ConfigSnapshot, 50*time.Millisecond)
+	healthChecker.WaitFirstCheck()
+
+	t.Run("Ready and alive", func(t *testing.T) {
+		code, resp := probe(admin, "/readyz")
+		if code != http.StatusOK || resp.Status != "ready" {
+			t.Errorf("Expected ready, got %d %+v", code, resp)
+		}
+		if _, ok := resp.Checks["redis"]; ok {
+			t.Error("Expected no Redis check when Redis is not required")
+		}
+		code, resp = probe(admin, "/livez")
+		if code != http.StatusOK || resp.Status != "alive" {
+			t.Errorf("Expected alive, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("Required Redis is unreachable", func(t *testing.T) {
+		server.cfg.Redis.Required = true
+		defer func() { server.cfg.Redis.Required = false }()
+		code, resp := probe(admin, "/readyz")
+		if code != http.StatusServiceUnavailable || resp.Checks["redis"].OK {
+			t.Errorf("Expected Redis check to fail, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("No healthy backends", func(t *testing.T) {
+		server.cfg.Backends[0].SetState(models.BackendMaintenance)
+		defer server.cfg.Backends[0].SetState("")
+		code, resp := probe(admin, "/readyz")
+		if code != http.StatusServiceUnavailable || resp.Checks["healthy_backends"].OK {
+			t.Errorf("Expected healthy backends check to fail, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("Not ready during shutdown", func(t *testing.T) {
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown failed: %v", err)
+		}
+		code, resp := probe(admin, "/readyz")
+		if code != http.StatusServiceUnavailable || resp.Checks["shutdown"].OK {
+			t.Errorf("Expected readiness to fail after shutdown, got %d %+v", code, resp)
+		}
+	})
+}
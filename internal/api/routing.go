@@ -0,0 +1,733 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/config"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/router"
+	"load-balancer/internal/tlsconfig"
+)
+
+// PoolStatus is a pool as reported by GET /api/pools.
+type PoolStatus struct {
+	*models.Pool
+	Backends []BackendStatus `json:"backends"`
+}
+
+// poolInput is the request body of POST and PUT /api/pools.
+type poolInput struct {
+	Name     string `json:"name"`
+	Backends []struct {
+		URL        string                    `json:"url"`
+		Weight     int                       `json:"weight"`
+		HostHeader string                    `json:"host_header"`
+		TLS        *models.UpstreamTLSConfig `json:"tls"`
+		Protocol   string                    `json:"protocol"`
+	} `json:"backends"`
+	Strategy            string                    `json:"strategy"`
+	SlowStart           models.SlowStartConfig    `json:"slow_start"`
+	HealthCheckPath     string                    `json:"health_check_path"`
+	HealthCheckInterval models.Duration           `json:"health_check_interval"`
+	Timeouts            models.TimeoutsConfig     `json:"timeouts"`
+	TLS                 *models.UpstreamTLSConfig `json:"tls"`
+	Protocol            string                    `json:"protocol"`
+}
+
+// rebuildRoutingLocked recreates the pool balancers and the router from the configuration.
+// The caller must hold s.mu for writing.
+func (s *Server) rebuildRoutingLocked() error {
+	pools := make(map[string]balancer.BalancerInterface, len(s.cfg.Pools))
+	for _, p := range s.cfg.Pools {
+		strategy, slowStart := s.poolBalancing(p)
+		pools[p.Name] = balancer.New(strategy, p.Backends, slowStart)
+	}
+	rt, err := router.New(s.cfg.Routes)
+	if err != nil {
+		return err
+	}
+	global, err := proxy.NewHeaders(&s.cfg.Headers)
+	if err != nil {
+		return err
+	}
+	options := make(map[string]*proxy.Options, len(s.cfg.Routes))
+	splitOptions := make(map[string]*proxy.Options)
+	for _, route := range s.cfg.Routes {
+		rewrite, err := proxy.NewRewrite(route.Rewrite)
+		if err != nil {
+			return fmt.Errorf("route %s: %w", route.ID, err)
+		}
+		headers, err := proxy.NewHeaders(route.Headers)
+		if err != nil {
+			return fmt.Errorf("route %s: %w", route.ID, err)
+		}
+		// The timeouts, TLS settings and protocol come from the pool the request is sent to
+		poolOptions := func(pool string) *proxy.Options {
+			timeouts := s.cfg.Timeouts
+			var upstreamTLS *models.UpstreamTLSConfig
+			var protocol string
+			if p, _ := s.findPoolLocked(pool); p != nil {
+				timeouts = timeouts.Merge(p.Timeouts)
+				upstreamTLS, protocol = p.TLS, p.Protocol
+			}
+			if route.Timeouts != nil {
+				timeouts = timeouts.Merge(*route.Timeouts)
+			}
+			return &proxy.Options{
+				Rewrite:           rewrite,
+				Headers:           []*proxy.Headers{global, headers},
+				Forwarding:        s.cfg.Forwarding,
+				Timeouts:          timeouts,
+				TLS:               upstreamTLS,
+				Protocol:          protocol,
+				ClientCert:        s.cfg.TLS.ClientAuth.Headers,
+				FlushInterval:     route.FlushInterval.Std(),
+				RequireClientCert: route.RequireClientCert,
+			}
+		}
+		options[route.ID] = poolOptions(route.Pool)
+		if route.Split != nil {
+			splitOptions[route.ID] = poolOptions(route.Split.Pool)
+		}
+	}
+	s.pools = pools
+	s.router = rt
+	s.routeOptions = options
+	s.splitOptions = splitOptions
+	s.defaultOptions = &proxy.Options{Headers: []*proxy.Headers{global}, Forwarding: s.cfg.Forwarding, Timeouts: s.cfg.Timeouts, ClientCert: s.cfg.TLS.ClientAuth.Headers}
+	s.syncCanariesLocked()
+	return nil
+}
+
+// poolBalancing returns the strategy and slow-start settings of a pool, falling back to the global ones.
+func (s *Server) poolBalancing(p *models.Pool) (string, models.SlowStartConfig) {
+	strategy, slowStart := p.Strategy, p.SlowStart
+	if strategy == "" {
+		strategy = s.cfg.Strategy
+	}
+	if slowStart == (models.SlowStartConfig{}) {
+		slowStart = s.cfg.SlowStart
+	}
+	return strategy, slowStart
+}
+
+// selection is the pool and backend chosen for a request.
+type selection struct {
+	pool    string
+	lb      balancer.BalancerInterface // Balancer of the pool, nil if there is no such pool
+	opts    *proxy.Options             // Proxy options of the matched route, the default options if no route matched
+	backend *models.Backend            // Set by pick, nil if the pool has no available backends
+	route   string                     // ID of the matched route
+	canary  *canary                    // Set when a traffic split sent the request to its canary pool
+	cookie  *http.Cookie               // Sticky cookie of a traffic split to set on the response
+}
+
+// pick chooses the backend of the selected pool for the client. It is a separate step, so a
+// request the route rejects does not advance the balancer.
+func (sel *selection) pick(clientIP string) {
+	if sel.lb != nil {
+		sel.backend = balancer.Pick(sel.lb, clientIP)
+	}
+}
+
+// selectPool matches the request against the routes and applies the traffic split of the
+// matched route. The backend of the chosen pool is picked by selection.pick.
+func (s *Server) selectPool(r *http.Request) selection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sel := selection{pool: models.DefaultPool, opts: s.defaultOptions}
+	lb := s.balancer
+	if route := s.router.Match(r); route != nil {
+		sel.route = route.ID
+		sel.opts = s.routeOptions[route.ID]
+		if route.Pool != models.DefaultPool {
+			sel.pool = route.Pool
+			lb = s.pools[route.Pool]
+		}
+		if split := route.Split; split != nil {
+			var bucket int
+			bucket, sel.cookie = splitBucket(r, split)
+			if bucket < split.Weight {
+				sel.pool = split.Pool
+				sel.opts = s.splitOptions[route.ID]
+				sel.canary = s.canaries[route.ID]
+				lb = s.balancer
+				if split.Pool != models.DefaultPool {
+					lb = s.pools[split.Pool]
+				}
+			}
+		}
+	}
+	sel.lb = lb
+	return sel
+}
+
+// findPoolLocked returns the pool with the given name and its index, or nil and -1.
+// The caller must hold s.mu.
+func (s *Server) findPoolLocked(name string) (*models.Pool, int) {
+	for i, p := range s.cfg.Pools {
+		if p.Name == name {
+			return p, i
+		}
+	}
+	return nil, -1
+}
+
+// poolNamesLocked returns the set of configured pool names. The caller must hold s.mu.
+func (s *Server) poolNamesLocked() map[string]bool {
+	names := make(map[string]bool, len(s.cfg.Pools))
+	for _, p := range s.cfg.Pools {
+		names[p.Name] = true
+	}
+	return names
+}
+
+// poolStatus builds the API view of a pool.
+func (s *Server) poolStatus(p *models.Pool) PoolStatus {
+	_, slowStart := s.poolBalancing(p)
+	backends := make([]BackendStatus, len(p.Backends))
+	for i, b := range p.Backends {
+		backends[i] = BackendStatus{
+			Backend:         b,
+			InFlight:        b.InFlight(),
+			EffectiveWeight: balancer.EffectiveWeight(b, slowStart, time.Now()),
+		}
+	}
+	return PoolStatus{Pool: p, Backends: backends}
+}
+
+// buildPool validates the request body and creates the pool. Backends already present in
+// previous are replaced by copies with the new settings that keep their health status and
+// in-flight count, so the live backends stay untouched until the pool is swapped in; new
+// ones are health checked first.
+func (s *Server) buildPool(r *http.Request, input poolInput, previous *models.Pool) (*models.Pool, error) {
+	existing := make(map[string]*models.Backend)
+	if previous != nil {
+		for _, b := range previous.Backends {
+			existing[b.URL] = b
+		}
+	}
+
+	pool := &models.Pool{
+		Name:                input.Name,
+		Backends:            make([]*models.Backend, 0, len(input.Backends)),
+		Strategy:            input.Strategy,
+		SlowStart:           input.SlowStart,
+		HealthCheckPath:     input.HealthCheckPath,
+		HealthCheckInterval: input.HealthCheckInterval,
+		Timeouts:            input.Timeouts,
+		TLS:                 input.TLS,
+		Protocol:            input.Protocol,
+	}
+	seen := make(map[string]bool, len(input.Backends))
+	taken := make(map[string]bool, len(input.Backends))
+	for _, in := range input.Backends {
+		if in.URL == "" {
+			return nil, fmt.Errorf("backend URL is required")
+		}
+		if err := config.ValidateBackendURL(in.URL); err != nil {
+			return nil, err
+		}
+		if in.Weight < 0 {
+			return nil, fmt.Errorf("weight of %s must not be negative", in.URL)
+		}
+		if err := config.ValidateHostHeader(in.HostHeader); err != nil {
+			return nil, fmt.Errorf("backend %s: %w", in.URL, err)
+		}
+		if err := tlsconfig.ValidateUpstream(in.TLS); err != nil {
+			return nil, fmt.Errorf("backend %s: %w", in.URL, err)
+		}
+		if err := config.ValidateBackendProtocol(in.URL, in.Protocol); err != nil {
+			return nil, fmt.Errorf("backend %s: %w", in.URL, err)
+		}
+		if seen[in.URL] {
+			return nil, fmt.Errorf("duplicate backend %s", in.URL)
+		}
+		seen[in.URL] = true
+
+		if b, ok := existing[in.URL]; ok {
+			kept := b.Copy()
+			kept.Weight, kept.HostHeader, kept.TLS, kept.Protocol = in.Weight, in.HostHeader, in.TLS, in.Protocol
+			taken[kept.ID] = true
+			pool.Backends = append(pool.Backends, kept)
+			continue
+		}
+		pool.Backends = append(pool.Backends, &models.Backend{URL: in.URL, Weight: in.Weight, HostHeader: in.HostHeader, TLS: in.TLS, Protocol: in.Protocol})
+	}
+	if err := config.ValidatePool(pool); err != nil {
+		return nil, err
+	}
+
+	path := pool.HealthCheckPath
+	if path == "" {
+		path = s.cfg.HealthCheckPath
+	}
+	for i, b := range pool.Backends {
+		if b.ID != "" {
+			continue
+		}
+		for n := i; ; n++ {
+			if id := config.PoolBackendID(pool.Name, n); !taken[id] {
+				b.ID = id
+				taken[id] = true
+				break
+			}
+		}
+		s.health.Check(r.Context(), b, path, pool.TLS)
+	}
+	return pool, nil
+}
+
+// handlePools manages CRUD operations for backend pools.
+// @Summary Manage backend pools
+// @Description Get, create, replace or delete named backend pools that routes send traffic to.
+// @Tags Routing
+// @Accept json
+// @Produce json
+// @Param name query string false "Pool name (required for PUT and DELETE)"
+// @Param body body object false "Pool definition (required for POST and PUT, e.g., {\"name\": \"api\", \"backends\": [{\"url\": \"http://api1:80\", \"weight\": 1, \"host_header\": \"backend\"}], \"strategy\": \"least_connections\", \"health_check_path\": \"/healthz\", \"health_check_interval\": \"10s\"})"
+// @Success 200 {array} PoolStatus "List of pools (GET) or the updated pool (PUT)"
+// @Success 201 {object} PoolStatus "Pool created (POST)"
+// @Success 204 {string} string "Pool deleted (DELETE)"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Pool not found"
+// @Failure 409 {object} ErrorResponse "Pool already exists, is used by a route or was changed concurrently"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /pools [get]
+// @Router /pools [post]
+// @Router /pools [put]
+// @Router /pools [delete]
+func (s *Server) handlePools(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.RLock()
+		pools := make([]PoolStatus, len(s.cfg.Pools))
+		for i, p := range s.cfg.Pools {
+			pools[i] = s.poolStatus(p)
+		}
+		s.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pools); err != nil {
+			s.sendError(w, http.StatusInternalServerError, "Failed to encode pools")
+		}
+
+	case http.MethodPost:
+		var input poolInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		s.mu.RLock()
+		existing, _ := s.findPoolLocked(input.Name)
+		s.mu.RUnlock()
+		if existing != nil {
+			s.sendError(w, http.StatusConflict, fmt.Sprintf("Pool %s already exists", input.Name))
+			return
+		}
+
+		pool, err := s.buildPool(r, input, nil)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		if existing, _ := s.findPoolLocked(pool.Name); existing != nil {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusConflict, fmt.Sprintf("Pool %s already exists", pool.Name))
+			return
+		}
+		s.cfg.Pools = append(s.cfg.Pools, pool)
+		err = s.rebuildRoutingLocked()
+		s.mu.Unlock()
+		if err != nil {
+			log.ErrorKV("Failed to rebuild routing", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+			return
+		}
+
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+			return
+		}
+
+		log.InfoKV("Successfully added pool", "pool", pool.Name, "backends", len(pool.Backends), "strategy", pool.Strategy)
+		s.writePool(w, http.StatusCreated, pool)
+
+	case http.MethodPut:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			s.sendError(w, http.StatusBadRequest, "Pool name is required as query parameter 'name'")
+			return
+		}
+		var input poolInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		input.Name = name
+
+		s.mu.RLock()
+		previous, _ := s.findPoolLocked(name)
+		s.mu.RUnlock()
+		if previous == nil {
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Pool %s not found", name))
+			return
+		}
+
+		pool, err := s.buildPool(r, input, previous)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		current, index := s.findPoolLocked(name)
+		if index == -1 {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Pool %s not found", name))
+			return
+		}
+		if current != previous {
+			// The copies were made from a pool another request has replaced since
+			s.mu.Unlock()
+			s.sendError(w, http.StatusConflict, fmt.Sprintf("Pool %s was changed concurrently, retry the request", name))
+			return
+		}
+		pools := append([]*models.Pool(nil), s.cfg.Pools...)
+		pools[index] = pool
+		s.cfg.Pools = pools
+		err = s.rebuildRoutingLocked()
+		s.mu.Unlock()
+		if err != nil {
+			log.ErrorKV("Failed to rebuild routing", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+			return
+		}
+		s.forgetRemoved(previous.Backends, pool.Backends)
+
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+			return
+		}
+
+		log.InfoKV("Successfully updated pool", "pool", pool.Name, "backends", len(pool.Backends), "strategy", pool.Strategy)
+		s.writePool(w, http.StatusOK, pool)
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			s.sendError(w, http.StatusBadRequest, "Pool name is required as query parameter 'name'")
+			return
+		}
+
+		s.mu.Lock()
+		pool, index := s.findPoolLocked(name)
+		if pool == nil {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Pool %s not found", name))
+			return
+		}
+		for _, route := range s.cfg.Routes {
+			if route.Pool == name || (route.Split != nil && route.Split.Pool == name) {
+				s.mu.Unlock()
+				s.sendError(w, http.StatusConflict, fmt.Sprintf("Pool %s is used by route %s", name, route.ID))
+				return
+			}
+		}
+		for _, l := range s.cfg.TCPListeners {
+			if l.Pool == name {
+				s.mu.Unlock()
+				s.sendError(w, http.StatusConflict, fmt.Sprintf("Pool %s is used by tcp listener %s", name, l.Name))
+				return
+			}
+		}
+		for _, l := range s.cfg.UDPListeners {
+			if l.Pool == name {
+				s.mu.Unlock()
+				s.sendError(w, http.StatusConflict, fmt.Sprintf("Pool %s is used by udp listener %s", name, l.Name))
+				return
+			}
+		}
+		pools := append([]*models.Pool(nil), s.cfg.Pools[:index]...)
+		s.cfg.Pools = append(pools, s.cfg.Pools[index+1:]...)
+		err := s.rebuildRoutingLocked()
+		s.mu.Unlock()
+		if err != nil {
+			log.ErrorKV("Failed to rebuild routing", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+			return
+		}
+		s.forgetRemoved(pool.Backends, nil)
+
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+			return
+		}
+
+		log.InfoKV("Successfully deleted pool", "pool", name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// forgetRemoved drops the health history, TCP connections and UDP sessions of backends that are
+// in old but not in current. A URL that the default pool or another pool still uses is kept,
+// since the history and the connections are tracked by URL.
+func (s *Server) forgetRemoved(old, current []*models.Backend) {
+	kept := make(map[string]bool, len(current))
+	for _, b := range current {
+		kept[b.URL] = true
+	}
+	s.mu.RLock()
+	for _, b := range old {
+		if !kept[b.URL] && s.backendURLInUseLocked(b.URL) {
+			kept[b.URL] = true
+		}
+	}
+	s.mu.RUnlock()
+	for _, b := range old {
+		if !kept[b.URL] {
+			s.health.Forget(b.URL)
+			s.closeTCPConnections(b.URL)
+			s.closeUDPSessions(b.URL)
+		}
+	}
+}
+
+// backendURLInUseLocked reports whether a backend of the default pool or of a named pool has
+// the given URL. The caller must hold s.mu.
+func (s *Server) backendURLInUseLocked(backendURL string) bool {
+	for _, b := range s.cfg.Backends {
+		if b.URL == backendURL {
+			return true
+		}
+	}
+	for _, p := range s.cfg.Pools {
+		for _, b := range p.Backends {
+			if b.URL == backendURL {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writePool writes the API view of a pool with the given status code.
+func (s *Server) writePool(w http.ResponseWriter, code int, pool *models.Pool) {
+	s.mu.RLock()
+	status := s.poolStatus(pool)
+	s.mu.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.ErrorKV("Failed to encode pool", "error", err)
+	}
+}
+
+// handleRoutes manages CRUD operations for routing rules.
+// @Summary Manage routes
+// @Description Get, create, replace or delete routing rules. Routes are evaluated in order and the first match selects the pool; requests matching no route go to the default pool.
+// @Tags Routing
+// @Accept json
+// @Produce json
+// @Param id query string false "Route ID (required for PUT and DELETE)"
+// @Param body body object false "Route (required for POST and PUT, e.g., {\"id\": \"api\", \"match\": {\"host\": \"*.example.com\", \"path_prefix\": \"/api\", \"headers\": {\"X-Canary\": \"1\"}}, \"pool\": \"api\", \"position\": 0}). position is the optional index to insert at on POST, the route is appended otherwise"
+// @Success 200 {array} models.Route "List of routes (GET) or the updated route (PUT)"
+// @Success 201 {object} models.Route "Route created (POST)"
+// @Success 204 {string} string "Route deleted (DELETE)"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Route not found"
+// @Failure 409 {object} ErrorResponse "Route already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /routes [get]
+// @Router /routes [post]
+// @Router /routes [put]
+// @Router /routes [delete]
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.RLock()
+		routes := append([]*models.Route{}, s.cfg.Routes...)
+		s.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(routes); err != nil {
+			s.sendError(w, http.StatusInternalServerError, "Failed to encode routes")
+		}
+
+	case http.MethodPost:
+		var input struct {
+			models.Route
+			Position *int `json:"position"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		route := input.Route
+
+		s.mu.Lock()
+		if route.ID == "" {
+			route.ID = s.uniqueRouteIDLocked()
+		}
+		if _, index := s.findRouteLocked(route.ID); index != -1 {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusConflict, fmt.Sprintf("Route %s already exists", route.ID))
+			return
+		}
+		if err := config.ValidateRoute(&route, s.poolNamesLocked()); err != nil {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		position := len(s.cfg.Routes)
+		if input.Position != nil {
+			if *input.Position < 0 || *input.Position > len(s.cfg.Routes) {
+				s.mu.Unlock()
+				s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Position must be between 0 and %d", len(s.cfg.Routes)))
+				return
+			}
+			position = *input.Position
+		}
+		routes := make([]*models.Route, 0, len(s.cfg.Routes)+1)
+		routes = append(routes, s.cfg.Routes[:position]...)
+		routes = append(routes, &route)
+		s.cfg.Routes = append(routes, s.cfg.Routes[position:]...)
+		err := s.rebuildRoutingLocked()
+		s.mu.Unlock()
+		if err != nil {
+			log.ErrorKV("Failed to rebuild routing", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+			return
+		}
+
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+			return
+		}
+
+		log.InfoKV("Successfully added route", "id", route.ID, "pool", route.Pool, "position", position)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(route)
+
+	case http.MethodPut:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			s.sendError(w, http.StatusBadRequest, "Route ID is required as query parameter 'id'")
+			return
+		}
+		var route models.Route
+		if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		route.ID = id
+
+		s.mu.Lock()
+		_, index := s.findRouteLocked(id)
+		if index == -1 {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Route %s not found", id))
+			return
+		}
+		if err := config.ValidateRoute(&route, s.poolNamesLocked()); err != nil {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		routes := append([]*models.Route(nil), s.cfg.Routes...)
+		routes[index] = &route
+		s.cfg.Routes = routes
+		err := s.rebuildRoutingLocked()
+		s.mu.Unlock()
+		if err != nil {
+			log.ErrorKV("Failed to rebuild routing", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+			return
+		}
+
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+			return
+		}
+
+		log.InfoKV("Successfully updated route", "id", route.ID, "pool", route.Pool)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(route)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			s.sendError(w, http.StatusBadRequest, "Route ID is required as query parameter 'id'")
+			return
+		}
+
+		s.mu.Lock()
+		_, index := s.findRouteLocked(id)
+		if index == -1 {
+			s.mu.Unlock()
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Route %s not found", id))
+			return
+		}
+		routes := append([]*models.Route(nil), s.cfg.Routes[:index]...)
+		s.cfg.Routes = append(routes, s.cfg.Routes[index+1:]...)
+		err := s.rebuildRoutingLocked()
+		s.mu.Unlock()
+		if err != nil {
+			log.ErrorKV("Failed to rebuild routing", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+			return
+		}
+
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
+			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+			return
+		}
+
+		log.InfoKV("Successfully deleted route", "id", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// findRouteLocked returns the route with the given ID and its index, or nil and -1.
+// The caller must hold s.mu.
+func (s *Server) findRouteLocked(id string) (*models.Route, int) {
+	for i, route := range s.cfg.Routes {
+		if route.ID == id {
+			return route, i
+		}
+	}
+	return nil, -1
+}
+
+// uniqueRouteIDLocked returns the first free "route<N>" ID. The caller must hold s.mu.
+func (s *Server) uniqueRouteIDLocked() string {
+	for n := len(s.cfg.Routes) + 1; ; n++ {
+		id := fmt.Sprintf("route%d", n)
+		if _, index := s.findRouteLocked(id); index == -1 {
+			return id
+		}
+	}
+}
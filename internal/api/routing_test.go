@@ -0,0 +1,262 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+func TestServer_Routing(t *testing.T) {
+	logger.Init()
+
+	newBackend := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Backend-Path", r.URL.Path)
+			w.Write([]byte(body))
+		}))
+	}
+	web := newBackend("web")
+	defer web.Close()
+	api := newBackend("api")
+	defer api.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	server := NewServer(
+		[]*models.Backend{{URL: web.URL, Healthy: true}},
+		health.NewHealthChecker(),
+		100, 100,
+		nil, "", configPath,
+	)
+
+	call := func(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.RemoteAddr = "127.0.0.1:12345"
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+	forwardRequest := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		server.handleRequest(rr, req)
+		return rr
+	}
+	forward := func(target string, headers map[string]string) string {
+		return forwardRequest(target, headers).Body.String()
+	}
+
+	t.Run("POST pool", func(t *testing.T) {
+		rr := call(server.handlePools, "POST", "/api/pools", `{"name": "api", "backends": [{"url": "`+api.URL+`", "weight": 2}], "strategy": "least_connections"}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var pool PoolStatus
+		if err := json.NewDecoder(rr.Body).Decode(&pool); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(pool.Backends) != 1 || pool.Backends[0].ID != "api-backend1" || !pool.Backends[0].Healthy {
+			t.Errorf("Expected one healthy backend api-backend1, got %+v", pool.Backends)
+		}
+
+		if rr := call(server.handlePools, "POST", "/api/pools", `{"name": "api", "backends": [{"url": "`+api.URL+`"}]}`); rr.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 for duplicate pool, got %d", rr.Code)
+		}
+		if rr := call(server.handlePools, "POST", "/api/pools", `{"name": "empty", "backends": []}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for pool without backends, got %d", rr.Code)
+		}
+	})
+
+	t.Run("POST route", func(t *testing.T) {
+		if rr := call(server.handleRoutes, "POST", "/api/routes", `{"match": {"path_prefix": "/x"}, "pool": "missing"}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for unknown pool, got %d", rr.Code)
+		}
+
+		rr := call(server.handleRoutes, "POST", "/api/routes", `{"id": "api", "match": {"path_prefix": "/shop"}, "pool": "api"}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		rr = call(server.handleRoutes, "POST", "/api/routes", `{"match": {"headers": {"X-Pool": "web"}}, "pool": "default", "position": 0}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var route models.Route
+		json.NewDecoder(rr.Body).Decode(&route)
+		if route.ID != "route2" {
+			t.Errorf("Expected generated ID route2, got %q", route.ID)
+		}
+	})
+
+	t.Run("Requests follow routes", func(t *testing.T) {
+		if got := forward("/shop/users", nil); got != "api" {
+			t.Errorf("Expected /shop/users to reach the api pool, got %q", got)
+		}
+		if got := forward("/index.html", nil); got != "web" {
+			t.Errorf("Expected unmatched request to reach the default pool, got %q", got)
+		}
+		if got := forward("/shop/users", map[string]string{"X-Pool": "web"}); got != "web" {
+			t.Errorf("Expected the first matching route to win, got %q", got)
+		}
+	})
+
+	t.Run("Routing is saved to config", func(t *testing.T) {
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to load saved config: %v", err)
+		}
+		if len(cfg.Pools) != 1 || cfg.Pools[0].Name != "api" || cfg.Pools[0].Backends[0].Weight != 2 {
+			t.Errorf("Expected pool api to be saved, got %+v", cfg.Pools)
+		}
+		if len(cfg.Routes) != 2 || cfg.Routes[0].ID != "route2" || cfg.Routes[1].ID != "api" {
+			t.Errorf("Expected routes route2 and api in order, got %+v", cfg.Routes)
+		}
+	})
+
+	t.Run("PUT route", func(t *testing.T) {
+		rr := call(server.handleRoutes, "PUT", "/api/routes?id=api", `{"match": {"path_prefix": "/v2"}, "pool": "api"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if got := forward("/shop/users", nil); got != "web" {
+			t.Errorf("Expected /shop/users to fall back to the default pool, got %q", got)
+		}
+		if got := forward("/v2/users", nil); got != "api" {
+			t.Errorf("Expected /v2/users to reach the api pool, got %q", got)
+		}
+	})
+
+	t.Run("Route rewrites path", func(t *testing.T) {
+		if rr := call(server.handleRoutes, "PUT", "/api/routes?id=api", `{"match": {"path_prefix": "/v2"}, "pool": "api", "rewrite": {"strip_prefix": "v2"}}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for relative strip_prefix, got %d", rr.Code)
+		}
+		rr := call(server.handleRoutes, "PUT", "/api/routes?id=api", `{"match": {"path_prefix": "/v2"}, "pool": "api", "rewrite": {"strip_prefix": "/v2", "add_prefix": "/internal"}}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if got := forwardRequest("/v2/users", nil).Header().Get("X-Backend-Path"); got != "/internal/users" {
+			t.Errorf("Expected backend path /internal/users, got %q", got)
+		}
+		if got := forwardRequest("/index.html", nil).Header().Get("X-Backend-Path"); got != "/index.html" {
+			t.Errorf("Expected unmatched request path to be unchanged, got %q", got)
+		}
+	})
+
+	t.Run("Global and route header rules", func(t *testing.T) {
+		server.mu.Lock()
+		server.cfg.Headers = models.HeadersConfig{Response: models.HeaderRules{Set: map[string]string{"X-Served-By": "lb"}}}
+		err := server.rebuildRoutingLocked()
+		server.mu.Unlock()
+		if err != nil {
+			t.Fatalf("Failed to rebuild routing: %v", err)
+		}
+		if rr := call(server.handleRoutes, "PUT", "/api/routes?id=api", `{"match": {"path_prefix": "/v2"}, "pool": "api", "headers": {"response": {"set": {"X-Served-By": "{unknown}"}}}}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for unknown placeholder, got %d", rr.Code)
+		}
+		rr := call(server.handleRoutes, "PUT", "/api/routes?id=api", `{"match": {"path_prefix": "/v2"}, "pool": "api", "headers": {"response": {"add": {"X-Served-By": "api-{client_ip}"}}}}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if got := forwardRequest("/index.html", nil).Header().Values("X-Served-By"); len(got) != 1 || got[0] != "lb" {
+			t.Errorf("Expected global rules for unmatched requests, got %v", got)
+		}
+		if got := forwardRequest("/v2/users", nil).Header().Values("X-Served-By"); len(got) != 2 || got[1] != "api-127.0.0.1" {
+			t.Errorf("Expected route rules after global rules, got %v", got)
+		}
+	})
+
+	t.Run("PUT pool keeps backend state", func(t *testing.T) {
+		live := server.findBackend("api-backend1")
+		weight := live.Weight
+		live.Acquire()
+		rr := call(server.handlePools, "PUT", "/api/pools?name=api", `{"backends": [{"url": "`+api.URL+`", "weight": 3}]}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var pool PoolStatus
+		json.NewDecoder(rr.Body).Decode(&pool)
+		if len(pool.Backends) != 1 || pool.Backends[0].Weight != 3 || !pool.Backends[0].Healthy || pool.Backends[0].ID != "api-backend1" || pool.Backends[0].InFlight != 1 {
+			t.Errorf("Expected the existing backend with weight 3 and 1 request in flight, got %+v", pool.Backends)
+		}
+		// The backend the running request holds is replaced, not changed
+		if live.Weight != weight {
+			t.Errorf("Expected the replaced backend to keep weight %d, got %d", weight, live.Weight)
+		}
+		live.Release()
+		if n := server.findBackend("api-backend1").InFlight(); n != 0 {
+			t.Errorf("Expected the release of the old backend to be counted, got %d in flight", n)
+		}
+	})
+
+	t.Run("PUT pool during traffic", func(t *testing.T) {
+		// Requests to the pool are proxied while its backends are replaced; run with -race
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						forwardRequest("/v2/users", nil)
+					}
+				}
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			body := fmt.Sprintf(`{"backends": [{"url": "%s", "weight": %d, "host_header": "backend"}]}`, api.URL, i+1)
+			if rr := call(server.handlePools, "PUT", "/api/pools?name=api", body); rr.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+		}
+		close(stop)
+		wg.Wait()
+	})
+
+	t.Run("DELETE pool keeps a URL another pool uses", func(t *testing.T) {
+		if rr := call(server.handlePools, "POST", "/api/pools", `{"name": "mirror", "backends": [{"url": "`+api.URL+`"}]}`); rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if rr := call(server.handlePools, "DELETE", "/api/pools?name=mirror", ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if len(server.health.History(api.URL)) == 0 {
+			t.Error("Expected the health history of a backend of pool api to be kept")
+		}
+	})
+
+	t.Run("DELETE pool in use", func(t *testing.T) {
+		// The health checker works on a snapshot that later changes don't touch
+		snapshot := server.ConfigSnapshot()
+		if rr := call(server.handlePools, "DELETE", "/api/pools?name=api", ""); rr.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 while a route uses the pool, got %d", rr.Code)
+		}
+		if rr := call(server.handleRoutes, "DELETE", "/api/routes?id=api", ""); rr.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", rr.Code)
+		}
+		if rr := call(server.handlePools, "DELETE", "/api/pools?name=api", ""); rr.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", rr.Code)
+		}
+		if rr := call(server.handlePools, "DELETE", "/api/pools?name=api", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+		if len(snapshot.Pools) != 1 || snapshot.Pools[0].Name != "api" || len(snapshot.Pools[0].Backends) != 1 || len(server.ConfigSnapshot().Pools) != 0 {
+			t.Errorf("Expected the snapshot to keep the deleted pool, got %+v", snapshot.Pools)
+		}
+	})
+}
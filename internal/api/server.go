@@ -3,25 +3,40 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"load-balancer/docs"
+	"load-balancer/internal/accesslog"
 	"load-balancer/internal/balancer"
 	"load-balancer/internal/config"
 	"load-balancer/internal/health"
+	"load-balancer/internal/l4"
 	"load-balancer/internal/logger"
 	"load-balancer/internal/models"
 	"load-balancer/internal/proxy"
 	"load-balancer/internal/ratelimiter"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/router"
+	"load-balancer/internal/statuswriter"
+	"load-balancer/internal/tlsconfig"
+	"load-balancer/internal/tracing"
 
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
 )
 
 // ErrorResponse represents a structured JSON error response.
@@ -30,16 +45,52 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// BackendStatus is a backend as reported by GET /api/backends.
+type BackendStatus struct {
+	*models.Backend
+	State           string  // active (default), draining or maintenance
+	InFlight        int64   // Requests currently being proxied to the backend, including upgraded connections
+	Upgraded        int     // Open WebSocket and other upgraded connections
+	Connections     int     // Open connections of the TCP listeners
+	Sessions        int     // Open sessions of the UDP listeners
+	EffectiveWeight float64 // Weight after slow-start ramp-up
+}
+
+// drainPollInterval is how often a draining backend's in-flight count is checked.
+const drainPollInterval = 100 * time.Millisecond
+
+// defaultDrainTimeout bounds the wait for in-flight requests during shutdown.
+const defaultDrainTimeout = 30 * time.Second
+
+// redisFlushTimeout bounds the wait for pending rate limiter writes during shutdown.
+const redisFlushTimeout = 5 * time.Second
+
 // Server manages the HTTP server and request balancing.
 type Server struct {
-	cfg         *models.Config
-	configPath  string // Path to config.json for saving changes
-	health      *health.HealthChecker
-	rateLimiter ratelimiter.RateLimiterInterface
-	server      *http.Server
-	mu          sync.RWMutex
-	balancer    balancer.BalancerInterface
-	proxy       *proxy.Proxy
+	cfg            *models.Config
+	configPath     string     // Path to config.json for saving changes
+	saveMu         sync.Mutex // Serializes the writes of config.json, held without mu during the disk write
+	health         *health.HealthChecker
+	rateLimiter    ratelimiter.RateLimiterInterface
+	server         *http.Server
+	hijacked       *hijackedConns // Connections of server taken over by h2c, nil without server.h2c
+	tlsServer      *http.Server   // HTTPS listener, nil unless TLS is enabled
+	adminServer    *http.Server
+	mu             sync.RWMutex
+	balancer       balancer.BalancerInterface            // Balancer of the default pool
+	pools          map[string]balancer.BalancerInterface // Balancers of the named pools
+	router         *router.Router
+	routeOptions   map[string]*proxy.Options // Proxy settings of the routes, keyed by route ID
+	splitOptions   map[string]*proxy.Options // Proxy settings of the canary pools of traffic splits, keyed by route ID
+	canaries       map[string]*canary        // Canary requests and rollouts of traffic splits, keyed by route ID
+	defaultOptions *proxy.Options            // Proxy settings of requests that match no route
+	proxy          *proxy.Proxy
+	tcpProxies     []*l4.TCPProxy                // Layer-4 listeners of cfg.TCPListeners
+	udpProxies     []*l4.UDPProxy                // Layer-4 listeners of cfg.UDPListeners
+	accessLog      *accesslog.Logger             // nil when the access log is disabled
+	tracer         *tracing.Tracer               // nil when tracing is disabled
+	drains         map[string]context.CancelFunc // Pending automatic removals of draining backends, keyed by URL
+	ready          atomic.Bool                   // Reported by /readyz, false once shutdown begins
 }
 
 // NewServer initializes a new server with backends, health checker, and rate-limiting parameters.
@@ -55,33 +106,104 @@ func NewServer(backends []*models.Backend, health *health.HealthChecker, rateLim
 		},
 		ClientConfigs: clientConfigs,
 	}
-	rl := ratelimiter.NewRateLimiter(float64(rateLimitCapacity), rateLimitRate, clientConfigs, redisAddr)
-	return &Server{
+	return NewServerFromConfig(cfg, health, redisAddr, configPath)
+}
+
+// NewServerFromConfig initializes a new server from a loaded configuration.
+// Changes made through the API are written back to configPath with all settings of cfg.
+func NewServerFromConfig(cfg *models.Config, health *health.HealthChecker, redisAddr, configPath string) *Server {
+	for i, b := range cfg.Backends {
+		if b.ID == "" {
+			b.ID = config.DefaultBackendID(i)
+		}
+	}
+	for _, p := range cfg.Pools {
+		for i, b := range p.Backends {
+			if b.ID == "" {
+				b.ID = config.PoolBackendID(p.Name, i)
+			}
+		}
+	}
+	rl := ratelimiter.NewRateLimiter(float64(cfg.RateLimit.Capacity), cfg.RateLimit.Rate, cfg.ClientConfigs, redisAddr)
+	s := &Server{
 		cfg:         cfg,
 		configPath:  configPath,
 		health:      health,
 		rateLimiter: rl,
-		balancer:    balancer.NewBalancer(backends),
+		balancer:    balancer.New(cfg.Strategy, cfg.Backends, cfg.SlowStart),
 		proxy:       proxy.NewProxy(),
+		drains:      make(map[string]context.CancelFunc),
+	}
+	s.proxy.SetErrorHandler(s.proxyError)
+	if err := s.rebuildRoutingLocked(); err != nil {
+		logger.ErrorKV("Invalid routes, all requests go to the default pool", "error", err)
+	}
+	s.newL4Proxies()
+	accessLog, err := accesslog.New(cfg.AccessLog)
+	if err != nil {
+		logger.ErrorKV("Failed to open access log, access logging is disabled", "error", err)
+	}
+	s.accessLog = accessLog
+	tracer, err := tracing.New(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.ErrorKV("Failed to set up tracing, tracing is disabled", "error", err)
 	}
+	s.tracer = tracer
+	s.ready.Store(true)
+	return s
 }
 
-// Handler returns the HTTP handler for the server.
+// Handler returns the HTTP handler for the public listener.
+// Admin routes are included unless a separate admin listener is configured; /livez is
+// served either way, so the container healthcheck can probe the public port.
+// Every request gets an X-Request-ID, see requestid.Middleware, and an access log record;
+// proxied requests are also traced. Request bodies are limited to server.max_body_bytes.
 func (s *Server) Handler() http.Handler {
-	docs.SwaggerInfo.Title = "Load Balancer API"
-	docs.SwaggerInfo.Description = "API for managing load balancer backends and rate-limiting configurations."
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Host = "localhost:8087"
-	docs.SwaggerInfo.BasePath = "/api"
-	docs.SwaggerInfo.Schemes = []string{"http"}
+	mux := http.NewServeMux()
+	mux.Handle("/", s.tracer.Middleware(http.HandlerFunc(s.handleRequest)))
+	if s.cfg.AdminPort == "" {
+		s.registerAdminRoutes(mux)
+	} else {
+		mux.HandleFunc("GET /livez", s.handleLivez)
+	}
+	return requestid.Middleware(s.accessLog.Middleware(s.limitBody(mux)))
+}
 
+// AdminHandler returns the HTTP handler for the admin listener: the management API,
+// Swagger UI and the liveness and readiness probes.
+func (s *Server) AdminHandler() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", s.handleRequest)
+	s.registerAdminRoutes(mux)
+	return requestid.Middleware(s.accessLog.Middleware(mux))
+}
+
+// swaggerInfo fills docs.SwaggerInfo once.
+var swaggerInfo sync.Once
+
+// registerAdminRoutes adds the management API, Swagger UI and probes to mux.
+func (s *Server) registerAdminRoutes(mux *http.ServeMux) {
+	// The public and admin handlers may be built at the same time
+	swaggerInfo.Do(func() {
+		docs.SwaggerInfo.Title = "Load Balancer API"
+		docs.SwaggerInfo.Description = "API for managing load balancer backends and rate-limiting configurations."
+		docs.SwaggerInfo.Version = "1.0"
+		docs.SwaggerInfo.Host = "localhost:8087"
+		docs.SwaggerInfo.BasePath = "/api"
+		docs.SwaggerInfo.Schemes = []string{"http"}
+	})
+
+	mux.HandleFunc("GET /livez", s.handleLivez)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
 	mux.HandleFunc("/api/backends", s.handleBackends)
+	mux.HandleFunc("POST /api/backends/{id}/check", s.handleBackendCheck)
+	mux.HandleFunc("GET /api/backends/{id}/health", s.handleBackendHealth)
+	mux.HandleFunc("/api/pools", s.handlePools)
+	mux.HandleFunc("/api/routes", s.handleRoutes)
+	mux.HandleFunc("GET /api/routes/{id}/split", s.handleRouteSplit)
+	mux.HandleFunc("PATCH /api/routes/{id}/split", s.handleRouteSplit)
 	mux.HandleFunc("/api/ratelimit", s.handleRateLimit)
 	mux.HandleFunc("/api/clients", s.handleClients)
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
-	return mux
 }
 
 // sendError sends a JSON error response with the specified code and message.
@@ -95,14 +217,35 @@ func (s *Server) sendError(w http.ResponseWriter, code int, message string) {
 	}
 }
 
+// proxyError answers a request the backend failed: 504 when an upstream timeout expired,
+// 413 when the request body turned out to exceed the limit, 502 otherwise.
+// gRPC calls get the matching gRPC status instead, see sendRequestError.
+func (s *Server) proxyError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	backendURL := r.URL.Scheme + "://" + r.URL.Host
+	switch status {
+	case http.StatusGatewayTimeout:
+		s.sendRequestError(w, r, status, fmt.Sprintf("Request to %s timed out", backendURL))
+	case http.StatusRequestEntityTooLarge:
+		s.sendBodyTooLarge(w, r)
+	default:
+		s.sendRequestError(w, r, status, fmt.Sprintf("Failed to forward request to %s", backendURL))
+	}
+}
+
 // handleRequest processes incoming requests with rate-limiting and forwarding to backends.
 // @Summary Forward request to backend
-// @Description Forwards an incoming HTTP request to a healthy backend using round-robin balancing.
+// @Description Forwards an incoming HTTP request to a healthy backend of the pool selected by the routes, or of the default pool if no route matches. gRPC calls (Content-Type application/grpc) get errors as a gRPC status in the grpc-status and grpc-message headers instead of a JSON body.
 // @Produce plain
+// @Param X-Request-ID header string false "Request ID to reuse; a UUID is generated when absent or invalid"
+// @Param X-Request-Timeout header string false "Shorter upstream deadline, e.g. 2s or 2000 (milliseconds); capped by the configured request timeout"
 // @Success 200 {string} string "Response from backend"
+// @Header 200,403,413,429,502,503,504 {string} X-Request-ID "ID of the request, also sent to the backend"
+// @Failure 403 {object} ErrorResponse "The route requires a client certificate"
+// @Failure 413 {object} ErrorResponse "Request body exceeds server.max_body_bytes"
 // @Failure 429 {object} ErrorResponse "Rate limit exceeded"
 // @Failure 503 {object} ErrorResponse "No healthy backends available"
 // @Failure 502 {object} ErrorResponse "Failed to forward request"
+// @Failure 504 {object} ErrorResponse "Upstream timeout expired"
 // @Router / [get]
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	// Requests to /api/* or /swagger/* are handled by other handlers, otherwise return 404
@@ -111,40 +254,123 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clientIP := strings.Split(r.RemoteAddr, ":")[0]
-	logger.DebugKV("Processing request", "clientIP", clientIP, "method", r.Method)
+	log := logger.FromContext(r.Context())
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// Unix socket clients have no address and share one identity
+		clientIP = r.RemoteAddr
+	}
+	clientCert := tlsconfig.VerifiedClientCert(r.TLS)
+	clientID := clientIP
+	if id := tlsconfig.ClientIdentity(clientCert, s.cfg.TLS.ClientAuth.Identity); id != "" {
+		clientID = id
+	}
+	log.DebugKV("Processing request", "clientIP", clientIP, "clientID", clientID, "method", r.Method)
 
 	// Check rate-limiting
-	if !s.rateLimiter.Allow(clientIP) {
-		logger.WarnKV("Request rejected due to rate limit", "clientIP", clientIP)
-		s.sendError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+	ctx := r.Context()
+	entry := accesslog.FromContext(ctx)
+	_, span := s.tracer.Start(ctx, tracing.SpanRateLimit)
+	allowed := s.rateLimiter.AllowContext(ctx, clientID)
+	span.SetAttributes(attribute.Bool("ratelimit.allowed", allowed))
+	span.End()
+	if !allowed {
+		log.WarnKV("Request rejected due to rate limit", "clientIP", clientIP, "clientID", clientID)
+		if entry != nil {
+			entry.RateLimit = accesslog.RateLimitRejected
+		}
+		s.sendRequestError(w, r, http.StatusTooManyRequests, "Rate limit exceeded")
 		return
 	}
 
-	// Select the next healthy backend
-	backend := s.balancer.NextBackend()
+	if entry != nil {
+		entry.RateLimit = accesslog.RateLimitAllowed
+	}
+
+	// Select the pool by the routes, check its policy, then pick the next healthy backend
+	_, span = s.tracer.Start(ctx, tracing.SpanSelectBackend)
+	sel := s.selectPool(r)
+	pool, opts := sel.pool, sel.opts
+	span.SetAttributes(attribute.String("pool", pool))
+	if entry != nil {
+		entry.Pool = pool
+	}
+	if opts != nil && opts.RequireClientCert && clientCert == nil {
+		span.SetStatus(codes.Error, "client certificate required")
+		span.End()
+		log.WarnKV("Request rejected without client certificate", "clientIP", clientIP, "pool", pool)
+		s.sendRequestError(w, r, http.StatusForbidden, "Client certificate required")
+		return
+	}
+	sel.pick(clientIP)
+	backend := sel.backend
+	if backend != nil {
+		span.SetAttributes(attribute.String("backend.id", backend.ID), attribute.String("backend.url", backend.URL))
+	} else {
+		span.SetStatus(codes.Error, "no healthy backends")
+	}
+	span.End()
+	if sel.cookie != nil {
+		http.SetCookie(w, sel.cookie)
+	}
+	if sel.canary != nil {
+		sw := statuswriter.New(w)
+		w = sw
+		defer func() {
+			// A client that went away says nothing about the canary
+			if r.Context().Err() != nil {
+				return
+			}
+			if sel.canary.record(canaryFailed(sw)) {
+				s.rollback(sel.route, sel.canary)
+			}
+		}()
+	}
 	if backend == nil {
-		logger.Warn("No healthy backends available")
-		s.sendError(w, http.StatusServiceUnavailable, "No healthy backends available")
+		log.WarnKV("No healthy backends available", "pool", pool)
+		s.sendRequestError(w, r, http.StatusServiceUnavailable, "No healthy backends available")
 		return
 	}
 
-	logger.InfoKV("Forwarding request", "method", r.Method, "url", r.URL.String(), "backend", backend.URL)
-	if err := s.proxy.Forward(w, r, backend.URL); err != nil {
-		logger.ErrorKV("Failed to forward request", "backend", backend.URL, "error", err)
-		s.sendError(w, http.StatusBadGateway, fmt.Sprintf("Failed to forward request to %s", backend.URL))
+	backend.Acquire()
+	defer backend.Release()
+
+	if backend.HostHeader == models.HostHeaderBackend || backend.TLS != nil || backend.Protocol != "" {
+		var backendOpts proxy.Options
+		if opts != nil {
+			backendOpts = *opts
+		}
+		backendOpts.BackendHost = backend.HostHeader == models.HostHeaderBackend
+		backendOpts.TLS = backend.UpstreamTLS(backendOpts.TLS)
+		backendOpts.Protocol = backend.UpstreamProtocol(backendOpts.Protocol)
+		opts = &backendOpts
+	}
+
+	log.InfoKV("Forwarding request", "method", r.Method, "url", r.URL.String(), "pool", pool, "backend", backend.URL)
+	// The upstream span is the parent of the backend's spans
+	upstreamCtx, span := s.tracer.Start(ctx, tracing.SpanUpstream, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("backend.url", backend.URL)))
+	s.tracer.Inject(upstreamCtx, r.Header)
+	start := time.Now()
+	err = s.proxy.ForwardWith(w, r.WithContext(upstreamCtx), backend.URL, opts)
+	span.End()
+	if entry != nil {
+		entry.Upstream, entry.UpstreamLatency = backend.URL, time.Since(start)
+	}
+	if err != nil {
+		// The proxy has already answered with 502 or 504, see proxyError
+		log.ErrorKV("Failed to forward request", "backend", backend.URL, "error", err)
 	}
 }
 
 // handleBackends manages CRUD operations for backends.
 // @Summary Manage backends
-// @Description Get, add, or delete backend servers.
+// @Description Get, add, delete backend servers or change their state (active, draining, maintenance).
 // @Tags Backends
 // @Accept json
 // @Produce json
 // @Param url query string false "Backend URL (required for DELETE)"
-// @Param body body object false "Backend URL (required for POST, e.g., {\"url\": \"http://backend3:80\"})"
-// @Success 200 {array} models.Backend "List of backends (GET)"
+// @Param body body object false "Backend URL (http, https or unix:///path/to.sock), optional weight, Host header choice, upstream TLS settings and protocol (required for POST, e.g., {\"url\": \"https://backend3:443\", \"weight\": 2, \"host_header\": \"backend\", \"tls\": {\"ca_file\": \"/etc/lb/ca.pem\"}, \"protocol\": \"http1\"}) or state change (PATCH, e.g., {\"id\": \"backend1\", \"state\": \"draining\", \"remove_when_drained\": true, \"drain_timeout\": \"30s\"})"
+// @Success 200 {array} BackendStatus "List of backends (GET) or the updated backend (PATCH)"
 // @Success 201 {string} string "Backend added (POST)"
 // @Success 204 {string} string "Backend deleted (DELETE)"
 // @Failure 400 {object} ErrorResponse "Invalid request"
@@ -154,11 +380,16 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 // @Router /backends [get]
 // @Router /backends [post]
 // @Router /backends [delete]
+// @Router /backends [patch]
 func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	switch r.Method {
 	case http.MethodGet:
 		s.mu.RLock()
-		backends := s.cfg.Backends
+		backends := make([]BackendStatus, len(s.cfg.Backends))
+		for i, b := range s.cfg.Backends {
+			backends[i] = s.backendStatus(b)
+		}
 		s.mu.RUnlock()
 
 		w.Header().Set("Content-Type", "application/json")
@@ -169,7 +400,11 @@ func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPost:
 		var input struct {
-			URL string `json:"url"`
+			URL        string                    `json:"url"`
+			Weight     int                       `json:"weight"`
+			HostHeader string                    `json:"host_header"`
+			TLS        *models.UpstreamTLSConfig `json:"tls"`
+			Protocol   string                    `json:"protocol"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			s.sendError(w, http.StatusBadRequest, "Invalid request body")
@@ -179,10 +414,30 @@ func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
 			s.sendError(w, http.StatusBadRequest, "Backend URL is required")
 			return
 		}
+		if input.Weight < 0 {
+			s.sendError(w, http.StatusBadRequest, "Weight must not be negative")
+			return
+		}
+		if err := config.ValidateHostHeader(input.HostHeader); err != nil {
+			s.sendError(w, http.StatusBadRequest, "host_header must be client or backend")
+			return
+		}
+		if err := tlsconfig.ValidateUpstream(input.TLS); err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid tls settings: %v", err))
+			return
+		}
+		if err := config.ValidateProtocol(input.Protocol); err != nil {
+			s.sendError(w, http.StatusBadRequest, "protocol must be http1 or h2c")
+			return
+		}
+		if err := config.ValidateBackendProtocol(input.URL, input.Protocol); err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid protocol: %v", err))
+			return
+		}
 
-		// Validate URL
-		if _, err := url.ParseRequestURI(input.URL); err != nil {
-			s.sendError(w, http.StatusBadRequest, "Invalid backend URL")
+		// Validate URL, unix:///path/to.sock for backends on a Unix domain socket
+		if err := config.ValidateBackendURL(input.URL); err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid backend URL: %v", err))
 			return
 		}
 
@@ -202,35 +457,27 @@ func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
 			URL:           input.URL,
 			Healthy:       false,
 			LoggedHealthy: false,
+			Weight:        input.Weight,
+			HostHeader:    input.HostHeader,
+			TLS:           input.TLS,
+			Protocol:      input.Protocol,
 		}
 
 		// Perform immediate health check
-		req, err := http.NewRequestWithContext(context.Background(), "GET", newBackend.URL+s.cfg.HealthCheckPath, nil)
-		if err == nil {
-			resp, err := s.health.Client().Do(req)
-			if err == nil && resp.StatusCode == http.StatusOK {
-				newBackend.Healthy = true
-				newBackend.LoggedHealthy = true
-				logger.InfoKV("New backend is healthy", "url", newBackend.URL)
-			} else {
-				logger.WarnKV("New backend is unhealthy", "url", newBackend.URL, "error", err)
-			}
-			if resp != nil {
-				resp.Body.Close()
-			}
-		}
+		s.health.Check(r.Context(), newBackend, s.cfg.HealthCheckPath, nil)
 
 		// Generate unique index for HTML file
 		s.mu.Lock()
 		backendIndex := len(s.cfg.Backends) + 1
+		newBackend.ID = s.uniqueBackendID(backendIndex)
 		s.cfg.Backends = append(s.cfg.Backends, newBackend)
-		s.balancer = balancer.NewBalancer(s.cfg.Backends)
+		s.balancer = balancer.New(s.cfg.Strategy, s.cfg.Backends, s.cfg.SlowStart)
 		s.mu.Unlock()
 
 		// Create configs directory if it doesn't exist
 		configsDir := filepath.Join(filepath.Dir(s.configPath), "configs")
 		if err := os.MkdirAll(configsDir, 0755); err != nil {
-			logger.ErrorKV("Failed to create configs directory", "error", err)
+			log.ErrorKV("Failed to create configs directory", "error", err)
 			s.sendError(w, http.StatusInternalServerError, "Failed to create configs directory")
 			return
 		}
@@ -239,20 +486,20 @@ func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
 		htmlContent := fmt.Sprintf(`<!DOCTYPE html><html><head><title>Welcome to Nginx!</title></head><body><h1>Hello from Nginx Backend %d!</h1></body></html>`, backendIndex)
 		htmlFilePath := filepath.Join(configsDir, fmt.Sprintf("index-backend%d.html", backendIndex))
 		if err := os.WriteFile(htmlFilePath, []byte(htmlContent), 0644); err != nil {
-			logger.ErrorKV("Failed to create HTML file", "path", htmlFilePath, "error", err)
+			log.ErrorKV("Failed to create HTML file", "path", htmlFilePath, "error", err)
 			s.sendError(w, http.StatusInternalServerError, "Failed to create HTML file for backend")
 			return
 		}
-		logger.InfoKV("Created HTML file for backend", "url", newBackend.URL, "path", htmlFilePath)
+		log.InfoKV("Created HTML file for backend", "url", newBackend.URL, "path", htmlFilePath)
 
 		// Save updated configuration to config.json
-		if err := config.SaveConfig(s.configPath, s.cfg); err != nil {
-			logger.ErrorKV("Failed to save config", "error", err)
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
 			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
 			return
 		}
 
-		logger.InfoKV("Successfully added new backend", "url", newBackend.URL, "index", backendIndex)
+		log.InfoKV("Successfully added new backend", "url", newBackend.URL, "id", newBackend.ID, "index", backendIndex)
 		w.WriteHeader(http.StatusCreated)
 
 	case http.MethodDelete:
@@ -263,41 +510,357 @@ func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Check if backend exists
-		s.mu.Lock()
-		var backendIndex = -1
-		for i, b := range s.cfg.Backends {
-			if b.URL == backendURL {
-				backendIndex = i
-				break
-			}
-		}
-		if backendIndex == -1 {
-			s.mu.Unlock()
+		if !s.removeBackend(backendURL) {
 			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Backend with URL %s not found", backendURL))
 			return
 		}
 
-		// Remove backend from configuration
-		s.cfg.Backends = append(s.cfg.Backends[:backendIndex], s.cfg.Backends[backendIndex+1:]...)
-		s.balancer = balancer.NewBalancer(s.cfg.Backends)
-		s.mu.Unlock()
-
 		// Save updated configuration to config.json
-		if err := config.SaveConfig(s.configPath, s.cfg); err != nil {
-			logger.ErrorKV("Failed to save config", "error", err)
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
 			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
 			return
 		}
 
-		logger.InfoKV("Successfully deleted backend", "url", backendURL)
+		log.InfoKV("Successfully deleted backend", "url", backendURL)
 		w.WriteHeader(http.StatusNoContent)
 
+	case http.MethodPatch:
+		s.patchBackend(w, r)
+
 	default:
 		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
+// patchBackend changes the state of a backend and optionally schedules its removal once drained.
+func (s *Server) patchBackend(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	var input struct {
+		ID                string `json:"id"`
+		URL               string `json:"url"`
+		State             string `json:"state"`
+		RemoveWhenDrained bool   `json:"remove_when_drained"`
+		DrainTimeout      string `json:"drain_timeout"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.ID == "" && input.URL == "" {
+		s.sendError(w, http.StatusBadRequest, "Backend ID or URL is required")
+		return
+	}
+	if input.State == "" {
+		s.sendError(w, http.StatusBadRequest, "Backend state is required")
+		return
+	}
+	if err := config.ValidateBackendState(input.State); err != nil {
+		s.sendError(w, http.StatusBadRequest, "State must be one of active, draining, maintenance")
+		return
+	}
+	if input.RemoveWhenDrained && input.State != models.BackendDraining {
+		s.sendError(w, http.StatusBadRequest, "remove_when_drained requires state draining")
+		return
+	}
+	var drainTimeout time.Duration
+	if input.DrainTimeout != "" {
+		var err error
+		drainTimeout, err = time.ParseDuration(input.DrainTimeout)
+		if err != nil || drainTimeout < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid drain_timeout")
+			return
+		}
+	}
+
+	id := input.ID
+	if id == "" {
+		id = input.URL
+	}
+	backend, pool := s.lookupBackend(id)
+	if backend == nil {
+		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Backend %s not found", id))
+		return
+	}
+	if pool != nil && input.RemoveWhenDrained {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Backend %s belongs to pool %s, remove it through /api/pools", id, pool.Name))
+		return
+	}
+
+	s.mu.Lock()
+	backend.SetState(input.State)
+	s.cancelDrainLocked(backend.URL)
+	if input.RemoveWhenDrained {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.drains[backend.URL] = cancel
+		go s.removeWhenDrained(ctx, backend, drainTimeout)
+	}
+	s.mu.Unlock()
+	if input.State != models.BackendActive {
+		// Upgraded connections never finish on their own; closed clients reconnect to other backends
+		if closed := s.proxy.CloseUpgraded(backend.URL); closed > 0 {
+			log.InfoKV("Closed upgraded connections", "url", backend.URL, "count", closed)
+		}
+	}
+	if input.State == models.BackendMaintenance {
+		// TCP connections of a draining backend are counted as in flight and may finish on their own
+		if closed := s.closeTCPConnections(backend.URL); closed > 0 {
+			log.InfoKV("Closed TCP connections", "url", backend.URL, "count", closed)
+		}
+		if closed := s.closeUDPSessions(backend.URL); closed > 0 {
+			log.InfoKV("Closed UDP sessions", "url", backend.URL, "count", closed)
+		}
+	}
+
+	if err := s.saveConfig(); err != nil {
+		log.ErrorKV("Failed to save config", "error", err)
+		s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+		return
+	}
+
+	log.InfoKV("Backend state changed", "id", backend.ID, "url", backend.URL, "state", input.State, "in_flight", backend.InFlight(), "remove_when_drained", input.RemoveWhenDrained, "drain_timeout", drainTimeout)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.backendStatus(backend)); err != nil {
+		s.sendError(w, http.StatusInternalServerError, "Failed to encode backend")
+	}
+}
+
+// removeWhenDrained waits until the backend has no in-flight requests or the timeout expires,
+// then removes it and saves the configuration. A zero timeout waits indefinitely.
+func (s *Server) removeWhenDrained(ctx context.Context, backend *models.Backend, timeout time.Duration) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	reason := "drained"
+wait:
+	for backend.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			reason = "timeout"
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	if !s.removeBackend(backend.URL) {
+		return
+	}
+	if err := s.saveConfig(); err != nil {
+		logger.ErrorKV("Failed to save config", "error", err)
+		return
+	}
+	logger.InfoKV("Removed draining backend", "id", backend.ID, "url", backend.URL, "reason", reason, "in_flight", backend.InFlight())
+}
+
+// saveConfig writes the configuration to the config file. It is encoded under s.mu.RLock, so
+// changes made at the same time, e.g. by a drain, never tear the saved state, and written
+// after the lock is released, so proxied requests do not wait for the disk. saveMu is held
+// from encoding to writing: the file always ends up with the state of the last save.
+// The caller must not hold s.mu.
+func (s *Server) saveConfig() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	data, err := config.MarshalConfig(s.cfg)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return config.WriteConfig(s.configPath, data)
+}
+
+// removeBackend deletes the backend with the given URL and reports whether it existed.
+func (s *Server) removeBackend(backendURL string) bool {
+	s.mu.Lock()
+	backendIndex := -1
+	for i, b := range s.cfg.Backends {
+		if b.URL == backendURL {
+			backendIndex = i
+			break
+		}
+	}
+	if backendIndex == -1 {
+		s.mu.Unlock()
+		return false
+	}
+
+	// Remove backend from configuration
+	s.cfg.Backends = append(s.cfg.Backends[:backendIndex], s.cfg.Backends[backendIndex+1:]...)
+	s.balancer = balancer.New(s.cfg.Strategy, s.cfg.Backends, s.cfg.SlowStart)
+	s.cancelDrainLocked(backendURL)
+	inUse := s.backendURLInUseLocked(backendURL)
+	s.mu.Unlock()
+
+	if inUse {
+		// A pool still uses the URL, and its history and connections are tracked by URL
+		return true
+	}
+	s.health.Forget(backendURL)
+	s.proxy.CloseUpgraded(backendURL)
+	s.closeTCPConnections(backendURL)
+	s.closeUDPSessions(backendURL)
+	return true
+}
+
+// cancelDrainLocked stops a pending automatic removal of the backend. The caller must hold s.mu.
+func (s *Server) cancelDrainLocked(backendURL string) {
+	if cancel, ok := s.drains[backendURL]; ok {
+		cancel()
+		delete(s.drains, backendURL)
+	}
+}
+
+// backendStatus builds the API view of a backend.
+func (s *Server) backendStatus(b *models.Backend) BackendStatus {
+	return BackendStatus{
+		Backend:         b,
+		State:           b.State(),
+		InFlight:        b.InFlight(),
+		Upgraded:        s.proxy.Upgraded(b.URL),
+		Connections:     s.tcpConnections(b.URL),
+		Sessions:        s.udpSessions(b.URL),
+		EffectiveWeight: balancer.EffectiveWeight(b, s.cfg.SlowStart, time.Now()),
+	}
+}
+
+// findBackend returns the backend with the given ID or URL, or nil if there is none.
+func (s *Server) findBackend(id string) *models.Backend {
+	b, _ := s.lookupBackend(id)
+	return b
+}
+
+// lookupBackend finds a backend of the default pool or of a named pool by ID or URL.
+// The returned pool is nil for backends of the default pool.
+func (s *Server) lookupBackend(id string) (*models.Backend, *models.Pool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, b := range s.cfg.Backends {
+		if b.ID == id || b.URL == id {
+			return b, nil
+		}
+	}
+	for _, p := range s.cfg.Pools {
+		for _, b := range p.Backends {
+			if b.ID == id || b.URL == id {
+				return b, p
+			}
+		}
+	}
+	return nil, nil
+}
+
+// allBackendsLocked returns the backends of the default pool followed by those of the named pools.
+// The caller must hold s.mu.
+func (s *Server) allBackendsLocked() []*models.Backend {
+	backends := append([]*models.Backend(nil), s.cfg.Backends...)
+	for _, p := range s.cfg.Pools {
+		backends = append(backends, p.Backends...)
+	}
+	return backends
+}
+
+// ConfigSnapshot returns a copy of the configuration with its own backend and pool lists, so it
+// can be read without the server lock while the API adds, replaces and removes backends and pools.
+// The backends themselves are shared.
+func (s *Server) ConfigSnapshot() *models.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cfg := *s.cfg
+	cfg.Backends = append([]*models.Backend(nil), s.cfg.Backends...)
+	cfg.Pools = make([]*models.Pool, len(s.cfg.Pools))
+	for i, p := range s.cfg.Pools {
+		pool := *p
+		pool.Backends = append([]*models.Backend(nil), p.Backends...)
+		cfg.Pools[i] = &pool
+	}
+	return &cfg
+}
+
+// uniqueBackendID returns "backend<index>", moving on to the next free index if it is taken.
+// The caller must hold s.mu.
+func (s *Server) uniqueBackendID(index int) string {
+	for {
+		id := config.DefaultBackendID(index - 1)
+		taken := false
+		for _, b := range s.cfg.Backends {
+			if b.ID == id {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return id
+		}
+		index++
+	}
+}
+
+// handleBackendCheck runs a health probe against a backend immediately.
+// @Summary Check backend health now
+// @Description Runs the backend's configured health probe immediately, updates its status and returns the result.
+// @Tags Backends
+// @Produce json
+// @Param id path string true "Backend ID (e.g. backend1)"
+// @Success 200 {object} health.ProbeResult "Probe result"
+// @Failure 404 {object} ErrorResponse "Backend not found"
+// @Router /backends/{id}/check [post]
+func (s *Server) handleBackendCheck(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	id := r.PathValue("id")
+	backend, pool := s.lookupBackend(id)
+	if backend == nil {
+		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Backend %s not found", id))
+		return
+	}
+
+	path := s.cfg.HealthCheckPath
+	var poolTLS *models.UpstreamTLSConfig
+	if pool != nil {
+		if pool.HealthCheckPath != "" {
+			path = pool.HealthCheckPath
+		}
+		poolTLS = pool.TLS
+	}
+	result := s.health.Check(r.Context(), backend, path, poolTLS)
+	log.InfoKV("On-demand health check", "id", backend.ID, "url", backend.URL, "status", result.Status, "latency_ms", result.LatencyMS)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		s.sendError(w, http.StatusInternalServerError, "Failed to encode probe result")
+	}
+}
+
+// handleBackendHealth returns the recent health probe results of a backend.
+// @Summary Get backend health history
+// @Description Returns the last probe results of a backend, oldest first.
+// @Tags Backends
+// @Produce json
+// @Param id path string true "Backend ID (e.g. backend1)"
+// @Success 200 {array} health.ProbeResult "Probe history"
+// @Failure 404 {object} ErrorResponse "Backend not found"
+// @Router /backends/{id}/health [get]
+func (s *Server) handleBackendHealth(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	backend := s.findBackend(id)
+	if backend == nil {
+		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Backend %s not found", id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.health.History(backend.URL)); err != nil {
+		s.sendError(w, http.StatusInternalServerError, "Failed to encode health history")
+	}
+}
+
 // handleRateLimit updates rate-limiting parameters.
 // @Summary Update global rate limit
 // @Description Update the global rate-limiting parameters (capacity and rate).
@@ -310,6 +873,7 @@ func (s *Server) handleBackends(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} ErrorResponse "Failed to save configuration"
 // @Router /ratelimit [patch]
 func (s *Server) handleRateLimit(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	if r.Method != http.MethodPatch {
 		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
@@ -342,13 +906,13 @@ func (s *Server) handleRateLimit(w http.ResponseWriter, r *http.Request) {
 	s.mu.Unlock()
 
 	// Save updated configuration to config.json
-	if err := config.SaveConfig(s.configPath, s.cfg); err != nil {
-		logger.ErrorKV("Failed to save config", "error", err)
+	if err := s.saveConfig(); err != nil {
+		log.ErrorKV("Failed to save config", "error", err)
 		s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
 		return
 	}
 
-	logger.InfoKV("Successfully updated global rate limit", "capacity", params.Capacity, "rate", params.Rate)
+	log.InfoKV("Successfully updated global rate limit", "capacity", params.Capacity, "rate", params.Rate)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -371,6 +935,7 @@ func (s *Server) handleRateLimit(w http.ResponseWriter, r *http.Request) {
 // @Router /clients [post]
 // @Router /clients [delete]
 func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	switch r.Method {
 	case http.MethodGet:
 		s.mu.RLock()
@@ -389,17 +954,17 @@ func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if client.ClientID == "" {
-			logger.ErrorKV("Client ID is empty", "client_id", client.ClientID)
+			log.ErrorKV("Client ID is empty", "client_id", client.ClientID)
 			s.sendError(w, http.StatusBadRequest, "Client ID is required")
 			return
 		}
 		if client.Capacity <= 0 {
-			logger.ErrorKV("Invalid client capacity", "client_id", client.ClientID, "capacity", client.Capacity)
+			log.ErrorKV("Invalid client capacity", "client_id", client.ClientID, "capacity", client.Capacity)
 			s.sendError(w, http.StatusBadRequest, "Capacity must be positive")
 			return
 		}
 		if client.Rate <= 0 {
-			logger.ErrorKV("Invalid client rate", "client_id", client.ClientID, "rate", client.Rate)
+			log.ErrorKV("Invalid client rate", "client_id", client.ClientID, "rate", client.Rate)
 			s.sendError(w, http.StatusBadRequest, "Rate must be positive")
 			return
 		}
@@ -422,13 +987,13 @@ func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
 		s.mu.Unlock()
 
 		// Save updated configuration to config.json
-		if err := config.SaveConfig(s.configPath, s.cfg); err != nil {
-			logger.ErrorKV("Failed to save config", "error", err)
+		if err := s.saveConfig(); err != nil {
+			log.ErrorKV("Failed to save config", "error", err)
 			s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
 			return
 		}
 
-		logger.InfoKV("Successfully added new client", "client_id", client.ClientID, "capacity", client.Capacity, "rate", client.Rate)
+		log.InfoKV("Successfully added new client", "client_id", client.ClientID, "capacity", client.Capacity, "rate", client.Rate)
 		w.WriteHeader(http.StatusCreated)
 
 	case http.MethodDelete:
@@ -447,13 +1012,13 @@ func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
 				s.mu.Unlock()
 
 				// Save updated configuration to config.json
-				if err := config.SaveConfig(s.configPath, s.cfg); err != nil {
-					logger.ErrorKV("Failed to save config", "error", err)
+				if err := s.saveConfig(); err != nil {
+					log.ErrorKV("Failed to save config", "error", err)
 					s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
 					return
 				}
 
-				logger.InfoKV("Successfully deleted client", "client_id", clientID)
+				log.InfoKV("Successfully deleted client", "client_id", clientID)
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
@@ -466,21 +1031,192 @@ func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Start launches the server on the specified port.
+// Start launches the server on the specified port or unix:///path socket,
+// created with the permissions of server.socket_mode.
 func (s *Server) Start(port string) error {
-	s.server = &http.Server{
-		Addr:    ":" + port,
-		Handler: s.Handler(),
+	ln, err := listen(port, s.cfg.Server.SocketMode)
+	if err != nil {
+		return err
 	}
 	logger.InfoKV("Starting server", "port", port)
-	return s.server.ListenAndServe()
+	return s.Serve(ln)
 }
 
-// Shutdown gracefully stops the server.
-func (s *Server) Shutdown(ctx context.Context) error {
-	if s.server == nil {
-		return nil
+// Serve accepts connections on the listener until the server is shut down.
+// The timeouts and limits of cfg.Server apply, see newHTTPServer. With tls.redirect_http
+// the plain port only redirects to the HTTPS listener, otherwise server.h2c also accepts
+// HTTP/2 without TLS there, e.g. from gRPC clients.
+func (s *Server) Serve(ln net.Listener) error {
+	limits := s.cfg.Server.WithDefaults()
+	handler := s.Handler()
+	var h2s *http2.Server
+	if s.cfg.TLS.Enabled && s.cfg.TLS.RedirectHTTP {
+		handler = s.RedirectHandler()
+	} else if limits.H2C {
+		h2s = &http2.Server{IdleTimeout: limits.IdleTimeout.Std()}
+		handler = h2c.NewHandler(handler, h2s)
+	}
+	srv := s.newHTTPServer(handler, limits)
+	var hijacked *hijackedConns
+	ln = netutil.LimitListener(ln, limits.MaxConnections)
+	if h2s != nil {
+		// h2c hijacks the connections: Shutdown sends them GOAWAY through the HTTP/2 server
+		// and waits for their streams with publicServer
+		if err := http2.ConfigureServer(srv, h2s); err != nil {
+			return fmt.Errorf("failed to configure h2c: %w", err)
+		}
+		hijacked = newHijackedConns()
+		srv.ConnState = hijacked.connState
+		ln = hijacked.listener(ln)
+	}
+	s.mu.Lock()
+	s.server = srv
+	s.hijacked = hijacked
+	s.mu.Unlock()
+	return srv.Serve(ln)
+}
+
+// StartAdmin launches the admin listener on the specified port or unix:///path socket,
+// created with the permissions of admin_socket_mode.
+func (s *Server) StartAdmin(port string) error {
+	ln, err := listen(port, s.cfg.AdminSocketMode)
+	if err != nil {
+		return err
+	}
+	logger.InfoKV("Starting admin server", "port", port)
+	return s.ServeAdmin(ln)
+}
+
+// ServeAdmin accepts admin connections on the listener until the server is shut down.
+func (s *Server) ServeAdmin(ln net.Listener) error {
+	s.mu.Lock()
+	s.adminServer = &http.Server{
+		Handler: s.AdminHandler(),
 	}
+	srv := s.adminServer
+	s.mu.Unlock()
+	return srv.Serve(ln)
+}
+
+// listener is a server that can be shut down gracefully or closed, e.g. *http.Server or *l4.TCPProxy.
+type listener interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+// shutdownAll closes the listeners of all servers at once and waits for their requests to finish.
+func shutdownAll(ctx context.Context, servers []listener) error {
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, srv := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = srv.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// inFlight returns the number of requests currently proxied to backends.
+func (s *Server) inFlight() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var total int64
+	for _, b := range s.allBackendsLocked() {
+		total += b.InFlight()
+	}
+	return total
+}
+
+// Shutdown stops the server in phases. Readiness is reported as failing first, then the
+// pre-stop delay gives upstream load balancers time to notice. After that the listener is
+// closed and in-flight requests get up to the drain timeout to finish; whatever remains is
+// force-closed. Pending Redis writes of the rate limiter are flushed last.
+func (s *Server) Shutdown(ctx context.Context) error {
 	logger.Info("Shutting down server")
-	return s.server.Shutdown(ctx)
+	preStopDelay := s.cfg.Shutdown.PreStopDelay.Std()
+	drainTimeout := s.cfg.Shutdown.DrainTimeout.Std()
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+
+	s.ready.Store(false)
+	s.stopRollouts()
+	logger.Info("Shutdown phase 1: readiness set to failing")
+
+	if preStopDelay > 0 {
+		logger.InfoKV("Shutdown phase 2: waiting pre-stop delay", "delay", preStopDelay)
+		timer := time.NewTimer(preStopDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	s.mu.RLock()
+	var servers []listener
+	if s.server != nil && s.hijacked != nil {
+		servers = append(servers, publicServer{Server: s.server, hijacked: s.hijacked})
+	} else if s.server != nil {
+		servers = append(servers, s.server)
+	}
+	if s.tlsServer != nil {
+		servers = append(servers, s.tlsServer)
+	}
+	s.mu.RUnlock()
+	for _, p := range s.tcpProxies {
+		servers = append(servers, p)
+	}
+	for _, p := range s.udpProxies {
+		servers = append(servers, p)
+	}
+
+	var err error
+	if len(servers) > 0 {
+		logger.InfoKV("Shutdown phase 3: closed listener, draining in-flight requests", "timeout", drainTimeout, "in_flight", s.inFlight())
+		drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+		err = shutdownAll(drainCtx, servers)
+		cancel()
+		if err != nil {
+			logger.WarnKV("Shutdown phase 4: drain deadline exceeded, closing remaining connections", "in_flight", s.inFlight(), "error", err)
+			for _, srv := range servers {
+				if cerr := srv.Close(); cerr != nil {
+					logger.ErrorKV("Failed to close remaining connections", "error", cerr)
+				}
+			}
+		} else {
+			logger.Info("Shutdown phase 4: all in-flight requests completed")
+		}
+	}
+
+	s.mu.RLock()
+	adminSrv := s.adminServer
+	s.mu.RUnlock()
+	if adminSrv != nil {
+		// The admin listener stays up until traffic is drained so probes keep reporting
+		if aerr := adminSrv.Shutdown(ctx); aerr != nil {
+			logger.ErrorKV("Admin server shutdown failed", "error", aerr)
+			adminSrv.Close()
+		}
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), redisFlushTimeout)
+	defer cancel()
+	logger.Info("Shutdown phase 5: flushing rate limiter state")
+	if ferr := s.rateLimiter.Close(flushCtx); ferr != nil {
+		logger.ErrorKV("Failed to flush rate limiter state", "error", ferr)
+	}
+
+	if cerr := s.accessLog.Close(); cerr != nil {
+		logger.ErrorKV("Failed to close access log", "error", cerr)
+	}
+	if terr := s.tracer.Shutdown(flushCtx); terr != nil {
+		logger.ErrorKV("Failed to flush traces", "error", terr)
+	}
+
+	logger.Info("Shutdown complete")
+	return err
 }
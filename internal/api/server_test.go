@@ -2,16 +2,25 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"load-balancer/internal/health"
 	"load-balancer/internal/logger"
 	"load-balancer/internal/models"
+	"load-balancer/internal/tracing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestServer_HandleRequest(t *testing.T) {
@@ -140,6 +149,48 @@ func TestServer_HandleBackends(t *testing.T) {
 		}
 	})
 
+	t.Run("POST backend URLs", func(t *testing.T) {
+		tests := []struct {
+			url      string
+			protocol string
+			want     int
+		}{
+			{"unix:///run/app.sock", "", http.StatusCreated},
+			{"unix://run/app.sock", "", http.StatusBadRequest},
+			{"localhost:8003", "", http.StatusBadRequest},
+			{"http://localhost:8004", "h2c", http.StatusCreated},
+			{"https://localhost:8005", "h2c", http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			body := `{"url": "` + tt.url + `", "protocol": "` + tt.protocol + `"}`
+			req, _ := http.NewRequest("POST", "/api/backends", bytes.NewBufferString(body))
+			rr := httptest.NewRecorder()
+			server.handleBackends(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("POST %s %s: expected status %d, got %d: %s", tt.url, tt.protocol, tt.want, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("GET effective weight during slow start", func(t *testing.T) {
+		server.cfg.SlowStart = models.SlowStartConfig{Window: models.Duration(time.Hour)}
+		defer func() { server.cfg.SlowStart = models.SlowStartConfig{} }()
+		server.cfg.Backends[0].HealthySince = time.Now()
+		server.cfg.Backends[0].Weight = 5
+
+		req, _ := http.NewRequest("GET", "/api/backends", nil)
+		rr := httptest.NewRecorder()
+		server.handleBackends(rr, req)
+
+		var backends []BackendStatus
+		if err := json.NewDecoder(rr.Body).Decode(&backends); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(backends) == 0 || backends[0].EffectiveWeight < 0.5 || backends[0].EffectiveWeight > 0.6 {
+			t.Errorf("Expected effective weight near 0.5 at the start of slow start, got %+v", backends)
+		}
+	})
+
 	t.Run("DELETE backend", func(t *testing.T) {
 		req, _ := http.NewRequest("DELETE", "/api/backends?url=http://localhost:8001", nil)
 		rr := httptest.NewRecorder()
@@ -151,6 +202,259 @@ func TestServer_HandleBackends(t *testing.T) {
 	})
 }
 
+func TestServer_BackendHealthEndpoints(t *testing.T) {
+	logger.Init()
+
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer backendServer.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	server := NewServer(
+		[]*models.Backend{{URL: backendServer.URL, Healthy: false}},
+		health.NewHealthChecker(),
+		10, 1,
+		nil, "", configPath,
+	)
+	handler := server.Handler()
+
+	t.Run("POST check", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/backends/backend1/check", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var result health.ProbeResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !result.Healthy || result.Status != "healthy" || result.Error != "" {
+			t.Errorf("Expected healthy probe result, got %+v", result)
+		}
+		if !server.cfg.Backends[0].Healthy {
+			t.Error("Expected backend to be marked healthy after check")
+		}
+	})
+
+	t.Run("GET health history", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/backends/backend1/health", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var history []health.ProbeResult
+		if err := json.NewDecoder(rr.Body).Decode(&history); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(history) != 1 || !history[0].Healthy {
+			t.Errorf("Expected one healthy result in history, got %+v", history)
+		}
+	})
+
+	t.Run("Unknown backend", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/backends/backend9/check", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+	})
+}
+
+func TestServer_PatchBackendState(t *testing.T) {
+	logger.Init()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	server := NewServer(
+		[]*models.Backend{
+			{URL: "http://localhost:8001", Healthy: true},
+			{URL: "http://localhost:8002", Healthy: true},
+		},
+		health.NewHealthChecker(),
+		10, 1,
+		nil, "", configPath,
+	)
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PATCH", "/api/backends", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		server.handleBackends(rr, req)
+		return rr
+	}
+
+	t.Run("Invalid state", func(t *testing.T) {
+		rr := patch(`{"id": "backend1", "state": "sleeping"}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Maintenance", func(t *testing.T) {
+		rr := patch(`{"url": "http://localhost:8002", "state": "maintenance"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		for i := 0; i < 3; i++ {
+			if b := server.balancer.NextBackend(); b == nil || b.URL != "http://localhost:8001" {
+				t.Errorf("Expected only backend1 to be selected, got %v", b)
+			}
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil || !bytes.Contains(data, []byte(`"state": "maintenance"`)) {
+			t.Errorf("Expected maintenance state in saved config, got %s (%v)", data, err)
+		}
+	})
+
+	t.Run("Drain and remove", func(t *testing.T) {
+		backend := server.findBackend("backend1")
+		backend.Acquire()
+
+		rr := patch(`{"id": "backend1", "state": "draining", "remove_when_drained": true, "drain_timeout": "10s"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var status struct {
+			ID       string
+			State    string
+			InFlight int64
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if status.State != models.BackendDraining || status.InFlight != 1 {
+			t.Errorf("Expected draining backend with 1 in-flight request, got %+v", status)
+		}
+		if b := server.balancer.NextBackend(); b != nil {
+			t.Errorf("Expected no backend for new requests, got %v", b.URL)
+		}
+
+		// The backend stays while its request is in flight
+		time.Sleep(3 * drainPollInterval)
+		if server.findBackend("backend1") == nil {
+			t.Fatal("Backend removed before its in-flight request finished")
+		}
+
+		backend.Release()
+		deadline := time.Now().Add(2 * time.Second)
+		for server.findBackend("backend1") != nil && time.Now().Before(deadline) {
+			time.Sleep(drainPollInterval)
+		}
+		if server.findBackend("backend1") != nil {
+			t.Error("Expected drained backend to be removed")
+		}
+	})
+
+	t.Run("Drain timeout", func(t *testing.T) {
+		backend := server.findBackend("backend2")
+		backend.Acquire()
+		defer backend.Release()
+
+		rr := patch(`{"id": "backend2", "state": "draining", "remove_when_drained": true, "drain_timeout": "200ms"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for server.findBackend("backend2") != nil && time.Now().Before(deadline) {
+			time.Sleep(drainPollInterval)
+		}
+		if server.findBackend("backend2") != nil {
+			t.Error("Expected backend to be removed after drain timeout")
+		}
+	})
+}
+
+func TestServer_PatchBackendStateDuringTraffic(t *testing.T) {
+	logger.Init()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer backend.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer other.Close()
+	server := NewServer(
+		[]*models.Backend{{URL: backend.URL, Healthy: true}, {URL: other.URL, Healthy: true}},
+		health.NewHealthChecker(),
+		100000, 100000,
+		nil, "", filepath.Join(t.TempDir(), "config.json"),
+	)
+
+	// Requests are proxied while the states change; run with -race
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				req := httptest.NewRequest("GET", "/", nil)
+				req.RemoteAddr = "127.0.0.1:12345"
+				server.handleRequest(httptest.NewRecorder(), req)
+			}
+		}()
+	}
+	for i := 0; i < 21; i++ {
+		state := []string{models.BackendDraining, models.BackendMaintenance, models.BackendActive}[i%3]
+		req := httptest.NewRequest("PATCH", "/api/backends", strings.NewReader(`{"id": "backend1", "state": "`+state+`"}`))
+		rr := httptest.NewRecorder()
+		server.handleBackends(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if state := server.findBackend("backend1").State(); state != models.BackendActive {
+		t.Errorf("Expected the last state active, got %q", state)
+	}
+}
+
+func TestServer_SaveConfigSharesLock(t *testing.T) {
+	logger.Init()
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	server := NewServer([]*models.Backend{{URL: "http://localhost:8001", Healthy: true}}, health.NewHealthChecker(), 10, 1, nil, "", configPath)
+
+	// A request picking a backend holds s.mu for reading; saving must not wait for it
+	server.mu.RLock()
+	done := make(chan error, 1)
+	go func() { done <- server.saveConfig() }()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("Save waited for the read lock of a request")
+		server.mu.RUnlock()
+		err = <-done
+		server.mu.RLock()
+	}
+	server.mu.RUnlock()
+	if err != nil {
+		t.Errorf("Save failed: %v", err)
+	}
+
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("Expected the config to be saved, got %v", err)
+	}
+}
+
 func TestServer_HandleRateLimit(t *testing.T) {
 	logger.Init()
 
@@ -171,6 +475,34 @@ func TestServer_HandleRateLimit(t *testing.T) {
 	})
 }
 
+func TestServer_RateLimitKeys(t *testing.T) {
+	logger.Init()
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+	server := NewServer([]*models.Backend{{URL: backendServer.URL, Healthy: true}}, health.NewHealthChecker(), 1, 0.001, nil, "", filepath.Join(t.TempDir(), "config.json"))
+
+	tests := []struct {
+		remoteAddr string
+		wantStatus int
+	}{
+		{"[::1]:1111", http.StatusOK},
+		{"[::2]:2222", http.StatusOK},
+		{"[::1]:3333", http.StatusTooManyRequests}, // Same address from another port
+		{"192.0.2.1:4444", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		rr := httptest.NewRecorder()
+		server.handleRequest(rr, req)
+		if rr.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.remoteAddr, tt.wantStatus, rr.Code)
+		}
+	}
+}
+
 func TestServer_HandleClients(t *testing.T) {
 	logger.Init()
 
@@ -201,3 +533,345 @@ func TestServer_HandleClients(t *testing.T) {
 		}
 	})
 }
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	logger.Init()
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write([]byte("done"))
+	}))
+	defer backendServer.Close()
+
+	newServer := func(drainTimeout time.Duration) (*Server, string) {
+		server := NewServer(
+			[]*models.Backend{{URL: backendServer.URL, Healthy: true}},
+			health.NewHealthChecker(),
+			10, 1,
+			nil, "", filepath.Join(t.TempDir(), "config.json"),
+		)
+		server.cfg.Shutdown = models.ShutdownConfig{
+			PreStopDelay: models.Duration(300 * time.Millisecond),
+			DrainTimeout: models.Duration(drainTimeout),
+		}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go server.Serve(ln)
+		return server, "http://" + ln.Addr().String()
+	}
+
+	// shuttingDown reports the shutdown check of /readyz; the health checker is not
+	// started in this test, so only that check is of interest.
+	shuttingDown := func(server *Server) bool {
+		rr := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+		var resp ProbeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode readiness response: %v", err)
+		}
+		return !resp.Checks["shutdown"].OK
+	}
+
+	t.Run("In-flight request completes", func(t *testing.T) {
+		server, addr := newServer(5 * time.Second)
+		if shuttingDown(server) {
+			t.Fatal("Expected readiness shutdown check to pass before shutdown")
+		}
+
+		type result struct {
+			body string
+			err  error
+		}
+		results := make(chan result, 1)
+		go func() {
+			resp, err := http.Get(addr + "/")
+			if err != nil {
+				results <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			results <- result{body: string(body), err: err}
+		}()
+		<-started
+
+		shutdownErr := make(chan error, 1)
+		go func() { shutdownErr <- server.Shutdown(context.Background()) }()
+
+		// During the pre-stop delay readiness fails but the listener still accepts requests
+		time.Sleep(100 * time.Millisecond)
+		if !shuttingDown(server) {
+			t.Error("Expected readiness to fail during shutdown")
+		}
+		if resp, err := http.Get(addr + "/readyz"); err != nil {
+			t.Errorf("Expected listener to accept connections during pre-stop delay, got %v", err)
+		} else {
+			resp.Body.Close()
+		}
+
+		// After the pre-stop delay new connections are refused while the request drains
+		time.Sleep(400 * time.Millisecond)
+		if _, err := net.DialTimeout("tcp", strings.TrimPrefix(addr, "http://"), time.Second); err == nil {
+			t.Error("Expected new connections to be refused after the pre-stop delay")
+		}
+
+		release <- struct{}{}
+		if res := <-results; res.err != nil || res.body != "done" {
+			t.Errorf("Expected in-flight request to complete, got %q, %v", res.body, res.err)
+		}
+		if err := <-shutdownErr; err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	})
+
+	t.Run("Drain deadline exceeded", func(t *testing.T) {
+		server, addr := newServer(200 * time.Millisecond)
+		defer func() { release <- struct{}{} }()
+
+		requestErr := make(chan error, 1)
+		go func() {
+			resp, err := http.Get(addr + "/")
+			if err == nil {
+				_, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			requestErr <- err
+		}()
+		<-started
+
+		start := time.Now()
+		if err := server.Shutdown(context.Background()); err == nil {
+			t.Error("Expected shutdown to report the exceeded drain deadline")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Shutdown took %v, expected it to stop after the drain deadline", elapsed)
+		}
+		if err := <-requestErr; err == nil {
+			t.Error("Expected the remaining request to be cut off")
+		}
+	})
+}
+
+func TestServer_RequestID(t *testing.T) {
+	logger.Init()
+	var received string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Request-ID")
+		w.Header().Set("X-Request-ID", "backend-generated")
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	server := NewServer(
+		[]*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		health.NewHealthChecker(),
+		1, 0.001,
+		nil, "", filepath.Join(t.TempDir(), "config.json"),
+	)
+	handler := server.Handler()
+
+	t.Run("Incoming ID is forwarded and returned", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", "abc-123")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if received != "abc-123" {
+			t.Errorf("Expected backend to receive abc-123, got %q", received)
+		}
+		if got := rr.Header().Values("X-Request-ID"); len(got) != 1 || got[0] != "abc-123" {
+			t.Errorf("Expected a single X-Request-ID abc-123 in the response, got %v", got)
+		}
+	})
+
+	t.Run("Generated ID is returned on errors", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429, got %d", rr.Code)
+		}
+		if id := rr.Header().Get("X-Request-ID"); len(id) != 36 {
+			t.Errorf("Expected a generated UUID, got %q", id)
+		}
+	})
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	logger.Init()
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "access.log")
+	cfg := &models.Config{
+		Port:                ":8087",
+		Backends:            []*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 1, Rate: 0.001},
+		AccessLog: models.AccessLogConfig{
+			Enabled:  true,
+			Format:   models.AccessLogTemplate,
+			Template: "{request_id} {status} {rate_limit} {upstream} {bytes_out}",
+			Output:   models.AccessLogFile,
+			Path:     logPath,
+		},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(dir, "config.json"))
+	handler := server.Handler()
+
+	for _, id := range []string{"first", "second"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", id)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if err := server.accessLog.Close(); err != nil {
+		t.Fatalf("Failed to close access log: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read access log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{
+		"first 200 allowed " + backendServer.URL + " 2",
+		"second 429 rejected - 45",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d records, got %q", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Expected record %q, got %q", want[i], lines[i])
+		}
+	}
+}
+
+func TestServer_Tracing(t *testing.T) {
+	logger.Init()
+	var traceparent string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	server := NewServer(
+		[]*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		health.NewHealthChecker(),
+		10, 1,
+		nil, "", filepath.Join(t.TempDir(), "config.json"),
+	)
+	exporter := tracetest.NewInMemoryExporter()
+	server.tracer = tracing.NewWithExporter(models.TracingConfig{Enabled: true}, exporter)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected span %q in the incoming trace, got %s", span.Name, span.SpanContext.TraceID())
+		}
+		spans[span.Name] = span
+	}
+	serverSpan, ok := spans["GET"]
+	if !ok || len(spans) != 4 {
+		t.Fatalf("Expected server span with 3 children, got %v", spans)
+	}
+	for _, name := range []string{tracing.SpanRateLimit, tracing.SpanSelectBackend, tracing.SpanUpstream} {
+		if span, ok := spans[name]; !ok || span.Parent.SpanID() != serverSpan.SpanContext.SpanID() {
+			t.Errorf("Expected %s span to be a child of the server span", name)
+		}
+	}
+	upstream := spans[tracing.SpanUpstream]
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + upstream.SpanContext.SpanID().String() + "-01"; traceparent != want {
+		t.Errorf("Expected backend to receive traceparent %q, got %q", want, traceparent)
+	}
+	var status int64
+	for _, attr := range upstream.Attributes {
+		if attr.Key == "http.response.status_code" {
+			status = attr.Value.AsInt64()
+		}
+	}
+	if status != http.StatusOK {
+		t.Errorf("Expected upstream span to record status 200, got %d", status)
+	}
+}
+
+func TestServer_UpstreamTimeouts(t *testing.T) {
+	logger.Init()
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	cfg := &models.Config{
+		Port:                ":8087",
+		Backends:            []*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 100, Rate: 100},
+		Timeouts:            models.TimeoutsConfig{Request: models.Duration(50 * time.Millisecond)},
+		Routes: []*models.Route{{
+			ID:       "reports",
+			Match:    models.RouteMatch{PathPrefix: "/reports"},
+			Pool:     models.DefaultPool,
+			Timeouts: &models.TimeoutsConfig{Request: models.Duration(5 * time.Second)},
+		}},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	handler := server.Handler()
+
+	t.Run("Global deadline answers 504", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		if rr.Code != http.StatusGatewayTimeout {
+			t.Fatalf("Expected status 504, got %d", rr.Code)
+		}
+		var resp ErrorResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+		if resp.Code != http.StatusGatewayTimeout || !strings.Contains(resp.Message, "timed out") {
+			t.Errorf("Unexpected error response: %+v", resp)
+		}
+	})
+
+	t.Run("Route extends the deadline", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/reports/daily", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Client header shortens the route deadline", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/reports/daily", nil)
+		req.Header.Set(models.RequestTimeoutHeader, "50ms")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusGatewayTimeout {
+			t.Fatalf("Expected status 504, got %d", rr.Code)
+		}
+	})
+}
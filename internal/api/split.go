@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	mathrand "math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/statuswriter"
+)
+
+// stickyCookieMaxAge is how long the sticky cookie of a traffic split keeps a client on its side.
+const stickyCookieMaxAge = 30 * 24 * time.Hour
+
+// SplitStatus is the traffic split of a route as reported by /api/routes/{id}/split.
+type SplitStatus struct {
+	*models.TrafficSplit
+	Route      string     // ID of the route
+	Requests   int64      // Requests sent to the canary pool at the current weight
+	Failures   int64      // Of them, answered with 5xx or a gRPC server error, or not forwarded at all
+	NextStepAt *time.Time `json:",omitempty"` // When the rollout moves to the next weight
+}
+
+// canary tracks the requests a traffic split sends to its canary pool and runs the scheduled
+// rollout. A changed split gets a new canary, so the counters cover the current weight only.
+type canary struct {
+	split    *models.TrafficSplit
+	requests atomic.Int64
+	failures atomic.Int64
+	nextStep time.Time          // Zero without a scheduled step
+	cancel   context.CancelFunc // Stops the scheduled step, nil without one
+}
+
+// record counts a canary request and reports whether the error rate of the rollout is exceeded.
+func (c *canary) record(failed bool) bool {
+	requests := c.requests.Add(1)
+	failures := c.failures.Load()
+	if failed {
+		failures = c.failures.Add(1)
+	}
+	rollout := c.split.Rollout
+	if rollout == nil || rollout.MaxErrorRate <= 0 {
+		return false
+	}
+	minRequests := int64(rollout.MinRequests)
+	if minRequests == 0 {
+		minRequests = models.DefaultSplitMinRequests
+	}
+	return requests >= minRequests && float64(failures)/float64(requests) > rollout.MaxErrorRate
+}
+
+func (c *canary) stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// syncCanariesLocked keeps the canaries of unchanged splits, creates canaries for new and
+// changed ones and stops the rest. The caller must hold s.mu for writing.
+func (s *Server) syncCanariesLocked() {
+	canaries := make(map[string]*canary)
+	for _, route := range s.cfg.Routes {
+		if route.Split == nil {
+			continue
+		}
+		if c, ok := s.canaries[route.ID]; ok && c.split == route.Split {
+			canaries[route.ID] = c
+			continue
+		}
+		canaries[route.ID] = s.newCanary(route.ID, route.Split)
+	}
+	for id, c := range s.canaries {
+		if canaries[id] != c {
+			c.stop()
+		}
+	}
+	s.canaries = canaries
+}
+
+// newCanary creates the canary of a split and schedules its next rollout step unless the
+// split is paused or rolled back.
+func (s *Server) newCanary(routeID string, split *models.TrafficSplit) *canary {
+	c := &canary{split: split}
+	if split.State == models.SplitPaused || split.State == models.SplitRolledBack {
+		return c
+	}
+	if _, ok := split.NextStep(); !ok {
+		return c
+	}
+	interval := split.Rollout.Interval.Std()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.nextStep = time.Now().Add(interval)
+	go s.runRollout(ctx, routeID, c, interval)
+	return c
+}
+
+// runRollout moves the split to its next step after the interval, unless the split changes first.
+func (s *Server) runRollout(ctx context.Context, routeID string, c *canary, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	split, ok := s.updateSplit(routeID, c, func(split *models.TrafficSplit) bool {
+		next, ok := split.NextStep()
+		split.Weight = next
+		return ok
+	})
+	if !ok {
+		return
+	}
+	logger.InfoKV("Traffic split advanced", "route", routeID, "pool", split.Pool, "weight", split.Weight,
+		"requests", c.requests.Load(), "failures", c.failures.Load())
+}
+
+// rollback sets the weight of the split to 0 after its canary exceeded the error rate.
+func (s *Server) rollback(routeID string, c *canary) {
+	split, ok := s.updateSplit(routeID, c, func(split *models.TrafficSplit) bool {
+		split.Weight, split.State = 0, models.SplitRolledBack
+		return true
+	})
+	if !ok {
+		return
+	}
+	requests, failures := c.requests.Load(), c.failures.Load()
+	logger.WarnKV("Canary rolled back", "route", routeID, "pool", split.Pool, "requests", requests, "failures", failures,
+		"error_rate", float64(failures)/float64(requests), "max_error_rate", c.split.Rollout.MaxErrorRate)
+}
+
+// updateSplit applies change to a copy of the route's split if c is still its canary and saves
+// the configuration. It returns the new split and false if nothing changed.
+func (s *Server) updateSplit(routeID string, c *canary, change func(*models.TrafficSplit) bool) (*models.TrafficSplit, bool) {
+	s.mu.Lock()
+	route, index := s.findRouteLocked(routeID)
+	if route == nil || s.canaries[routeID] != c {
+		s.mu.Unlock()
+		return nil, false
+	}
+	split := *route.Split
+	if !change(&split) {
+		s.mu.Unlock()
+		return nil, false
+	}
+	if err := s.replaceSplitLocked(index, &split); err != nil {
+		s.mu.Unlock()
+		logger.ErrorKV("Failed to rebuild routing", "error", err)
+		return nil, false
+	}
+	s.mu.Unlock()
+	// Written outside the lock: a rollback runs on the request path. A step or rollback right
+	// after this one saves again, and saveConfig keeps the later state in the file
+	if err := s.saveConfig(); err != nil {
+		logger.ErrorKV("Failed to save config", "error", err)
+	}
+	return &split, true
+}
+
+// replaceSplitLocked replaces the route at index with a copy that has the given split.
+// The caller must hold s.mu for writing.
+func (s *Server) replaceSplitLocked(index int, split *models.TrafficSplit) error {
+	route := *s.cfg.Routes[index]
+	route.Split = split
+	routes := append([]*models.Route(nil), s.cfg.Routes...)
+	routes[index] = &route
+	s.cfg.Routes = routes
+	return s.rebuildRoutingLocked()
+}
+
+// stopRollouts stops the scheduled rollout steps, e.g. on shutdown.
+func (s *Server) stopRollouts() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.canaries {
+		c.stop()
+	}
+}
+
+// splitStatus builds the API view of a route's split. The caller must hold s.mu.
+func (s *Server) splitStatus(routeID string, split *models.TrafficSplit) SplitStatus {
+	status := SplitStatus{TrafficSplit: split, Route: routeID}
+	if c, ok := s.canaries[routeID]; ok {
+		status.Requests, status.Failures = c.requests.Load(), c.failures.Load()
+		if !c.nextStep.IsZero() {
+			next := c.nextStep
+			status.NextStepAt = &next
+		}
+	}
+	return status
+}
+
+// splitBucket assigns the request to one of 100 buckets; the requests of buckets below the
+// weight go to the canary. A sticky header or cookie value always gets the same bucket, hashed
+// together with the canary pool so every route to the pool sends the client the same way.
+// The returned cookie assigns a new client and must be set on the response.
+func splitBucket(r *http.Request, split *models.TrafficSplit) (int, *http.Cookie) {
+	if split.StickyHeader != "" {
+		if value := r.Header.Get(split.StickyHeader); value != "" {
+			return hashBucket(split.Pool, value), nil
+		}
+	}
+	if split.StickyCookie != "" {
+		if c, err := r.Cookie(split.StickyCookie); err == nil && c.Value != "" {
+			return hashBucket(split.Pool, c.Value), nil
+		}
+		id := make([]byte, 16)
+		rand.Read(id)
+		cookie := &http.Cookie{
+			Name:     split.StickyCookie,
+			Value:    hex.EncodeToString(id),
+			Path:     "/",
+			MaxAge:   int(stickyCookieMaxAge.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		}
+		return hashBucket(split.Pool, cookie.Value), cookie
+	}
+	return mathrand.IntN(100), nil
+}
+
+func hashBucket(pool, value string) int {
+	h := fnv.New32a()
+	h.Write([]byte(pool))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return int(h.Sum32() % 100)
+}
+
+// handleRouteSplit reports or changes the traffic split of a route.
+// @Summary Manage the traffic split of a route
+// @Description Get the traffic split of a route with the canary requests and failures counted at the current weight, or change it live. PATCH fields are optional and merged into the current split; a route without a split needs pool. Changing the weight of a rolled back split makes it active again unless state is given. Every change restarts the rollout interval and the error counters.
+// @Tags Routing
+// @Accept json
+// @Produce json
+// @Param id path string true "Route ID"
+// @Param body body object false "Split changes (PATCH), e.g., {\"weight\": 25}, {\"state\": \"paused\"} or {\"pool\": \"api-v2\", \"weight\": 5, \"sticky_cookie\": \"lb_canary\", \"rollout\": {\"steps\": [5, 25, 50, 100], \"interval\": \"10m\", \"max_error_rate\": 0.05}}"
+// @Success 200 {object} SplitStatus "Traffic split of the route"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Route not found or has no split"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /routes/{id}/split [get]
+// @Router /routes/{id}/split [patch]
+func (s *Server) handleRouteSplit(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	id := r.PathValue("id")
+	if r.Method == http.MethodGet {
+		s.mu.RLock()
+		route, _ := s.findRouteLocked(id)
+		if route == nil || route.Split == nil {
+			s.mu.RUnlock()
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("Route %s has no traffic split", id))
+			return
+		}
+		status := s.splitStatus(id, route.Split)
+		s.mu.RUnlock()
+		s.writeSplit(w, status)
+		return
+	}
+
+	var input struct {
+		Pool         *string               `json:"pool"`
+		Weight       *int                  `json:"weight"`
+		StickyHeader *string               `json:"sticky_header"`
+		StickyCookie *string               `json:"sticky_cookie"`
+		Rollout      *models.RolloutConfig `json:"rollout"`
+		State        *string               `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	route, index := s.findRouteLocked(id)
+	if route == nil {
+		s.mu.Unlock()
+		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Route %s not found", id))
+		return
+	}
+	var split models.TrafficSplit
+	if route.Split != nil {
+		split = *route.Split
+	}
+	if input.Pool != nil {
+		split.Pool = *input.Pool
+	}
+	if input.Weight != nil {
+		split.Weight = *input.Weight
+		if split.State == models.SplitRolledBack {
+			split.State = ""
+		}
+	}
+	if input.StickyHeader != nil {
+		split.StickyHeader = *input.StickyHeader
+	}
+	if input.StickyCookie != nil {
+		split.StickyCookie = *input.StickyCookie
+	}
+	if input.Rollout != nil {
+		split.Rollout = input.Rollout
+	}
+	if input.State != nil {
+		split.State = *input.State
+	}
+	updated := *route
+	updated.Split = &split
+	if err := config.ValidateRoute(&updated, s.poolNamesLocked()); err != nil {
+		s.mu.Unlock()
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.replaceSplitLocked(index, &split); err != nil {
+		s.mu.Unlock()
+		log.ErrorKV("Failed to rebuild routing", "error", err)
+		s.sendError(w, http.StatusInternalServerError, "Failed to rebuild routing")
+		return
+	}
+	status := s.splitStatus(id, &split)
+	s.mu.Unlock()
+	if err := s.saveConfig(); err != nil {
+		log.ErrorKV("Failed to save config", "error", err)
+		s.sendError(w, http.StatusInternalServerError, "Failed to save configuration")
+		return
+	}
+
+	log.InfoKV("Traffic split changed", "route", id, "pool", split.Pool, "weight", split.Weight, "state", split.State)
+	s.writeSplit(w, status)
+}
+
+// writeSplit writes the API view of a split.
+func (s *Server) writeSplit(w http.ResponseWriter, status SplitStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.ErrorKV("Failed to encode traffic split", "error", err)
+	}
+}
+
+// canaryFailed reports whether the finished canary response is a failure: a 5xx status, or a
+// 200 whose grpc-status, in the headers or the trailers, is a gRPC server error.
+func canaryFailed(w *statuswriter.Writer) bool {
+	if w.Status() >= http.StatusInternalServerError {
+		return true
+	}
+	header := w.Header()
+	// The proxy sets trailers in the header map, announced ones by name and the others
+	// with http.TrailerPrefix
+	for _, key := range []string{"Grpc-Status", http.TrailerPrefix + "Grpc-Status"} {
+		if values := header[key]; len(values) > 0 {
+			return isGRPCServerError(values[0])
+		}
+	}
+	return false
+}
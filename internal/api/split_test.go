@@ -0,0 +1,374 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"load-balancer/internal/config"
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/statuswriter"
+)
+
+// newSplitServer serves a route that sends split.Weight percent of its requests from the default
+// pool, answering "stable", to the pool "canary", whose backend answers with canaryStatus.
+func newSplitServer(t *testing.T, split *models.TrafficSplit, canaryStatus *int) (*Server, string) {
+	t.Helper()
+	return newSplitServerWith(t, split, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if canaryStatus != nil {
+			status = *canaryStatus
+		}
+		w.WriteHeader(status)
+		w.Write([]byte("canary"))
+	}))
+}
+
+// newSplitServerWith is newSplitServer with the handler of the canary backend.
+func newSplitServerWith(t *testing.T, split *models.TrafficSplit, canaryHandler http.Handler) (*Server, string) {
+	t.Helper()
+	stable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stable"))
+	}))
+	t.Cleanup(stable.Close)
+	canary := httptest.NewServer(canaryHandler)
+	t.Cleanup(canary.Close)
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	cfg := &models.Config{
+		Backends:        []*models.Backend{{URL: stable.URL, Healthy: true}},
+		HealthCheckPath: "/health",
+		RateLimit:       models.RateLimitConfig{Capacity: 10000, Rate: 10000},
+		Pools:           []*models.Pool{{Name: "canary", Backends: []*models.Backend{{URL: canary.URL, Healthy: true}}}},
+		Routes:          []*models.Route{{ID: "web", Match: models.RouteMatch{PathPrefix: "/"}, Pool: models.DefaultPool, Split: split}},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", configPath)
+	t.Cleanup(server.stopRollouts)
+	return server, configPath
+}
+
+// sendSplit proxies a request and returns the answering side with the response.
+func sendSplit(server *Server, header http.Header) (string, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rr := httptest.NewRecorder()
+	server.handleRequest(rr, req)
+	return rr.Body.String(), rr
+}
+
+// patchSplit sends PATCH /api/routes/{id}/split through the admin handler.
+func patchSplit(server *Server, id, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	server.AdminHandler().ServeHTTP(rr, httptest.NewRequest("PATCH", "/api/routes/"+id+"/split", bytes.NewBufferString(body)))
+	return rr
+}
+
+// currentSplit returns the split of the route "web".
+func currentSplit(server *Server) models.TrafficSplit {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+	route, _ := server.findRouteLocked("web")
+	return *route.Split
+}
+
+func TestServer_TrafficSplit(t *testing.T) {
+	logger.Init()
+
+	t.Run("Weight", func(t *testing.T) {
+		server, _ := newSplitServer(t, &models.TrafficSplit{Pool: "canary", Weight: 30}, nil)
+		canary := 0
+		for i := 0; i < 400; i++ {
+			if side, _ := sendSplit(server, nil); side == "canary" {
+				canary++
+			}
+		}
+		if canary < 80 || canary > 160 {
+			t.Errorf("Expected about 30%% of 400 requests on the canary, got %d", canary)
+		}
+	})
+
+	t.Run("Sticky header", func(t *testing.T) {
+		server, _ := newSplitServer(t, &models.TrafficSplit{Pool: "canary", Weight: 50, StickyHeader: "X-User-ID"}, nil)
+		sides := make(map[string]int)
+		for user := 0; user < 40; user++ {
+			header := http.Header{"X-User-Id": {strconv.Itoa(user)}}
+			first, _ := sendSplit(server, header)
+			for i := 0; i < 3; i++ {
+				if side, _ := sendSplit(server, header); side != first {
+					t.Fatalf("User %d moved from %s to %s", user, first, side)
+				}
+			}
+			sides[first]++
+		}
+		if sides["stable"] == 0 || sides["canary"] == 0 {
+			t.Errorf("Expected users on both sides, got %v", sides)
+		}
+	})
+
+	t.Run("Sticky cookie", func(t *testing.T) {
+		server, _ := newSplitServer(t, &models.TrafficSplit{Pool: "canary", Weight: 50, StickyCookie: "lb_canary"}, nil)
+		sides := make(map[string]int)
+		for client := 0; client < 20; client++ {
+			first, rr := sendSplit(server, nil)
+			cookies := rr.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != "lb_canary" || cookies[0].Value == "" {
+				t.Fatalf("Expected the sticky cookie on the first response, got %v", cookies)
+			}
+			header := http.Header{"Cookie": {cookies[0].String()}}
+			for i := 0; i < 3; i++ {
+				side, rr := sendSplit(server, header)
+				if side != first {
+					t.Fatalf("Client %d moved from %s to %s", client, first, side)
+				}
+				if len(rr.Result().Cookies()) != 0 {
+					t.Fatal("Expected no new cookie for an assigned client")
+				}
+			}
+			sides[first]++
+		}
+		if sides["stable"] == 0 || sides["canary"] == 0 {
+			t.Errorf("Expected clients on both sides, got %v", sides)
+		}
+	})
+
+	t.Run("Sticky clients stay in the canary as the weight grows", func(t *testing.T) {
+		server, _ := newSplitServer(t, &models.TrafficSplit{Pool: "canary", Weight: 20, StickyHeader: "X-User-ID"}, nil)
+		var inCanary []http.Header
+		for user := 0; user < 50; user++ {
+			header := http.Header{"X-User-Id": {strconv.Itoa(user)}}
+			if side, _ := sendSplit(server, header); side == "canary" {
+				inCanary = append(inCanary, header)
+			}
+		}
+		if rr := patchSplit(server, "web", `{"weight": 60}`); rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		for _, header := range inCanary {
+			if side, _ := sendSplit(server, header); side != "canary" {
+				t.Errorf("User %s left the canary after the weight grew", header.Get("X-User-ID"))
+			}
+		}
+	})
+}
+
+func TestServer_RouteSplitAPI(t *testing.T) {
+	logger.Init()
+	server, configPath := newSplitServer(t, &models.TrafficSplit{Pool: "canary", Weight: 10}, nil)
+	admin := server.AdminHandler()
+
+	t.Run("GET split", func(t *testing.T) {
+		sendSplit(server, nil)
+		rr := httptest.NewRecorder()
+		admin.ServeHTTP(rr, httptest.NewRequest("GET", "/api/routes/web/split", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var status SplitStatus
+		if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if status.Route != "web" || status.Pool != "canary" || status.Weight != 10 || status.NextStepAt != nil {
+			t.Errorf("Unexpected split status %+v", status)
+		}
+	})
+
+	t.Run("PATCH weight", func(t *testing.T) {
+		rr := patchSplit(server, "web", `{"weight": 100}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		for i := 0; i < 10; i++ {
+			if side, _ := sendSplit(server, nil); side != "canary" {
+				t.Fatalf("Expected every request on the canary at weight 100, got %s", side)
+			}
+		}
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to load saved config: %v", err)
+		}
+		if split := cfg.Routes[0].Split; split == nil || split.Pool != "canary" || split.Weight != 100 {
+			t.Errorf("Expected the new weight in the saved config, got %+v", split)
+		}
+	})
+
+	t.Run("PATCH invalid", func(t *testing.T) {
+		tests := []struct {
+			id, body string
+			want     int
+		}{
+			{"web", `{"weight": 150}`, http.StatusBadRequest},
+			{"web", `{"pool": "missing"}`, http.StatusBadRequest},
+			{"web", `{"pool": "default"}`, http.StatusBadRequest},
+			{"web", `{"state": "finished"}`, http.StatusBadRequest},
+			{"web", `{"rollout": {"steps": [50, 25], "interval": "1m"}}`, http.StatusBadRequest},
+			{"web", `{"rollout": {"steps": [25, 50]}}`, http.StatusBadRequest},
+			{"web", `{"sticky_cookie": "bad cookie"}`, http.StatusBadRequest},
+			{"web", `not json`, http.StatusBadRequest},
+			{"missing", `{"weight": 5}`, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if rr := patchSplit(server, tt.id, tt.body); rr.Code != tt.want {
+				t.Errorf("PATCH %s %s: expected status %d, got %d", tt.id, tt.body, tt.want, rr.Code)
+			}
+		}
+		if split := currentSplit(server); split.Weight != 100 {
+			t.Errorf("Expected rejected changes to keep the split, got %+v", split)
+		}
+	})
+
+	t.Run("Canary pool cannot be deleted", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.handlePools(rr, httptest.NewRequest("DELETE", "/api/pools?name=canary", nil))
+		if rr.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", rr.Code)
+		}
+	})
+}
+
+func TestServer_SplitRollout(t *testing.T) {
+	logger.Init()
+
+	t.Run("Steps on schedule", func(t *testing.T) {
+		split := &models.TrafficSplit{Pool: "canary", Weight: 0, Rollout: &models.RolloutConfig{Steps: []int{10, 50, 100}, Interval: models.Duration(50 * time.Millisecond)}}
+		server, configPath := newSplitServer(t, split, nil)
+		deadline := time.Now().Add(2 * time.Second)
+		for currentSplit(server).Weight != 100 {
+			if time.Now().After(deadline) {
+				t.Fatalf("Rollout did not reach 100, weight %d", currentSplit(server).Weight)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to load saved config: %v", err)
+		}
+		if cfg.Routes[0].Split.Weight != 100 {
+			t.Errorf("Expected the final weight in the saved config, got %d", cfg.Routes[0].Split.Weight)
+		}
+	})
+
+	t.Run("Paused", func(t *testing.T) {
+		split := &models.TrafficSplit{Pool: "canary", Weight: 10, State: models.SplitPaused, Rollout: &models.RolloutConfig{Steps: []int{10, 50}, Interval: models.Duration(20 * time.Millisecond)}}
+		server, _ := newSplitServer(t, split, nil)
+		time.Sleep(100 * time.Millisecond)
+		if weight := currentSplit(server).Weight; weight != 10 {
+			t.Fatalf("Expected a paused rollout to stay at 10, got %d", weight)
+		}
+		if rr := patchSplit(server, "web", `{"state": "active"}`); rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		deadline := time.Now().Add(time.Second)
+		for currentSplit(server).Weight != 50 {
+			if time.Now().After(deadline) {
+				t.Fatal("Resumed rollout did not move on")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("Rollback on errors", func(t *testing.T) {
+		failing := http.StatusInternalServerError
+		split := &models.TrafficSplit{Pool: "canary", Weight: 50, Rollout: &models.RolloutConfig{
+			Steps: []int{50, 100}, Interval: models.Duration(time.Hour), MaxErrorRate: 0.2, MinRequests: 5,
+		}}
+		server, configPath := newSplitServer(t, split, &failing)
+		for i := 0; i < 100 && currentSplit(server).State != models.SplitRolledBack; i++ {
+			sendSplit(server, nil)
+		}
+		if got := currentSplit(server); got.State != models.SplitRolledBack || got.Weight != 0 {
+			t.Fatalf("Expected the split to be rolled back, got %+v", got)
+		}
+		for i := 0; i < 10; i++ {
+			if side, _ := sendSplit(server, nil); side != "stable" {
+				t.Fatalf("Expected every request on stable after rollback, got %s", side)
+			}
+		}
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to load saved config: %v", err)
+		}
+		if cfg.Routes[0].Split.State != models.SplitRolledBack {
+			t.Errorf("Expected the rollback in the saved config, got %+v", cfg.Routes[0].Split)
+		}
+
+		// A new weight after the fix makes the split active again
+		failing = http.StatusOK
+		if rr := patchSplit(server, "web", `{"weight": 5}`); rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if got := currentSplit(server); got.State != "" || got.Weight != 5 {
+			t.Errorf("Expected an active split at 5, got %+v", got)
+		}
+	})
+
+	t.Run("Rollback on gRPC errors", func(t *testing.T) {
+		split := &models.TrafficSplit{Pool: "canary", Weight: 50, Rollout: &models.RolloutConfig{
+			Steps: []int{50, 100}, Interval: models.Duration(time.Hour), MaxErrorRate: 0.2, MinRequests: 5,
+		}}
+		// A 200 with UNAVAILABLE in the trailers, as a gRPC server answers a failed call
+		server, _ := newSplitServerWith(t, split, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Trailer", "Grpc-Status")
+			w.Write([]byte("canary"))
+			w.Header().Set("Grpc-Status", "14")
+		}))
+		for i := 0; i < 100 && currentSplit(server).State != models.SplitRolledBack; i++ {
+			sendSplit(server, nil)
+		}
+		if got := currentSplit(server); got.State != models.SplitRolledBack {
+			t.Fatalf("Expected the split to be rolled back, got %+v", got)
+		}
+	})
+
+	t.Run("Successful canary is kept", func(t *testing.T) {
+		split := &models.TrafficSplit{Pool: "canary", Weight: 100, Rollout: &models.RolloutConfig{MaxErrorRate: 0.1, MinRequests: 5}}
+		server, _ := newSplitServer(t, split, nil)
+		for i := 0; i < 20; i++ {
+			sendSplit(server, nil)
+		}
+		if got := currentSplit(server); got.State != "" || got.Weight != 100 {
+			t.Errorf("Expected the healthy canary to keep its weight, got %+v", got)
+		}
+	})
+}
+
+func TestCanaryFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   bool
+	}{
+		{"OK", http.StatusOK, nil, false},
+		{"Client error", http.StatusNotFound, nil, false},
+		{"Server error", http.StatusBadGateway, nil, true},
+		{"gRPC OK", http.StatusOK, http.Header{"Grpc-Status": {"0"}}, false},
+		{"gRPC client error", http.StatusOK, http.Header{"Grpc-Status": {"5"}}, false},
+		{"gRPC error in headers", http.StatusOK, http.Header{"Grpc-Status": {"14"}}, true},
+		{"gRPC error in unannounced trailers", http.StatusOK, http.Header{http.TrailerPrefix + "Grpc-Status": {"13"}}, true},
+		{"Invalid gRPC status", http.StatusOK, http.Header{"Grpc-Status": {"x"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			for name, values := range tt.header {
+				rr.Header()[name] = values
+			}
+			sw := statuswriter.New(rr)
+			sw.WriteHeader(tt.status)
+			if got := canaryFailed(sw); got != tt.want {
+				t.Errorf("Expected failed=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
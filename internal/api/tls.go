@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/tlsconfig"
+
+	"golang.org/x/net/netutil"
+)
+
+// StartTLS launches the HTTPS listener on the specified port.
+func (s *Server) StartTLS(port string) error {
+	ln, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	logger.InfoKV("Starting TLS server", "port", port)
+	return s.ServeTLS(ln)
+}
+
+// ServeTLS terminates TLS on the listener with the certificates of cfg.TLS and serves
+// the public handler until the server is shut down. Certificates changed on disk are
+// picked up without a restart, see tlsconfig.Store.
+func (s *Server) ServeTLS(ln net.Listener) error {
+	store, err := tlsconfig.NewStore(s.cfg.TLS)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	tlsCfg, err := tlsconfig.Server(s.cfg.TLS, store)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	limits := s.cfg.Server.WithDefaults()
+	s.mu.Lock()
+	s.tlsServer = s.newHTTPServer(s.Handler(), limits)
+	s.tlsServer.TLSConfig = tlsCfg
+	srv := s.tlsServer
+	s.mu.Unlock()
+	return srv.ServeTLS(netutil.LimitListener(ln, limits.MaxConnections), "", "")
+}
+
+// RedirectHandler answers every request on the plain port with 308 to the same URL
+// on the HTTPS listener. 308 keeps the method and body of the request. /livez is answered
+// directly, so the container healthcheck needs no certificate.
+func (s *Server) RedirectHandler() http.Handler {
+	port := strings.TrimPrefix(s.cfg.TLS.Port, ":")
+	if i := strings.LastIndex(port, ":"); i >= 0 {
+		port = port[i+1:]
+	}
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/", redirect)
+	mux.HandleFunc("GET /livez", s.handleLivez)
+	return requestid.Middleware(s.accessLog.Middleware(mux))
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig/tlstest"
+)
+
+// writeSelfSigned generates a self-signed server certificate for dnsName and adds it to roots.
+func writeSelfSigned(t *testing.T, dir, dnsName string, roots *x509.CertPool) models.CertificateConfig {
+	t.Helper()
+	cert := tlstest.NewCert(t, dir, dnsName)
+	roots.AddCert(cert.Leaf)
+	return cert.Config()
+}
+
+func TestServer_TLS(t *testing.T) {
+	logger.Init()
+	var proto string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Header.Get("X-Forwarded-Proto")
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	dir := t.TempDir()
+	roots := x509.NewCertPool()
+	cfg := &models.Config{
+		Port:                "8087",
+		Backends:            []*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 100, Rate: 100},
+		TLS: models.TLSConfig{
+			Enabled: true,
+			Port:    "8443",
+			Certificates: []models.CertificateConfig{
+				writeSelfSigned(t, dir, "shop.example.com", roots),
+				writeSelfSigned(t, dir, "api.example.com", roots),
+			},
+			MinVersion:   models.TLSVersion12,
+			RedirectHTTP: true,
+		},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(dir, "config.json"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.ServeTLS(ln)
+	defer func() {
+		server.mu.RLock()
+		srv := server.tlsServer
+		server.mu.RUnlock()
+		if srv != nil {
+			srv.Close()
+		}
+	}()
+
+	for _, name := range []string{"shop.example.com", "api.example.com"} {
+		t.Run("SNI "+name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: name},
+			}}
+			resp, err := client.Get("https://" + ln.Addr().String() + "/")
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != "OK" {
+				t.Fatalf("Expected 200 OK, got %d %q", resp.StatusCode, body)
+			}
+			if got := resp.TLS.PeerCertificates[0].Subject.CommonName; got != name {
+				t.Errorf("Expected certificate for %s, got %s", name, got)
+			}
+			if proto != "https" {
+				t.Errorf("Expected backend to see X-Forwarded-Proto https, got %q", proto)
+			}
+		})
+	}
+
+	t.Run("HTTP/2 is negotiated by ALPN", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "shop.example.com"},
+			ForceAttemptHTTP2: true,
+		}}
+		resp, err := client.Get("https://" + ln.Addr().String() + "/")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 2 || resp.TLS.NegotiatedProtocol != "h2" {
+			t.Errorf("Expected HTTP/2 over h2, got %s over %q", resp.Proto, resp.TLS.NegotiatedProtocol)
+		}
+	})
+
+	t.Run("Minimum version is enforced", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "shop.example.com", MaxVersion: tls.VersionTLS11})
+		if err == nil {
+			conn.Close()
+			t.Fatal("Expected handshake with TLS 1.1 to fail")
+		}
+	})
+
+	t.Run("Plain port answers liveness without redirect", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.RedirectHandler().ServeHTTP(rr, httptest.NewRequest("GET", "http://localhost:8087/livez", nil))
+		if rr.Code == http.StatusPermanentRedirect || !strings.Contains(rr.Body.String(), `"process"`) {
+			t.Errorf("Expected a liveness response, got %d %q", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Plain port redirects to HTTPS", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.RedirectHandler().ServeHTTP(rr, httptest.NewRequest("POST", "http://shop.example.com:8087/cart?item=1", nil))
+		if rr.Code != http.StatusPermanentRedirect {
+			t.Fatalf("Expected status 308, got %d", rr.Code)
+		}
+		if got := rr.Header().Get("Location"); got != "https://shop.example.com:8443/cart?item=1" {
+			t.Errorf("Unexpected redirect location %q", got)
+		}
+	})
+}
+
+func TestServer_ClientCertAuth(t *testing.T) {
+	logger.Init()
+	var subject string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = r.Header.Get("X-Client-Subject")
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	dir := t.TempDir()
+	roots := x509.NewCertPool()
+	partner := tlstest.NewCert(t, dir, "partner.example.com")
+	cfg := &models.Config{
+		Port:                "8087",
+		Backends:            []*models.Backend{{URL: backendServer.URL, Healthy: true}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 100, Rate: 100},
+		ClientConfigs:       []models.ClientConfig{{ClientID: "partner.example.com", Capacity: 1, Rate: 0.001}},
+		Routes:              []*models.Route{{ID: "partners", Match: models.RouteMatch{PathPrefix: "/partners"}, Pool: models.DefaultPool, RequireClientCert: true}},
+		TLS: models.TLSConfig{
+			Enabled:      true,
+			Port:         "8443",
+			Certificates: []models.CertificateConfig{writeSelfSigned(t, dir, "shop.example.com", roots)},
+			ClientAuth: models.ClientAuthConfig{
+				Mode:     models.ClientAuthRequest,
+				CAFile:   partner.CertFile,
+				Identity: models.ClientIdentitySubject,
+				Headers:  models.ClientCertHeaders{Subject: "X-Client-Subject"},
+			},
+		},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(dir, "config.json"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.ServeTLS(ln)
+	defer func() {
+		server.mu.RLock()
+		srv := server.tlsServer
+		server.mu.RUnlock()
+		if srv != nil {
+			srv.Close()
+		}
+	}()
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "shop.example.com", Certificates: certs},
+		}}
+	}
+	anonymous, withCert := newClient(), newClient(partner.TLS)
+
+	tests := []struct {
+		name        string
+		client      *http.Client
+		path        string
+		wantStatus  int
+		wantSubject string
+	}{
+		{"Route requires a certificate", anonymous, "/partners/orders", http.StatusForbidden, ""},
+		{"Other routes accept clients without a certificate", anonymous, "/", http.StatusOK, ""},
+		{"Verified certificate is forwarded", withCert, "/partners/orders", http.StatusOK, "CN=partner.example.com"},
+		{"Rate limit applies to the certificate subject", withCert, "/partners/orders", http.StatusTooManyRequests, ""},
+		{"Other clients keep their own limit", anonymous, "/", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject = "unset"
+			req, _ := http.NewRequest("GET", "https://"+ln.Addr().String()+tt.path, nil)
+			req.Header.Set("X-Client-Subject", "CN=forged")
+			resp, err := tt.client.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus == http.StatusOK && subject != tt.wantSubject {
+				t.Errorf("Expected backend to see X-Client-Subject %q, got %q", tt.wantSubject, subject)
+			}
+		})
+	}
+}
+
+func TestServer_ClientCertCheckedBeforePick(t *testing.T) {
+	logger.Init()
+	cfg := &models.Config{
+		Port: "8087",
+		Backends: []*models.Backend{
+			{URL: "http://localhost:8001", Healthy: true},
+			{URL: "http://localhost:8002", Healthy: true},
+		},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 100, Rate: 100},
+		Routes: []*models.Route{{
+			ID: "partners", Match: models.RouteMatch{PathPrefix: "/partners"}, Pool: models.DefaultPool, RequireClientCert: true,
+			Split: &models.TrafficSplit{Pool: models.DefaultPool, Weight: 50, StickyCookie: "lb_canary"},
+		}},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	t.Cleanup(server.stopRollouts)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/partners/orders", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		rr := httptest.NewRecorder()
+		server.handleRequest(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Fatalf("Expected status 403, got %d", rr.Code)
+		}
+		if cookie := rr.Header().Get("Set-Cookie"); cookie != "" {
+			t.Errorf("Expected no sticky cookie on a rejected request, got %q", cookie)
+		}
+	}
+	// The rejected requests did not advance the round robin
+	if b := server.balancer.NextBackend(); b == nil || b.URL != "http://localhost:8001" {
+		t.Errorf("Expected the first backend to be next, got %v", b)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// listen opens the listener for a port or a unix:///path listen address. A socket file left
+// behind by a previous run is removed unless a server still accepts connections on it, and
+// a non-empty mode sets the permissions of the new socket file before clients can reach it.
+func listen(addr, mode string) (net.Listener, error) {
+	socket, ok := models.UnixSocket(addr)
+	if !ok {
+		return net.Listen("tcp", ":"+addr)
+	}
+	perm, err := models.ParseSocketMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(socket); err != nil {
+		return nil, err
+	}
+	if perm == 0 {
+		return net.Listen("unix", socket)
+	}
+	return listenUnixMode(socket, perm)
+}
+
+// listenUnixMode creates the socket in a private directory next to path, sets its mode and
+// only then renames it into place. A chmod after listening on path itself would leave a
+// window in which any client allowed by the umask can connect.
+func listenUnixMode(path string, perm os.FileMode) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".socket-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "s")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// The listener would unlink the temporary name, the socket file is removed by unixListener
+	ln.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, perm); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket mode: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return &unixListener{UnixListener: ln, path: path}, nil
+}
+
+// unixListener is a listener whose socket file was renamed to path. It reports path as its
+// address and removes the file on Close, like a listener created on path directly.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	if rerr := os.Remove(l.path); rerr != nil && !os.IsNotExist(rerr) && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// removeStaleSocket removes the socket file at path if nothing listens on it.
+// Other files are left alone, so a wrong path cannot delete data.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+// unixClient returns an HTTP client that sends every request to the Unix socket.
+func unixClient(socket string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
+// waitForSocket waits until the socket file exists.
+func waitForSocket(t *testing.T, socket string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", socket)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_UnixSockets(t *testing.T) {
+	logger.Init()
+	dir := t.TempDir()
+
+	backendSocket := filepath.Join(dir, "backend.sock")
+	backendLn, err := net.Listen("unix", backendSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("unix backend"))
+	}))
+	backendServer.Listener = backendLn
+	backendServer.Start()
+	defer backendServer.Close()
+
+	server := NewServer(
+		[]*models.Backend{{URL: "unix://" + backendSocket, Healthy: true}},
+		health.NewHealthChecker(),
+		10, 1,
+		nil, "", filepath.Join(dir, "config.json"),
+	)
+	server.cfg.Server.SocketMode = "0660"
+	server.cfg.AdminSocketMode = "0600"
+
+	// A socket left behind by a previous run is replaced
+	publicSocket := filepath.Join(dir, "lb.sock")
+	stale, err := net.Listen("unix", publicSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	adminSocket := filepath.Join(dir, "admin.sock")
+	go server.Start("unix://" + publicSocket)
+	go server.StartAdmin("unix://" + adminSocket)
+	waitForSocket(t, adminSocket)
+
+	t.Run("Public requests reach the unix backend", func(t *testing.T) {
+		var resp *http.Response
+		deadline := time.Now().Add(time.Second)
+		for {
+			if resp, err = unixClient(publicSocket).Get("http://lb/"); err == nil || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "unix backend" {
+			t.Errorf("Expected 200 from the backend, got %d %q", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("Admin API", func(t *testing.T) {
+		resp, err := unixClient(adminSocket).Get("http://admin/api/backends")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Socket permissions", func(t *testing.T) {
+		for socket, want := range map[string]os.FileMode{publicSocket: 0o660, adminSocket: 0o600} {
+			info, err := os.Stat(socket)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != want {
+				t.Errorf("Expected %s to have mode %o, got %o", socket, want, got)
+			}
+		}
+	})
+
+	t.Run("Socket in use", func(t *testing.T) {
+		if err := server.StartAdmin("unix://" + adminSocket); err == nil {
+			t.Error("Expected an error for a socket another server listens on")
+		}
+	})
+
+	t.Run("Path of a regular file", func(t *testing.T) {
+		file := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(file, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := server.StartAdmin("unix://" + file); err == nil {
+			t.Error("Expected an error for a path that is not a socket")
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("Expected the file to be kept, got %v", err)
+		}
+	})
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	for _, socket := range []string{publicSocket, adminSocket} {
+		if _, err := os.Stat(socket); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed on shutdown, got %v", socket, err)
+		}
+	}
+}
+
+func TestListen_SocketMode(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "lb.sock")
+
+	ln, err := listen("unix://"+socket, "0600")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0o600 {
+		t.Errorf("Expected mode 600, got %o", got)
+	}
+	if got := ln.Addr().String(); got != socket {
+		t.Errorf("Expected address %s, got %s", socket, got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the socket in %s, got %d entries", dir, len(entries))
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn.Close()
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket to be removed on Close, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/health"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/testutil"
+
+	"golang.org/x/net/websocket"
+)
+
+// newEchoBackend starts an in-process WebSocket backend that echoes every message back.
+func newEchoBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			if err := websocket.Message.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+// wsEcho sends msg over ws and reports whether the same message came back.
+func wsEcho(ws *websocket.Conn, msg string) bool {
+	ws.SetDeadline(time.Now().Add(2 * time.Second))
+	if err := websocket.Message.Send(ws, msg); err != nil {
+		return false
+	}
+	var reply string
+	return websocket.Message.Receive(ws, &reply) == nil && reply == msg
+}
+
+func TestServer_WebSocket(t *testing.T) {
+	logger.Init()
+	backends := []*models.Backend{
+		{URL: newEchoBackend(t).URL, Healthy: true},
+		{URL: newEchoBackend(t).URL, Healthy: true},
+	}
+	cfg := &models.Config{
+		Port:                ":8087",
+		Backends:            backends,
+		Strategy:            models.StrategyLeastConnections,
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 5 * time.Second,
+		RateLimit:           models.RateLimitConfig{Capacity: 2, Rate: 0.001},
+		Shutdown:            models.ShutdownConfig{DrainTimeout: models.Duration(5 * time.Second)},
+	}
+	server := NewServerFromConfig(cfg, health.NewHealthChecker(), "", filepath.Join(t.TempDir(), "config.json"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(ln)
+	url := "ws://" + ln.Addr().String() + "/live"
+
+	upgraded := func() []int {
+		counts := make([]int, len(backends))
+		for i, b := range backends {
+			counts[i] = server.backendStatus(b).Upgraded
+		}
+		return counts
+	}
+
+	// Least connections counts open WebSockets, so the two connections land on different backends
+	var conns []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		ws, err := websocket.Dial(url, "", "http://localhost/")
+		if err != nil {
+			t.Fatalf("Failed to open WebSocket %d: %v", i, err)
+		}
+		defer ws.Close()
+		if !wsEcho(ws, "hello") {
+			t.Fatalf("WebSocket %d: echo failed", i)
+		}
+		conns = append(conns, ws)
+	}
+	if counts := upgraded(); counts[0] != 1 || counts[1] != 1 {
+		t.Fatalf("Expected one WebSocket per backend, got %v", counts)
+	}
+	if status := server.backendStatus(backends[0]); status.InFlight != 1 {
+		t.Errorf("Expected the WebSocket to count as in flight, got %d", status.InFlight)
+	}
+
+	t.Run("Rate limit applies to the upgrade", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://"+ln.Addr().String()+"/live", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("Expected status 429, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Draining a backend closes its WebSockets", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.handleBackends(rr, httptest.NewRequest("PATCH", "/api/backends", bytes.NewBufferString(`{"id": "backend1", "state": "draining"}`)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		drained := 0
+		for _, ws := range conns {
+			if !wsEcho(ws, "after drain") {
+				drained++
+			}
+		}
+		if drained != 1 {
+			t.Errorf("Expected exactly the WebSocket of backend1 to be closed, %d were", drained)
+		}
+		if !testutil.WaitFor(func() bool { return backends[0].InFlight() == 0 && upgraded()[0] == 0 }) {
+			t.Errorf("Expected the drained backend to have no connections, got %d", backends[0].InFlight())
+		}
+	})
+
+	t.Run("Shutdown closes the remaining WebSockets", func(t *testing.T) {
+		start := time.Now()
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Shutdown took %v, expected upgraded connections to be closed at once", elapsed)
+		}
+		for i, ws := range conns {
+			if wsEcho(ws, "after shutdown") {
+				t.Errorf("WebSocket %d: expected to be closed", i)
+			}
+		}
+	})
+}
@@ -11,6 +11,21 @@ type BalancerInterface interface {
 	NextBackend() *models.Backend
 }
 
+// KeyedBalancer выбирает бэкенд по ключу клиента, например по его IP.
+type KeyedBalancer interface {
+	BalancerInterface
+	NextBackendFor(key string) *models.Backend
+}
+
+// Pick выбирает бэкенд для клиента с ключом key: по ключу, если балансировщик это умеет,
+// иначе по его обычному алгоритму.
+func Pick(b BalancerInterface, key string) *models.Backend {
+	if kb, ok := b.(KeyedBalancer); ok {
+		return kb.NextBackendFor(key)
+	}
+	return b.NextBackend()
+}
+
 // Balancer управляет списком бэкендов и выбирает следующий доступный.
 type Balancer struct {
 	backends []*models.Backend
@@ -18,6 +33,20 @@ type Balancer struct {
 	mu       sync.Mutex
 }
 
+// New создает балансировщик для указанной стратегии; пустая стратегия означает round-robin.
+func New(strategy string, backends []*models.Backend, slowStart models.SlowStartConfig) BalancerInterface {
+	switch strategy {
+	case models.StrategyWeightedRoundRobin:
+		return NewWeightedBalancer(backends, slowStart)
+	case models.StrategyLeastConnections:
+		return NewLeastConnBalancer(backends, slowStart)
+	case models.StrategySourceIPHash:
+		return NewHashBalancer(backends)
+	default:
+		return NewBalancer(backends)
+	}
+}
+
 // NewBalancer создает новый экземпляр балансировщика.
 func NewBalancer(backends []*models.Backend) *Balancer {
 	return &Balancer{
@@ -27,6 +56,7 @@ func NewBalancer(backends []*models.Backend) *Balancer {
 }
 
 // NextBackend возвращает следующий доступный бэкенд по алгоритму round-robin.
+// Бэкенды в состоянии draining или maintenance пропускаются.
 func (b *Balancer) NextBackend() *models.Backend {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -34,7 +64,7 @@ func (b *Balancer) NextBackend() *models.Backend {
 	for i := 0; i < len(b.backends); i++ {
 		b.current = (b.current + 1) % len(b.backends)
 		backend := b.backends[b.current]
-		if backend.Healthy {
+		if backend.Available() {
 			return backend
 		}
 	}
@@ -1,9 +1,12 @@
 package balancer
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"load-balancer/internal/logger"
 	"load-balancer/internal/models"
@@ -84,3 +87,173 @@ func TestBalancer_ConcurrentAccess(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestBalancer_SkipsUnavailableStates(t *testing.T) {
+	backends := []*models.Backend{
+		{URL: "http://localhost:8001", Healthy: true},
+		{URL: "http://localhost:8002", Healthy: true},
+		{URL: "http://localhost:8003", Healthy: true},
+		{URL: "http://localhost:8004", Healthy: true},
+	}
+	backends[0].SetState(models.BackendDraining)
+	backends[1].SetState(models.BackendMaintenance)
+	backends[2].SetState(models.BackendActive)
+	balancer := NewBalancer(backends)
+
+	for i, expectedURL := range []string{"http://localhost:8003", "http://localhost:8004", "http://localhost:8003"} {
+		backend := balancer.NextBackend()
+		if backend == nil || backend.URL != expectedURL {
+			t.Errorf("Call %d: Expected backend %v, got %v", i+1, expectedURL, backend)
+		}
+	}
+}
+
+func TestWeightedBalancer_Distribution(t *testing.T) {
+	backends := []*models.Backend{
+		{URL: "http://localhost:8001", Healthy: true, Weight: 3},
+		{URL: "http://localhost:8002", Healthy: true, Weight: 1},
+		{URL: "http://localhost:8003", Healthy: false, Weight: 5},
+	}
+	balancer := New(models.StrategyWeightedRoundRobin, backends, models.SlowStartConfig{})
+
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		backend := balancer.NextBackend()
+		if backend == nil {
+			t.Fatal("Expected non-nil backend")
+		}
+		counts[backend.URL]++
+	}
+	if counts["http://localhost:8001"] != 300 || counts["http://localhost:8002"] != 100 || counts["http://localhost:8003"] != 0 {
+		t.Errorf("Expected 300/100/0 distribution, got %v", counts)
+	}
+}
+
+func TestLeastConnBalancer_NextBackend(t *testing.T) {
+	backends := []*models.Backend{
+		{URL: "http://localhost:8001", Healthy: true},
+		{URL: "http://localhost:8002", Healthy: true},
+		{URL: "http://localhost:8003", Healthy: true, Weight: 2},
+	}
+	balancer := New(models.StrategyLeastConnections, backends, models.SlowStartConfig{})
+
+	backends[0].Acquire()
+	backends[0].Acquire()
+	backends[1].Acquire()
+	backends[2].Acquire()
+	backends[2].Acquire()
+
+	// Scores: 3/1, 2/1, 3/2
+	if backend := balancer.NextBackend(); backend != backends[2] {
+		t.Errorf("Expected backend 3, got %v", backend)
+	}
+	backends[2].Acquire()
+	backends[2].Acquire()
+	// Scores: 3/1, 2/1, 5/2
+	if backend := balancer.NextBackend(); backend != backends[1] {
+		t.Errorf("Expected backend 2, got %v", backend)
+	}
+
+	backends[1].Healthy = false
+	if backend := balancer.NextBackend(); backend != backends[2] {
+		t.Errorf("Expected backend 3 when backend 2 is unhealthy, got %v", backend)
+	}
+}
+
+func TestEffectiveWeight_SlowStart(t *testing.T) {
+	now := time.Now()
+	cfg := models.SlowStartConfig{Window: models.Duration(100 * time.Second)}
+
+	tests := []struct {
+		name     string
+		backend  *models.Backend
+		cfg      models.SlowStartConfig
+		expected float64
+	}{
+		{"Disabled", &models.Backend{Weight: 4, HealthySince: now}, models.SlowStartConfig{}, 4},
+		{"Never recovered", &models.Backend{Weight: 4}, cfg, 4},
+		{"Minimum at start", &models.Backend{Weight: 4, HealthySince: now}, cfg, 0.4},
+		{"Linear halfway", &models.Backend{Weight: 4, HealthySince: now.Add(-50 * time.Second)}, cfg, 2},
+		{"Window elapsed", &models.Backend{Weight: 4, HealthySince: now.Add(-200 * time.Second)}, cfg, 4},
+		{"Default weight", &models.Backend{HealthySince: now.Add(-25 * time.Second)}, cfg, 0.25},
+		{
+			"Aggressive curve",
+			&models.Backend{Weight: 1, HealthySince: now.Add(-25 * time.Second)},
+			models.SlowStartConfig{Window: cfg.Window, Aggression: 2, MinWeightPercent: 5},
+			0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weight := EffectiveWeight(tt.backend, tt.cfg, now)
+			if math.Abs(weight-tt.expected) > 1e-9 {
+				t.Errorf("Expected weight %v, got %v", tt.expected, weight)
+			}
+		})
+	}
+}
+
+func TestWeightedBalancer_SlowStart(t *testing.T) {
+	backends := []*models.Backend{
+		{URL: "http://localhost:8001", Healthy: true},
+		{URL: "http://localhost:8002", Healthy: true, HealthySince: time.Now()},
+	}
+	slowStart := models.SlowStartConfig{Window: models.Duration(time.Hour), MinWeightPercent: 10}
+	balancer := New(models.StrategyWeightedRoundRobin, backends, slowStart)
+
+	counts := make(map[string]int)
+	for i := 0; i < 110; i++ {
+		counts[balancer.NextBackend().URL]++
+	}
+	if counts["http://localhost:8002"] != 10 {
+		t.Errorf("Expected recovering backend to get 10 of 110 requests, got %v", counts)
+	}
+}
+
+func TestHashBalancer_NextBackendFor(t *testing.T) {
+	backends := []*models.Backend{
+		{URL: "tcp://db1:5432", Healthy: true},
+		{URL: "tcp://db2:5432", Healthy: true},
+		{URL: "tcp://db3:5432", Healthy: true, Weight: 2},
+	}
+	b := New(models.StrategySourceIPHash, backends, models.SlowStartConfig{})
+
+	assigned := make(map[string]*models.Backend)
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/250, i%250)
+		backend := Pick(b, ip)
+		if backend == nil {
+			t.Fatal("Expected a backend")
+		}
+		if again := Pick(b, ip); again != backend {
+			t.Fatalf("Client %s moved from %s to %s", ip, backend.URL, again.URL)
+		}
+		assigned[ip] = backend
+		counts[backend.URL]++
+	}
+	// Shares follow the weights 1:1:2 within a few percent
+	for url, want := range map[string]float64{"tcp://db1:5432": 0.25, "tcp://db2:5432": 0.25, "tcp://db3:5432": 0.5} {
+		if got := float64(counts[url]) / 4000; math.Abs(got-want) > 0.04 {
+			t.Errorf("Expected %s to get %.2f of clients, got %.2f", url, want, got)
+		}
+	}
+
+	// Only the clients of an unavailable backend move, the others keep theirs
+	backends[0].Healthy = false
+	for ip, before := range assigned {
+		after := Pick(b, ip)
+		if before != backends[0] && after != before {
+			t.Fatalf("Client %s moved from %s to %s although its backend is healthy", ip, before.URL, after.URL)
+		}
+		if after == backends[0] {
+			t.Fatalf("Client %s stayed on the unhealthy backend", ip)
+		}
+	}
+
+	// Without a key the balancer falls back to round-robin
+	if first, second := b.NextBackend(), b.NextBackend(); first == second {
+		t.Errorf("Expected round-robin without a key, got %s twice", first.URL)
+	}
+}
@@ -0,0 +1,63 @@
+package balancer
+
+import (
+	"hash/fnv"
+	"math"
+
+	"load-balancer/internal/models"
+)
+
+// HashBalancer закрепляет клиентов за бэкендами по хешу ключа (IP клиента) методом
+// rendezvous hashing: при выходе бэкенда из работы на другие переходят только его клиенты.
+// Вес бэкенда увеличивает его долю ключей; slow start не учитывается, чтобы привязка не менялась.
+type HashBalancer struct {
+	backends []*models.Backend
+	fallback *Balancer // Для запросов без ключа
+}
+
+// NewHashBalancer создает балансировщик source-IP hash.
+func NewHashBalancer(backends []*models.Backend) *HashBalancer {
+	return &HashBalancer{backends: backends, fallback: NewBalancer(backends)}
+}
+
+// NextBackend возвращает бэкенд по round-robin: без ключа привязывать клиента не к чему.
+func (b *HashBalancer) NextBackend() *models.Backend {
+	return b.fallback.NextBackend()
+}
+
+// NextBackendFor возвращает доступный бэкенд с наибольшим весом пары ключ–бэкенд.
+func (b *HashBalancer) NextBackendFor(key string) *models.Backend {
+	if key == "" {
+		return b.NextBackend()
+	}
+	var best *models.Backend
+	bestScore := 0.0
+	for _, backend := range b.backends {
+		if !backend.Available() {
+			continue
+		}
+		if score := rendezvousScore(key, backend); best == nil || score > bestScore {
+			best, bestScore = backend, score
+		}
+	}
+	return best
+}
+
+// rendezvousScore — взвешенный счет HRW: -weight/ln(h), где h — хеш пары в интервале (0, 1).
+func rendezvousScore(key string, backend *models.Backend) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(backend.URL))
+	// fnv плохо перемешивает близкие строки, поэтому результат дополнительно перемешивается
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	u := (float64(x>>11) + 0.5) / (1 << 53)
+	weight := float64(backend.Weight)
+	if weight <= 0 {
+		weight = 1
+	}
+	return -weight / math.Log(u)
+}
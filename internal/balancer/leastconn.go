@@ -0,0 +1,51 @@
+package balancer
+
+import (
+	"sync"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// LeastConnBalancer выбирает бэкенд с наименьшим числом активных запросов относительно его веса.
+type LeastConnBalancer struct {
+	backends  []*models.Backend
+	slowStart models.SlowStartConfig
+	offset    int
+	mu        sync.Mutex
+}
+
+// NewLeastConnBalancer создает балансировщик least-connections.
+func NewLeastConnBalancer(backends []*models.Backend, slowStart models.SlowStartConfig) *LeastConnBalancer {
+	return &LeastConnBalancer{
+		backends:  backends,
+		slowStart: slowStart,
+	}
+}
+
+// NextBackend возвращает доступный бэкенд с минимальным (InFlight+1)/вес.
+// Поиск начинается со смещения, которое сдвигается при каждом вызове, чтобы равные бэкенды чередовались.
+func (b *LeastConnBalancer) NextBackend() *models.Backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(b.backends)
+	if n == 0 {
+		return nil
+	}
+	now := time.Now()
+	var best *models.Backend
+	bestScore := 0.0
+	for i := 0; i < n; i++ {
+		backend := b.backends[(b.offset+i)%n]
+		if !backend.Available() {
+			continue
+		}
+		score := float64(backend.InFlight()+1) / EffectiveWeight(backend, b.slowStart, now)
+		if best == nil || score < bestScore {
+			best, bestScore = backend, score
+		}
+	}
+	b.offset = (b.offset + 1) % n
+	return best
+}
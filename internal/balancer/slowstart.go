@@ -0,0 +1,43 @@
+package balancer
+
+import (
+	"math"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// defaultMinWeightPercent — доля веса в начале окна slow start, если она не задана.
+const defaultMinWeightPercent = 10
+
+// EffectiveWeight возвращает текущий вес бэкенда с учетом slow start.
+// Пока не истекло окно slow start после HealthySince, вес растет от MinWeightPercent
+// до полного по кривой (elapsed/window)^(1/aggression); aggression 1 дает линейный рост.
+func EffectiveWeight(b *models.Backend, cfg models.SlowStartConfig, now time.Time) float64 {
+	weight := float64(b.Weight)
+	if weight <= 0 {
+		weight = 1
+	}
+	window := cfg.Window.Std()
+	if window <= 0 || b.HealthySince.IsZero() {
+		return weight
+	}
+	elapsed := now.Sub(b.HealthySince)
+	if elapsed >= window {
+		return weight
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	aggression := cfg.Aggression
+	if aggression <= 0 {
+		aggression = 1
+	}
+	minPercent := cfg.MinWeightPercent
+	if minPercent <= 0 {
+		minPercent = defaultMinWeightPercent
+	}
+	factor := math.Pow(float64(elapsed)/float64(window), 1/aggression)
+	return weight * math.Max(minPercent/100, factor)
+}
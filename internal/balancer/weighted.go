@@ -0,0 +1,51 @@
+package balancer
+
+import (
+	"sync"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// WeightedBalancer распределяет запросы пропорционально весам бэкендов
+// по алгоритму smooth weighted round-robin (как в nginx).
+type WeightedBalancer struct {
+	backends  []*models.Backend
+	slowStart models.SlowStartConfig
+	current   map[*models.Backend]float64
+	mu        sync.Mutex
+}
+
+// NewWeightedBalancer создает балансировщик weighted round-robin.
+func NewWeightedBalancer(backends []*models.Backend, slowStart models.SlowStartConfig) *WeightedBalancer {
+	return &WeightedBalancer{
+		backends:  backends,
+		slowStart: slowStart,
+		current:   make(map[*models.Backend]float64),
+	}
+}
+
+// NextBackend возвращает доступный бэкенд с наибольшим накопленным весом.
+func (b *WeightedBalancer) NextBackend() *models.Backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var best *models.Backend
+	total := 0.0
+	for _, backend := range b.backends {
+		if !backend.Available() {
+			continue
+		}
+		weight := EffectiveWeight(backend, b.slowStart, now)
+		b.current[backend] += weight
+		total += weight
+		if best == nil || b.current[backend] > b.current[best] {
+			best = backend
+		}
+	}
+	if best != nil {
+		b.current[best] -= total
+	}
+	return best
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig"
+)
+
+// backendEntry is a backend as written in config.json: either a plain URL string
+// or an object with the URL and per-backend settings.
+type backendEntry struct {
+	ID          string                    `json:"id,omitempty"`
+	URL         string                    `json:"url"`
+	HealthCheck *models.HealthCheckConfig `json:"health_check,omitempty"`
+	State       string                    `json:"state,omitempty"`
+	Weight      int                       `json:"weight,omitempty"`
+	HostHeader  string                    `json:"host_header,omitempty"`
+	TLS         *models.UpstreamTLSConfig `json:"tls,omitempty"`
+	Protocol    string                    `json:"protocol,omitempty"`
+}
+
+// UnmarshalJSON accepts both "http://host:80" and {"url": "http://host:80", ...}.
+func (e *backendEntry) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*e = backendEntry{URL: url}
+		return nil
+	}
+	type plain backendEntry
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("backend must be a URL string or an object: %w", err)
+	}
+	*e = backendEntry(p)
+	return nil
+}
+
+// MarshalJSON writes the short string form when the backend has no extra settings.
+func (e backendEntry) MarshalJSON() ([]byte, error) {
+	if e.ID == "" && e.HealthCheck == nil && e.State == "" && e.Weight == 0 && e.HostHeader == "" && e.TLS == nil && e.Protocol == "" {
+		return json.Marshal(e.URL)
+	}
+	type plain backendEntry
+	return json.Marshal(plain(e))
+}
+
+// DefaultBackendID returns the identifier given to the backend at the given 0-based position.
+func DefaultBackendID(index int) string {
+	return fmt.Sprintf("backend%d", index+1)
+}
+
+// PoolBackendID returns the identifier given to the backend at the given 0-based position in a pool.
+func PoolBackendID(pool string, index int) string {
+	return pool + "-" + DefaultBackendID(index)
+}
+
+// newBackendEntry converts a backend into its config.json form.
+// The ID is omitted when it matches defaultID, the default for the backend's position.
+func newBackendEntry(b *models.Backend, defaultID string) backendEntry {
+	entry := backendEntry{
+		ID:          b.ID,
+		URL:         b.URL,
+		HealthCheck: b.HealthCheck,
+		State:       b.State(),
+		Weight:      b.Weight,
+		HostHeader:  b.HostHeader,
+		TLS:         b.TLS,
+		Protocol:    b.Protocol,
+	}
+	if entry.ID == defaultID {
+		entry.ID = ""
+	}
+	if entry.State == models.BackendActive {
+		entry.State = ""
+	}
+	return entry
+}
+
+// backend converts the config.json form into a backend that starts out healthy.
+// Backends without an explicit ID get defaultID.
+func (e backendEntry) backend(defaultID string) *models.Backend {
+	id := e.ID
+	if id == "" {
+		id = defaultID
+	}
+	b := &models.Backend{
+		ID:            id,
+		URL:           e.URL,
+		Healthy:       true,
+		LoggedHealthy: false,
+		HealthCheck:   e.HealthCheck,
+		Weight:        e.Weight,
+		HostHeader:    e.HostHeader,
+		TLS:           e.TLS,
+		Protocol:      e.Protocol,
+	}
+	b.SetState(e.State)
+	return b
+}
+
+// ValidateBackendState checks that state is one of the backend states accepted by the admin API.
+func ValidateBackendState(state string) error {
+	switch state {
+	case "", models.BackendActive, models.BackendDraining, models.BackendMaintenance:
+		return nil
+	default:
+		return fmt.Errorf("unknown backend state %q", state)
+	}
+}
+
+// validate checks the per-backend settings of the entry.
+func (e backendEntry) validate() error {
+	if e.URL == "" {
+		return fmt.Errorf("backend URL is required")
+	}
+	if err := ValidateBackendURL(e.URL); err != nil {
+		return err
+	}
+	if err := validateHealthCheck(e.HealthCheck); err != nil {
+		return err
+	}
+	if err := ValidateBackendState(e.State); err != nil {
+		return err
+	}
+	if e.Weight < 0 {
+		return fmt.Errorf("backend weight must not be negative")
+	}
+	if err := tlsconfig.ValidateUpstream(e.TLS); err != nil {
+		return fmt.Errorf("backend %s: %w", e.URL, err)
+	}
+	if err := ValidateBackendProtocol(e.URL, e.Protocol); err != nil {
+		return fmt.Errorf("backend %s: %w", e.URL, err)
+	}
+	return ValidateHostHeader(e.HostHeader)
+}
+
+// ValidateBackendURL checks that a backend URL has a scheme and a host, or names a Unix
+// domain socket as unix:///path/to.sock.
+func ValidateBackendURL(raw string) error {
+	u, err := url.ParseRequestURI(raw)
+	if err != nil {
+		return fmt.Errorf("invalid backend URL %s", raw)
+	}
+	if socket, ok := models.UnixSocket(raw); ok {
+		if !path.IsAbs(socket) {
+			return fmt.Errorf("backend URL %s must have the form unix:///path/to.sock", raw)
+		}
+		return nil
+	}
+	if u.Host == "" {
+		return fmt.Errorf("backend URL %s has no host", raw)
+	}
+	return nil
+}
+
+// ValidateProtocol checks the upstream protocol of a backend or pool.
+func ValidateProtocol(protocol string) error {
+	switch protocol {
+	case "", models.ProtocolHTTP1, models.ProtocolH2C:
+		return nil
+	default:
+		return fmt.Errorf("unknown protocol %q", protocol)
+	}
+}
+
+// ValidateBackendProtocol checks the upstream protocol of the backend with the given URL.
+// h2c is HTTP/2 over plaintext, so https backends cannot use it.
+func ValidateBackendProtocol(backendURL, protocol string) error {
+	if err := ValidateProtocol(protocol); err != nil {
+		return err
+	}
+	if u, err := url.Parse(backendURL); err == nil && protocol == models.ProtocolH2C && u.Scheme == "https" {
+		return fmt.Errorf("protocol h2c needs an http:// URL, https backends negotiate HTTP/2 over TLS")
+	}
+	return nil
+}
+
+// ValidateHostHeader checks the Host header choice of a backend.
+func ValidateHostHeader(hostHeader string) error {
+	switch hostHeader {
+	case "", models.HostHeaderClient, models.HostHeaderBackend:
+		return nil
+	default:
+		return fmt.Errorf("unknown host_header %q", hostHeader)
+	}
+}
+
+// validateHealthCheck checks per-backend probe settings.
+func validateHealthCheck(check *models.HealthCheckConfig) error {
+	if check == nil {
+		return nil
+	}
+	switch check.Type {
+	case "", models.HealthCheckHTTP, models.HealthCheckTCP, models.HealthCheckUDP, models.HealthCheckGRPC:
+	case models.HealthCheckExec:
+		if len(check.Command) == 0 {
+			return fmt.Errorf("exec health check requires a command")
+		}
+	default:
+		return fmt.Errorf("unknown health check type %q", check.Type)
+	}
+	if check.Timeout < 0 {
+		return fmt.Errorf("health check timeout must not be negative")
+	}
+	return nil
+}
+
+// validateBalancing checks the balancing strategy and slow-start settings.
+func validateBalancing(strategy string, slowStart models.SlowStartConfig) error {
+	switch strategy {
+	case "", models.StrategyRoundRobin, models.StrategyWeightedRoundRobin, models.StrategyLeastConnections, models.StrategySourceIPHash:
+	default:
+		return fmt.Errorf("unknown strategy %q", strategy)
+	}
+	if slowStart.Window < 0 {
+		return fmt.Errorf("slow start window must not be negative")
+	}
+	if slowStart.Aggression < 0 {
+		return fmt.Errorf("slow start aggression must not be negative")
+	}
+	if slowStart.MinWeightPercent < 0 || slowStart.MinWeightPercent > 100 {
+		return fmt.Errorf("slow start min_weight_percent must be between 0 and 100")
+	}
+	return nil
+}
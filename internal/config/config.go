@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
+	"load-balancer/internal/accesslog"
 	"load-balancer/internal/domain"
 	"load-balancer/internal/logger"
 	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig"
+	"load-balancer/internal/tracing"
 )
 
 // LoadConfig loads configuration from a JSON file.
@@ -28,12 +32,31 @@ func LoadConfig(path string) (*models.Config, error) {
 	logger.InfoKV("Config file read successfully", "path", path)
 
 	var cfg struct {
-		Port                string                 `json:"port"`
-		Backends            []string               `json:"backends"`
-		HealthCheckPath     string                 `json:"health_check_path"`
-		HealthCheckInterval string                 `json:"health_check_interval"`
-		RateLimit           models.RateLimitConfig `json:"rate_limit"`
-		ClientConfigs       []models.ClientConfig  `json:"client_configs"`
+		Port                string                  `json:"port"`
+		AdminPort           string                  `json:"admin_port"`
+		AdminSocketMode     string                  `json:"admin_socket_mode"`
+		Backends            []backendEntry          `json:"backends"`
+		HealthCheckPath     string                  `json:"health_check_path"`
+		HealthCheckInterval string                  `json:"health_check_interval"`
+		HealthHistorySize   int                     `json:"health_history_size"`
+		RateLimit           models.RateLimitConfig  `json:"rate_limit"`
+		ClientConfigs       []models.ClientConfig   `json:"client_configs"`
+		Strategy            string                  `json:"strategy"`
+		SlowStart           models.SlowStartConfig  `json:"slow_start"`
+		Shutdown            models.ShutdownConfig   `json:"shutdown"`
+		Redis               models.RedisConfig      `json:"redis"`
+		Pools               []poolEntry             `json:"pools"`
+		Routes              []*models.Route         `json:"routes"`
+		Headers             models.HeadersConfig    `json:"headers"`
+		Forwarding          models.ForwardingConfig `json:"forwarding"`
+		AccessLog           models.AccessLogConfig  `json:"access_log"`
+		Tracing             models.TracingConfig    `json:"tracing"`
+		Timeouts            models.TimeoutsConfig   `json:"timeouts"`
+		Server              models.ServerConfig     `json:"server"`
+		TLS                 models.TLSConfig        `json:"tls"`
+
+		TCPListeners []models.TCPListenerConfig `json:"tcp_listeners"`
+		UDPListeners []models.UDPListenerConfig `json:"udp_listeners"`
 	}
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		logger.ErrorKV("Failed to unmarshal config", "error", err)
@@ -54,14 +77,25 @@ func LoadConfig(path string) (*models.Config, error) {
 		logger.InfoKV("Using default health check interval", "interval", "5s")
 	}
 
-	// Convert string backends to []*models.Backend
+	// Convert config entries to []*models.Backend
 	backends := make([]*models.Backend, len(cfg.Backends))
-	for i, url := range cfg.Backends {
-		backends[i] = &models.Backend{
-			URL:           url,
-			Healthy:       true,
-			LoggedHealthy: false,
+	for i, entry := range cfg.Backends {
+		if err := entry.validate(); err != nil {
+			logger.ErrorKV("Invalid backend", "url", entry.URL, "error", err)
+			return nil, domain.ErrInvalidConfig
+		}
+		backends[i] = entry.backend(DefaultBackendID(i))
+	}
+
+	// Convert pool entries to []*models.Pool
+	pools := make([]*models.Pool, len(cfg.Pools))
+	for i, entry := range cfg.Pools {
+		pool, err := entry.pool()
+		if err != nil {
+			logger.ErrorKV("Invalid pool", "pool", entry.Name, "error", err)
+			return nil, domain.ErrInvalidConfig
 		}
+		pools[i] = pool
 	}
 
 	// Log environment variables for debugging
@@ -77,6 +111,7 @@ func LoadConfig(path string) (*models.Config, error) {
 		backends = make([]*models.Backend, len(backendURLs))
 		for i, url := range backendURLs {
 			backends[i] = &models.Backend{
+				ID:            DefaultBackendID(i),
 				URL:           strings.TrimSpace(url),
 				Healthy:       true,
 				LoggedHealthy: false,
@@ -108,11 +143,29 @@ func LoadConfig(path string) (*models.Config, error) {
 
 	finalCfg := &models.Config{
 		Port:                port,
+		AdminPort:           strings.TrimPrefix(cfg.AdminPort, ":"),
+		AdminSocketMode:     cfg.AdminSocketMode,
 		Backends:            backends,
 		HealthCheckPath:     cfg.HealthCheckPath,
 		HealthCheckInterval: healthCheckInterval,
+		HealthHistorySize:   cfg.HealthHistorySize,
 		RateLimit:           rateLimit,
 		ClientConfigs:       cfg.ClientConfigs,
+		Strategy:            cfg.Strategy,
+		SlowStart:           cfg.SlowStart,
+		Shutdown:            cfg.Shutdown,
+		Redis:               cfg.Redis,
+		Pools:               pools,
+		Routes:              cfg.Routes,
+		Headers:             cfg.Headers,
+		Forwarding:          cfg.Forwarding,
+		AccessLog:           cfg.AccessLog,
+		Tracing:             cfg.Tracing,
+		Timeouts:            cfg.Timeouts,
+		Server:              cfg.Server,
+		TLS:                 cfg.TLS,
+		TCPListeners:        cfg.TCPListeners,
+		UDPListeners:        cfg.UDPListeners,
 	}
 
 	// Validate configuration
@@ -124,14 +177,91 @@ func LoadConfig(path string) (*models.Config, error) {
 		logger.Error("No backends specified in config")
 		return nil, domain.ErrInvalidConfig
 	}
+	if finalCfg.AdminPort != "" && finalCfg.AdminPort == finalCfg.Port {
+		logger.ErrorKV("Admin port must differ from port", "port", finalCfg.Port)
+		return nil, domain.ErrInvalidConfig
+	}
+	for _, addr := range []string{finalCfg.Port, finalCfg.AdminPort} {
+		if err := validateListenAddress(addr); err != nil {
+			logger.ErrorKV("Invalid listen address", "error", err)
+			return nil, domain.ErrInvalidConfig
+		}
+	}
+	if _, err := models.ParseSocketMode(finalCfg.AdminSocketMode); err != nil {
+		logger.ErrorKV("Invalid admin socket mode", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
 	if finalCfg.HealthCheckPath == "" {
 		finalCfg.HealthCheckPath = "/health"
 		logger.InfoKV("Using default health check path", "path", "/health")
 	}
+	if finalCfg.Redis.Addr == "" {
+		finalCfg.Redis.Addr = "redis:6379"
+		logger.InfoKV("Using default Redis address", "addr", finalCfg.Redis.Addr)
+	}
 	if finalCfg.HealthCheckInterval <= 0 {
 		finalCfg.HealthCheckInterval = 5 * time.Second
 		logger.InfoKV("Using default health check interval", "interval", "5s")
 	}
+	if err := validateBalancing(finalCfg.Strategy, finalCfg.SlowStart); err != nil {
+		logger.ErrorKV("Invalid balancing settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if finalCfg.Shutdown.PreStopDelay < 0 || finalCfg.Shutdown.DrainTimeout < 0 {
+		logger.ErrorKV("Shutdown delays must not be negative", "pre_stop_delay", finalCfg.Shutdown.PreStopDelay.Std(), "drain_timeout", finalCfg.Shutdown.DrainTimeout.Std())
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := validateRouting(finalCfg.Pools, finalCfg.Routes); err != nil {
+		logger.ErrorKV("Invalid routing settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := validateHeaders(&finalCfg.Headers); err != nil {
+		logger.ErrorKV("Invalid header rules", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := validateForwarding(finalCfg.Forwarding); err != nil {
+		logger.ErrorKV("Invalid forwarding settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := accesslog.Validate(finalCfg.AccessLog); err != nil {
+		logger.ErrorKV("Invalid access log settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := tracing.Validate(finalCfg.Tracing); err != nil {
+		logger.ErrorKV("Invalid tracing settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := validateTimeouts(finalCfg.Timeouts); err != nil {
+		logger.ErrorKV("Invalid timeouts", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := validateServer(finalCfg.Server); err != nil {
+		logger.ErrorKV("Invalid server settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := tlsconfig.Validate(finalCfg.TLS); err != nil {
+		logger.ErrorKV("Invalid TLS settings", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	finalCfg.TLS.Port = strings.TrimPrefix(finalCfg.TLS.Port, ":")
+	if finalCfg.TLS.Enabled {
+		if finalCfg.TLS.Port == finalCfg.Port || finalCfg.TLS.Port == finalCfg.AdminPort {
+			logger.ErrorKV("TLS port must differ from port and admin port", "tls_port", finalCfg.TLS.Port)
+			return nil, domain.ErrInvalidConfig
+		}
+	}
+	if err := validateTCPListeners(finalCfg); err != nil {
+		logger.ErrorKV("Invalid TCP listeners", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if err := validateUDPListeners(finalCfg); err != nil {
+		logger.ErrorKV("Invalid UDP listeners", "error", err)
+		return nil, domain.ErrInvalidConfig
+	}
+	if finalCfg.HealthHistorySize < 0 {
+		logger.ErrorKV("Health history size must not be negative", "value", finalCfg.HealthHistorySize)
+		return nil, domain.ErrInvalidConfig
+	}
 	if finalCfg.RateLimit.Capacity <= 0 {
 		logger.Error("Rate limit capacity must be positive")
 		return nil, domain.ErrInvalidConfig
@@ -156,53 +286,140 @@ func LoadConfig(path string) (*models.Config, error) {
 		}
 	}
 
-	logger.InfoKV("Configuration loaded", "port", finalCfg.Port, "backends", len(finalCfg.Backends), "health_check_path", finalCfg.HealthCheckPath, "health_check_interval", finalCfg.HealthCheckInterval, "rate_limit_capacity", finalCfg.RateLimit.Capacity, "rate_limit_rate", finalCfg.RateLimit.Rate, "client_configs", len(finalCfg.ClientConfigs))
+	logger.InfoKV("Configuration loaded", "port", finalCfg.Port, "backends", len(finalCfg.Backends), "health_check_path", finalCfg.HealthCheckPath, "health_check_interval", finalCfg.HealthCheckInterval, "rate_limit_capacity", finalCfg.RateLimit.Capacity, "rate_limit_rate", finalCfg.RateLimit.Rate, "client_configs", len(finalCfg.ClientConfigs), "strategy", finalCfg.Strategy, "pools", len(finalCfg.Pools), "routes", len(finalCfg.Routes))
 	return finalCfg, nil
 }
 
 // SaveConfig saves the configuration to a JSON file.
 func SaveConfig(path string, cfg *models.Config) error {
-	if cfg.Port == "" {
-		cfg.Port = "8087"
-		logger.InfoKV("Using default port for save", "port", cfg.Port)
+	data, err := MarshalConfig(cfg)
+	if err != nil {
+		return err
 	}
-	if cfg.HealthCheckPath == "" {
-		cfg.HealthCheckPath = "/health"
-		logger.InfoKV("Using default health check path for save", "path", cfg.HealthCheckPath)
+	return WriteConfig(path, data)
+}
+
+// MarshalConfig encodes the configuration in its config.json form. It only reads cfg, so
+// callers that share cfg can encode it under a read lock and write the file after releasing it.
+func MarshalConfig(cfg *models.Config) ([]byte, error) {
+	port := cfg.Port
+	if port == "" {
+		port = "8087"
+		logger.InfoKV("Using default port for save", "port", port)
+	}
+	healthCheckPath := cfg.HealthCheckPath
+	if healthCheckPath == "" {
+		healthCheckPath = "/health"
+		logger.InfoKV("Using default health check path for save", "path", healthCheckPath)
 	}
-	if cfg.HealthCheckInterval <= 0 {
-		cfg.HealthCheckInterval = 5 * time.Second
-		logger.InfoKV("Using default health check interval for save", "interval", cfg.HealthCheckInterval)
+	healthCheckInterval := cfg.HealthCheckInterval
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = 5 * time.Second
+		logger.InfoKV("Using default health check interval for save", "interval", healthCheckInterval)
 	}
 
 	// Prepare config for serialization
 	configData := struct {
-		Port                string                 `json:"port"`
-		Backends            []string               `json:"backends"`
-		HealthCheckPath     string                 `json:"health_check_path"`
-		HealthCheckInterval string                 `json:"health_check_interval"`
-		RateLimit           models.RateLimitConfig `json:"rate_limit"`
-		ClientConfigs       []models.ClientConfig  `json:"client_configs"`
+		Port                string                   `json:"port"`
+		AdminPort           string                   `json:"admin_port,omitempty"`
+		AdminSocketMode     string                   `json:"admin_socket_mode,omitempty"`
+		Backends            []backendEntry           `json:"backends"`
+		HealthCheckPath     string                   `json:"health_check_path"`
+		HealthCheckInterval string                   `json:"health_check_interval"`
+		HealthHistorySize   int                      `json:"health_history_size,omitempty"`
+		RateLimit           models.RateLimitConfig   `json:"rate_limit"`
+		ClientConfigs       []models.ClientConfig    `json:"client_configs"`
+		Strategy            string                   `json:"strategy,omitempty"`
+		SlowStart           *models.SlowStartConfig  `json:"slow_start,omitempty"`
+		Shutdown            *models.ShutdownConfig   `json:"shutdown,omitempty"`
+		Redis               *models.RedisConfig      `json:"redis,omitempty"`
+		Pools               []poolEntry              `json:"pools,omitempty"`
+		Routes              []*models.Route          `json:"routes,omitempty"`
+		Headers             *models.HeadersConfig    `json:"headers,omitempty"`
+		Forwarding          *models.ForwardingConfig `json:"forwarding,omitempty"`
+		AccessLog           *models.AccessLogConfig  `json:"access_log,omitempty"`
+		Tracing             *models.TracingConfig    `json:"tracing,omitempty"`
+		Timeouts            *models.TimeoutsConfig   `json:"timeouts,omitempty"`
+		Server              *models.ServerConfig     `json:"server,omitempty"`
+		TLS                 *models.TLSConfig        `json:"tls,omitempty"`
+
+		TCPListeners []models.TCPListenerConfig `json:"tcp_listeners,omitempty"`
+		UDPListeners []models.UDPListenerConfig `json:"udp_listeners,omitempty"`
 	}{
-		Port:                ":" + strings.TrimPrefix(cfg.Port, ":"),
-		Backends:            make([]string, len(cfg.Backends)),
-		HealthCheckPath:     cfg.HealthCheckPath,
-		HealthCheckInterval: cfg.HealthCheckInterval.String(),
+		Port:                listenAddress(port),
+		AdminSocketMode:     cfg.AdminSocketMode,
+		Backends:            make([]backendEntry, len(cfg.Backends)),
+		HealthCheckPath:     healthCheckPath,
+		HealthCheckInterval: healthCheckInterval.String(),
+		HealthHistorySize:   cfg.HealthHistorySize,
 		RateLimit:           cfg.RateLimit,
 		ClientConfigs:       cfg.ClientConfigs,
+		Strategy:            cfg.Strategy,
+		Routes:              cfg.Routes,
+	}
+	for _, l := range cfg.TCPListeners {
+		l.Port = ":" + strings.TrimPrefix(l.Port, ":")
+		configData.TCPListeners = append(configData.TCPListeners, l)
+	}
+	for _, l := range cfg.UDPListeners {
+		l.Port = ":" + strings.TrimPrefix(l.Port, ":")
+		configData.UDPListeners = append(configData.UDPListeners, l)
+	}
+	for _, pool := range cfg.Pools {
+		configData.Pools = append(configData.Pools, newPoolEntry(pool))
+	}
+	if cfg.SlowStart != (models.SlowStartConfig{}) {
+		configData.SlowStart = &cfg.SlowStart
+	}
+	if cfg.Shutdown != (models.ShutdownConfig{}) {
+		configData.Shutdown = &cfg.Shutdown
+	}
+	if cfg.Redis != (models.RedisConfig{}) {
+		configData.Redis = &cfg.Redis
+	}
+	if !cfg.Headers.IsZero() {
+		configData.Headers = &cfg.Headers
+	}
+	if cfg.Forwarding != (models.ForwardingConfig{}) {
+		configData.Forwarding = &cfg.Forwarding
+	}
+	if cfg.AccessLog != (models.AccessLogConfig{}) {
+		configData.AccessLog = &cfg.AccessLog
+	}
+	if !cfg.Tracing.IsZero() {
+		configData.Tracing = &cfg.Tracing
+	}
+	if cfg.Timeouts != (models.TimeoutsConfig{}) {
+		configData.Timeouts = &cfg.Timeouts
+	}
+	if cfg.Server != (models.ServerConfig{}) {
+		configData.Server = &cfg.Server
+	}
+	if !cfg.TLS.IsZero() {
+		tlsCfg := cfg.TLS
+		if tlsCfg.Port != "" {
+			tlsCfg.Port = ":" + strings.TrimPrefix(tlsCfg.Port, ":")
+		}
+		configData.TLS = &tlsCfg
+	}
+	if cfg.AdminPort != "" {
+		configData.AdminPort = listenAddress(cfg.AdminPort)
 	}
 	for i, backend := range cfg.Backends {
-		configData.Backends[i] = backend.URL
+		configData.Backends[i] = newBackendEntry(backend, DefaultBackendID(i))
 	}
 
 	// Serialize to JSON
 	data, err := json.MarshalIndent(configData, "", "  ")
 	if err != nil {
 		logger.ErrorKV("Failed to marshal config", "error", err)
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
+	return data, nil
+}
 
-	// Write to file
+// WriteConfig writes a configuration encoded by MarshalConfig to the file at path.
+func WriteConfig(path string, data []byte) error {
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		logger.ErrorKV("Failed to write config file", "path", path, "error", err)
 		return fmt.Errorf("failed to write config file: %w", err)
@@ -211,3 +428,66 @@ func SaveConfig(path string, cfg *models.Config) error {
 	logger.InfoKV("Config file saved successfully", "path", path)
 	return nil
 }
+
+// validateForwarding checks the forwarding header settings.
+func validateForwarding(f models.ForwardingConfig) error {
+	switch f.XForwardedFor {
+	case "", models.ForwardedForAppend, models.ForwardedForReplace, models.ForwardedForOff:
+		return nil
+	default:
+		return fmt.Errorf("unknown x_forwarded_for mode %q", f.XForwardedFor)
+	}
+}
+
+// validateTimeouts checks that upstream timeouts are not negative. Zero means no timeout.
+func validateTimeouts(t models.TimeoutsConfig) error {
+	values := []struct {
+		name string
+		d    models.Duration
+	}{
+		{"connect", t.Connect},
+		{"tls_handshake", t.TLSHandshake},
+		{"response_header", t.ResponseHeader},
+		{"idle_read", t.IdleRead},
+		{"request", t.Request},
+		{"upgrade_idle", t.UpgradeIdle},
+		{"upgrade_lifetime", t.UpgradeLifetime},
+	}
+	for _, v := range values {
+		if v.d < 0 {
+			return fmt.Errorf("timeout %s must not be negative", v.name)
+		}
+	}
+	return nil
+}
+
+// validateServer checks the limits of the public listener. Zero values take the defaults.
+func validateServer(c models.ServerConfig) error {
+	if c.ReadHeaderTimeout < 0 || c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 {
+		return fmt.Errorf("server timeouts must not be negative")
+	}
+	if c.MaxHeaderBytes < 0 || c.MaxBodyBytes < 0 || c.MaxConnections < 0 {
+		return fmt.Errorf("server limits must not be negative")
+	}
+	if _, err := models.ParseSocketMode(c.SocketMode); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateListenAddress checks that a Unix socket listen address has an absolute path.
+// Other addresses are TCP ports.
+func validateListenAddress(addr string) error {
+	if socket, ok := models.UnixSocket(addr); ok && !path.IsAbs(socket) {
+		return fmt.Errorf("listen address %s must have the form unix:///path/to.sock", addr)
+	}
+	return nil
+}
+
+// listenAddress returns a port as written in config.json: ":8087", or unix:///path unchanged.
+func listenAddress(port string) string {
+	if _, ok := models.UnixSocket(port); ok {
+		return port
+	}
+	return ":" + strings.TrimPrefix(port, ":")
+}
@@ -140,3 +140,247 @@ func TestLoadConfig_InvalidConfig(t *testing.T) {
 		t.Errorf("Expected ErrInvalidConfig, got %v", err)
 	}
 }
+
+func TestLoadConfig_BackendHealthChecks(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.json")
+
+	configContent := `{
+		"port": ":8087",
+		"backends": [
+			"http://localhost:8001",
+			{"url": "http://localhost:8002", "health_check": {"type": "tcp", "send": "PING\n", "expect": "PONG", "timeout": "2s"}},
+			{"url": "http://localhost:8003", "health_check": {"type": "grpc", "service": "billing"}}
+		],
+		"rate_limit": {"capacity": 100, "rate": 10}
+	}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Backends) != 3 {
+		t.Fatalf("Expected 3 backends, got %d", len(cfg.Backends))
+	}
+	if cfg.Backends[0].HealthCheck != nil {
+		t.Errorf("Expected no health check settings for plain URL backend, got %+v", cfg.Backends[0].HealthCheck)
+	}
+	tcp := cfg.Backends[1].HealthCheck
+	if tcp == nil || tcp.Type != models.HealthCheckTCP || tcp.Expect != "PONG" || tcp.Timeout.Std() != 2*time.Second {
+		t.Errorf("Unexpected TCP health check settings: %+v", tcp)
+	}
+	if grpc := cfg.Backends[2].HealthCheck; grpc == nil || grpc.Type != models.HealthCheckGRPC || grpc.Service != "billing" {
+		t.Errorf("Unexpected gRPC health check settings: %+v", grpc)
+	}
+
+	// Saving and loading again must keep both backend forms
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	reloaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if reloaded.Backends[0].URL != "http://localhost:8001" || reloaded.Backends[1].HealthCheck == nil || reloaded.Backends[1].HealthCheck.Send != "PING\n" {
+		t.Errorf("Backends did not survive save/load: %+v, %+v", reloaded.Backends[0], reloaded.Backends[1].HealthCheck)
+	}
+
+	invalidPath := filepath.Join(configDir, "invalid.json")
+	invalidContent := `{
+		"port": ":8087",
+		"backends": [{"url": "http://localhost:8001", "health_check": {"type": "exec"}}],
+		"rate_limit": {"capacity": 100, "rate": 10}
+	}`
+	if err := os.WriteFile(invalidPath, []byte(invalidContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(invalidPath); err != domain.ErrInvalidConfig {
+		t.Errorf("Expected ErrInvalidConfig for exec check without command, got %v", err)
+	}
+}
+
+func TestLoadConfig_ProxySettings(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.json")
+
+	configContent := `{
+		"port": ":8087",
+		"backends": [{"url": "http://localhost:8001", "host_header": "backend"}, {"url": "https://localhost:8003", "tls": {"server_name": "billing.internal"}}, {"url": "http://localhost:8004", "protocol": "h2c"}],
+		"rate_limit": {"capacity": 100, "rate": 10},
+		"headers": {"response": {"remove": ["Server"]}},
+		"forwarding": {"x_forwarded_for": "replace", "x_real_ip": false, "forwarded": true},
+		"access_log": {"enabled": true, "format": "combined", "output": "syslog", "syslog_tag": "lb"},
+		"tracing": {"enabled": true, "endpoint": "otel-collector:4318", "insecure": true, "sample_ratio": 0.25},
+		"timeouts": {"connect": "2s", "response_header": "10s", "upgrade_idle": "5m"},
+		"server": {"read_header_timeout": "5s", "max_body_bytes": 1048576, "max_connections": 500, "h2c": true},
+		"tls": {"enabled": true, "port": ":8443", "certificates": [{"cert_file": "certs/site.crt", "key_file": "certs/site.key"}], "min_version": "1.3", "redirect_http": true,
+			"client_auth": {"mode": "request", "ca_file": "certs/partners.pem", "identity": "subject", "headers": {"subject": "X-Client-Subject"}}},
+		"pools": [{"name": "reports", "backends": ["http://localhost:8002"], "timeouts": {"request": "1m"}, "tls": {"insecure_skip_verify": true}, "protocol": "http1"},
+			{"name": "db", "backends": ["tcp://localhost:5432"], "strategy": "source_ip_hash"}],
+		"tcp_listeners": [{"name": "postgres", "port": ":15432", "pool": "db", "idle_timeout": "1h", "max_connections_per_ip": 10}],
+		"udp_listeners": [{"name": "dns", "port": "15353", "pool": "default", "session_timeout": "5s", "max_sessions": 1000}],
+		"routes": [{"id": "reports", "match": {"path_prefix": "/reports"}, "pool": "reports", "timeouts": {"idle_read": "30s"}, "require_client_cert": true, "flush_interval": "100ms",
+			"split": {"pool": "default", "weight": 5, "sticky_cookie": "lb_canary", "rollout": {"steps": [25, 50, 100], "interval": "10m", "max_error_rate": 0.05}}}]
+	}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Backends[0].HostHeader != models.HostHeaderBackend {
+		t.Errorf("Expected host_header backend, got %q", cfg.Backends[0].HostHeader)
+	}
+	f := cfg.Forwarding
+	if f.XForwardedFor != models.ForwardedForReplace || !f.Forwarded || f.XRealIP == nil || *f.XRealIP || f.XForwardedProto != nil {
+		t.Errorf("Unexpected forwarding settings: %+v", f)
+	}
+
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	reloaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if reloaded.Backends[0].HostHeader != models.HostHeaderBackend || reloaded.Forwarding.XForwardedFor != models.ForwardedForReplace || len(reloaded.Headers.Response.Remove) != 1 {
+		t.Errorf("Proxy settings did not survive save/load: %+v, %+v, %+v", reloaded.Backends[0], reloaded.Forwarding, reloaded.Headers)
+	}
+	if reloaded.AccessLog != cfg.AccessLog || !reloaded.AccessLog.Enabled || reloaded.AccessLog.SyslogTag != "lb" {
+		t.Errorf("Access log settings did not survive save/load: %+v", reloaded.AccessLog)
+	}
+	if tr := reloaded.Tracing; !tr.Enabled || tr.Endpoint != "otel-collector:4318" || tr.SampleRatio == nil || *tr.SampleRatio != 0.25 {
+		t.Errorf("Tracing settings did not survive save/load: %+v", tr)
+	}
+	if reloaded.Timeouts != cfg.Timeouts || reloaded.Timeouts.Connect.Std() != 2*time.Second {
+		t.Errorf("Timeouts did not survive save/load: %+v", reloaded.Timeouts)
+	}
+	if reloaded.Server != cfg.Server || reloaded.Server.ReadHeaderTimeout.Std() != 5*time.Second || reloaded.Server.MaxBodyBytes != 1<<20 {
+		t.Errorf("Server settings did not survive save/load: %+v", reloaded.Server)
+	}
+	if tc := reloaded.TLS; !tc.Enabled || tc.Port != "8443" || len(tc.Certificates) != 1 || tc.Certificates[0].KeyFile != "certs/site.key" || tc.MinVersion != "1.3" || !tc.RedirectHTTP {
+		t.Errorf("TLS settings did not survive save/load: %+v", tc)
+	}
+	if ca := reloaded.TLS.ClientAuth; ca != cfg.TLS.ClientAuth || ca.Mode != models.ClientAuthRequest || ca.Headers.Subject != "X-Client-Subject" || !reloaded.Routes[0].RequireClientCert {
+		t.Errorf("Client certificate settings did not survive save/load: %+v, route %+v", ca, reloaded.Routes[0])
+	}
+	if reloaded.Routes[0].FlushInterval.Std() != 100*time.Millisecond {
+		t.Errorf("Route flush_interval did not survive save/load: %v", reloaded.Routes[0].FlushInterval)
+	}
+	if reloaded.Pools[0].Timeouts.Request.Std() != time.Minute || reloaded.Routes[0].Timeouts == nil || reloaded.Routes[0].Timeouts.IdleRead.Std() != 30*time.Second {
+		t.Errorf("Pool and route timeouts did not survive save/load: %+v, %+v", reloaded.Pools[0].Timeouts, reloaded.Routes[0].Timeouts)
+	}
+	if reloaded.Backends[1].TLS == nil || reloaded.Backends[1].TLS.ServerName != "billing.internal" || reloaded.Backends[0].TLS != nil {
+		t.Errorf("Backend TLS settings did not survive save/load: %+v, %+v", reloaded.Backends[0].TLS, reloaded.Backends[1].TLS)
+	}
+	if reloaded.Pools[0].TLS == nil || !reloaded.Pools[0].TLS.InsecureSkipVerify {
+		t.Errorf("Pool TLS settings did not survive save/load: %+v", reloaded.Pools[0].TLS)
+	}
+	if reloaded.Backends[2].Protocol != models.ProtocolH2C || reloaded.Backends[1].Protocol != "" || reloaded.Pools[0].Protocol != models.ProtocolHTTP1 || !reloaded.Server.H2C {
+		t.Errorf("Protocol settings did not survive save/load: %q, %q, pool %q, h2c %v", reloaded.Backends[1].Protocol, reloaded.Backends[2].Protocol, reloaded.Pools[0].Protocol, reloaded.Server.H2C)
+	}
+	if len(reloaded.TCPListeners) != 1 || reloaded.TCPListeners[0] != cfg.TCPListeners[0] || reloaded.TCPListeners[0].Port != "15432" || reloaded.TCPListeners[0].IdleTimeout.Std() != time.Hour {
+		t.Errorf("TCP listeners did not survive save/load: %+v", reloaded.TCPListeners)
+	}
+	if len(reloaded.UDPListeners) != 1 || reloaded.UDPListeners[0] != cfg.UDPListeners[0] || reloaded.UDPListeners[0].SessionTimeout.Std() != 5*time.Second {
+		t.Errorf("UDP listeners did not survive save/load: %+v", reloaded.UDPListeners)
+	}
+	if sp := reloaded.Routes[0].Split; sp == nil || sp.Pool != models.DefaultPool || sp.Weight != 5 || sp.StickyCookie != "lb_canary" ||
+		sp.Rollout == nil || len(sp.Rollout.Steps) != 3 || sp.Rollout.Interval.Std() != 10*time.Minute || sp.Rollout.MaxErrorRate != 0.05 {
+		t.Errorf("Route split did not survive save/load: %+v", sp)
+	}
+	if reloaded.Pools[1].Strategy != models.StrategySourceIPHash {
+		t.Errorf("Expected source_ip_hash strategy, got %q", reloaded.Pools[1].Strategy)
+	}
+
+	for name, content := range map[string]string{
+		"unknown forwarding mode":   `"forwarding": {"x_forwarded_for": "prepend"}`,
+		"unknown host_header":       `"backends": [{"url": "http://localhost:8002", "host_header": "upstream"}]`,
+		"unknown placeholder":       `"headers": {"request": {"set": {"X-User": "{user}"}}}`,
+		"unknown access log field":  `"access_log": {"enabled": true, "format": "template", "template": "{user} {status}"}`,
+		"access log without path":   `"access_log": {"enabled": true, "output": "file"}`,
+		"negative timeout":          `"timeouts": {"request": "-1s"}`,
+		"negative pool timeout":     `"pools": [{"name": "p", "backends": ["http://localhost:8002"], "timeouts": {"connect": "-1s"}}]`,
+		"negative server limit":     `"server": {"max_connections": -1}`,
+		"tls on the public port":    `"tls": {"enabled": true, "port": "8087", "certificates": [{"cert_file": "a.crt", "key_file": "a.key"}]}`,
+		"unknown tls version":       `"tls": {"enabled": true, "port": "8443", "certificates": [{"cert_file": "a.crt", "key_file": "a.key"}], "min_version": "1.4"}`,
+		"client auth without CA":    `"tls": {"enabled": true, "port": "8443", "certificates": [{"cert_file": "a.crt", "key_file": "a.key"}], "client_auth": {"mode": "require"}}`,
+		"backend cert without key":  `"backends": [{"url": "https://localhost:8002", "tls": {"cert_file": "lb.crt"}}]`,
+		"missing pool CA file":      `"pools": [{"name": "p", "backends": ["https://localhost:8002"], "tls": {"ca_file": "missing-ca.pem"}}]`,
+		"unknown backend protocol":  `"backends": [{"url": "http://localhost:8002", "protocol": "h3"}]`,
+		"unknown pool protocol":     `"pools": [{"name": "p", "backends": ["http://localhost:8002"], "protocol": "spdy"}]`,
+		"h2c to https backend":      `"backends": [{"url": "https://localhost:8002", "protocol": "h2c"}]`,
+		"h2c pool of https backend": `"pools": [{"name": "p", "backends": ["https://localhost:8002"], "protocol": "h2c"}]`,
+		"negative flush interval":   `"routes": [{"id": "events", "match": {"path_prefix": "/events"}, "pool": "default", "flush_interval": "-1s"}]`,
+		"split to the route pool":   `"routes": [{"id": "web", "match": {"path_prefix": "/"}, "pool": "default", "split": {"pool": "default", "weight": 5}}]`,
+		"split to unknown pool":     `"routes": [{"id": "web", "match": {"path_prefix": "/"}, "pool": "default", "split": {"pool": "canary", "weight": 5}}]`,
+		"split weight over 100":     `"pools": [{"name": "canary", "backends": ["http://localhost:8002"]}], "routes": [{"id": "web", "match": {"path_prefix": "/"}, "pool": "default", "split": {"pool": "canary", "weight": 150}}]`,
+		"decreasing rollout steps":  `"pools": [{"name": "canary", "backends": ["http://localhost:8002"]}], "routes": [{"id": "web", "match": {"path_prefix": "/"}, "pool": "default", "split": {"pool": "canary", "rollout": {"steps": [50, 25], "interval": "1m"}}}]`,
+		"rollout without interval":  `"pools": [{"name": "canary", "backends": ["http://localhost:8002"]}], "routes": [{"id": "web", "match": {"path_prefix": "/"}, "pool": "default", "split": {"pool": "canary", "rollout": {"steps": [5, 50]}}}]`,
+		"unknown split state":       `"pools": [{"name": "canary", "backends": ["http://localhost:8002"]}], "routes": [{"id": "web", "match": {"path_prefix": "/"}, "pool": "default", "split": {"pool": "canary", "state": "frozen"}}]`,
+		"tcp listener on http port": `"tcp_listeners": [{"name": "db", "port": "8087", "pool": "default"}]`,
+		"duplicate tcp port":        `"tcp_listeners": [{"name": "a", "port": "5432", "pool": "default"}, {"name": "b", "port": ":5432", "pool": "default"}]`,
+		"tcp listener without name": `"tcp_listeners": [{"port": "5432", "pool": "default"}]`,
+		"tcp listener unknown pool": `"tcp_listeners": [{"name": "db", "port": "5432", "pool": "db"}]`,
+		"negative tcp idle timeout": `"tcp_listeners": [{"name": "db", "port": "5432", "pool": "default", "idle_timeout": "-1s"}]`,
+		"duplicate udp port":        `"udp_listeners": [{"name": "a", "port": "53", "pool": "default"}, {"name": "b", "port": ":53", "pool": "default"}]`,
+		"udp listener unknown pool": `"udp_listeners": [{"name": "dns", "port": "53", "pool": "dns"}]`,
+		"negative udp max sessions": `"udp_listeners": [{"name": "dns", "port": "53", "pool": "default", "max_sessions": -1}]`,
+		"relative unix socket":      `"port": "unix://run/lb.sock"`,
+		"relative admin socket":     `"admin_port": "unix://admin.sock"`,
+		"socket mode not octal":     `"server": {"socket_mode": "0999"}`,
+		"admin socket mode too big": `"admin_port": "unix:///run/admin.sock", "admin_socket_mode": "01777"`,
+		"relative unix backend":     `"backends": ["unix://run/app.sock"]`,
+		"backend URL without host":  `"backends": ["localhost:8001"]`,
+	} {
+		invalidPath := filepath.Join(configDir, "invalid.json")
+		invalidContent := `{"port": ":8087", "backends": ["http://localhost:8001"], "rate_limit": {"capacity": 100, "rate": 10}, ` + content + `}`
+		if err := os.WriteFile(invalidPath, []byte(invalidContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(invalidPath); err != domain.ErrInvalidConfig {
+			t.Errorf("Expected ErrInvalidConfig for %s, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfig_UnixSockets(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configContent := `{
+		"port": "unix:///run/lb/lb.sock",
+		"admin_port": "unix:///run/lb/admin.sock",
+		"admin_socket_mode": "0600",
+		"server": {"socket_mode": "0660"},
+		"backends": ["unix:///run/app/app.sock", {"url": "unix:///run/app/grpc.sock", "health_check": {"type": "grpc"}}],
+		"rate_limit": {"capacity": 100, "rate": 10}
+	}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	reloaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if reloaded.Port != "unix:///run/lb/lb.sock" || reloaded.AdminPort != "unix:///run/lb/admin.sock" {
+		t.Errorf("Socket listen addresses did not survive save/load: %q, %q", reloaded.Port, reloaded.AdminPort)
+	}
+	if reloaded.Server.SocketMode != "0660" || reloaded.AdminSocketMode != "0600" {
+		t.Errorf("Socket modes did not survive save/load: %q, %q", reloaded.Server.SocketMode, reloaded.AdminSocketMode)
+	}
+	if len(reloaded.Backends) != 2 || reloaded.Backends[0].URL != "unix:///run/app/app.sock" || reloaded.Backends[1].HealthCheck == nil {
+		t.Errorf("Unix backends did not survive save/load: %+v", reloaded.Backends)
+	}
+}
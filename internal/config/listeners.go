@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"load-balancer/internal/models"
+)
+
+// validateTCPListeners normalizes the ports of the TCP listeners and checks them against
+// each other, the HTTP ports and the known pools.
+func validateTCPListeners(cfg *models.Config) error {
+	ports := map[string]string{cfg.Port: "port"}
+	if cfg.AdminPort != "" {
+		ports[cfg.AdminPort] = "admin_port"
+	}
+	if cfg.TLS.Enabled {
+		ports[cfg.TLS.Port] = "tls port"
+	}
+	names := make(map[string]bool, len(cfg.TCPListeners))
+	for i := range cfg.TCPListeners {
+		l := &cfg.TCPListeners[i]
+		l.Port = strings.TrimPrefix(l.Port, ":")
+		if l.Name == "" {
+			return fmt.Errorf("tcp listener name is required")
+		}
+		if names[l.Name] {
+			return fmt.Errorf("duplicate tcp listener %s", l.Name)
+		}
+		names[l.Name] = true
+		if l.Port == "" {
+			return fmt.Errorf("tcp listener %s: port is required", l.Name)
+		}
+		if used, ok := ports[l.Port]; ok {
+			return fmt.Errorf("tcp listener %s: port %s is already used by %s", l.Name, l.Port, used)
+		}
+		ports[l.Port] = "tcp listener " + l.Name
+		if err := validatePoolRef(cfg.Pools, l.Pool); err != nil {
+			return fmt.Errorf("tcp listener %s: %w", l.Name, err)
+		}
+		if l.IdleTimeout < 0 || l.ConnectTimeout < 0 {
+			return fmt.Errorf("tcp listener %s: timeouts must not be negative", l.Name)
+		}
+		if l.MaxConnections < 0 || l.MaxConnectionsPerIP < 0 {
+			return fmt.Errorf("tcp listener %s: limits must not be negative", l.Name)
+		}
+	}
+	return nil
+}
+
+// validateUDPListeners normalizes the ports of the UDP listeners and checks them against each
+// other and the known pools. UDP ports may coincide with TCP ones, e.g. for DNS on port 53.
+func validateUDPListeners(cfg *models.Config) error {
+	ports := make(map[string]string, len(cfg.UDPListeners))
+	names := make(map[string]bool, len(cfg.UDPListeners))
+	for i := range cfg.UDPListeners {
+		l := &cfg.UDPListeners[i]
+		l.Port = strings.TrimPrefix(l.Port, ":")
+		if l.Name == "" {
+			return fmt.Errorf("udp listener name is required")
+		}
+		if names[l.Name] {
+			return fmt.Errorf("duplicate udp listener %s", l.Name)
+		}
+		names[l.Name] = true
+		if l.Port == "" {
+			return fmt.Errorf("udp listener %s: port is required", l.Name)
+		}
+		if used, ok := ports[l.Port]; ok {
+			return fmt.Errorf("udp listener %s: port %s is already used by udp listener %s", l.Name, l.Port, used)
+		}
+		ports[l.Port] = l.Name
+		if err := validatePoolRef(cfg.Pools, l.Pool); err != nil {
+			return fmt.Errorf("udp listener %s: %w", l.Name, err)
+		}
+		if l.SessionTimeout < 0 {
+			return fmt.Errorf("udp listener %s: session_timeout must not be negative", l.Name)
+		}
+		if l.MaxSessions < 0 {
+			return fmt.Errorf("udp listener %s: max_sessions must not be negative", l.Name)
+		}
+	}
+	return nil
+}
+
+// validatePoolRef checks that pool names the default pool or one of pools.
+func validatePoolRef(pools []*models.Pool, pool string) error {
+	if pool == "" {
+		return fmt.Errorf("pool is required")
+	}
+	if pool == models.DefaultPool {
+		return nil
+	}
+	for _, p := range pools {
+		if p.Name == pool {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown pool %q", pool)
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/proxy"
+	"load-balancer/internal/tlsconfig"
+)
+
+// poolEntry is a pool as written in config.json.
+type poolEntry struct {
+	Name                string                    `json:"name"`
+	Backends            []backendEntry            `json:"backends"`
+	Strategy            string                    `json:"strategy,omitempty"`
+	SlowStart           *models.SlowStartConfig   `json:"slow_start,omitempty"`
+	HealthCheckPath     string                    `json:"health_check_path,omitempty"`
+	HealthCheckInterval models.Duration           `json:"health_check_interval,omitempty"`
+	Timeouts            *models.TimeoutsConfig    `json:"timeouts,omitempty"`
+	TLS                 *models.UpstreamTLSConfig `json:"tls,omitempty"`
+	Protocol            string                    `json:"protocol,omitempty"`
+}
+
+// newPoolEntry converts a pool into its config.json form.
+func newPoolEntry(p *models.Pool) poolEntry {
+	entry := poolEntry{
+		Name:                p.Name,
+		Backends:            make([]backendEntry, len(p.Backends)),
+		Strategy:            p.Strategy,
+		HealthCheckPath:     p.HealthCheckPath,
+		HealthCheckInterval: p.HealthCheckInterval,
+		TLS:                 p.TLS,
+		Protocol:            p.Protocol,
+	}
+	if p.SlowStart != (models.SlowStartConfig{}) {
+		slowStart := p.SlowStart
+		entry.SlowStart = &slowStart
+	}
+	if p.Timeouts != (models.TimeoutsConfig{}) {
+		timeouts := p.Timeouts
+		entry.Timeouts = &timeouts
+	}
+	for i, b := range p.Backends {
+		entry.Backends[i] = newBackendEntry(b, PoolBackendID(p.Name, i))
+	}
+	return entry
+}
+
+// pool converts the config.json form into a pool whose backends start out healthy.
+func (e poolEntry) pool() (*models.Pool, error) {
+	p := &models.Pool{
+		Name:                e.Name,
+		Backends:            make([]*models.Backend, len(e.Backends)),
+		Strategy:            e.Strategy,
+		HealthCheckPath:     e.HealthCheckPath,
+		HealthCheckInterval: e.HealthCheckInterval,
+		TLS:                 e.TLS,
+		Protocol:            e.Protocol,
+	}
+	if e.SlowStart != nil {
+		p.SlowStart = *e.SlowStart
+	}
+	if e.Timeouts != nil {
+		p.Timeouts = *e.Timeouts
+	}
+	for i, b := range e.Backends {
+		if err := b.validate(); err != nil {
+			return nil, fmt.Errorf("pool %s: %w", e.Name, err)
+		}
+		p.Backends[i] = b.backend(PoolBackendID(e.Name, i))
+	}
+	return p, nil
+}
+
+// ValidatePool checks the settings of a pool. Backend entries are validated when the pool is loaded.
+func ValidatePool(p *models.Pool) error {
+	if p.Name == "" {
+		return fmt.Errorf("pool name is required")
+	}
+	if p.Name == models.DefaultPool {
+		return fmt.Errorf("pool name %q is reserved for the top-level backends", models.DefaultPool)
+	}
+	if len(p.Backends) == 0 {
+		return fmt.Errorf("pool %s has no backends", p.Name)
+	}
+	if p.HealthCheckInterval < 0 {
+		return fmt.Errorf("pool %s: health check interval must not be negative", p.Name)
+	}
+	if err := validateBalancing(p.Strategy, p.SlowStart); err != nil {
+		return fmt.Errorf("pool %s: %w", p.Name, err)
+	}
+	if err := validateTimeouts(p.Timeouts); err != nil {
+		return fmt.Errorf("pool %s: %w", p.Name, err)
+	}
+	if err := tlsconfig.ValidateUpstream(p.TLS); err != nil {
+		return fmt.Errorf("pool %s: %w", p.Name, err)
+	}
+	if err := ValidateProtocol(p.Protocol); err != nil {
+		return fmt.Errorf("pool %s: %w", p.Name, err)
+	}
+	for _, b := range p.Backends {
+		if err := ValidateBackendProtocol(b.URL, b.UpstreamProtocol(p.Protocol)); err != nil {
+			return fmt.Errorf("pool %s: backend %s: %w", p.Name, b.URL, err)
+		}
+	}
+	return nil
+}
+
+// ValidateRoute checks a route against the set of known pool names.
+func ValidateRoute(r *models.Route, pools map[string]bool) error {
+	if r.ID == "" {
+		return fmt.Errorf("route ID is required")
+	}
+	if r.Pool == "" {
+		return fmt.Errorf("route %s: pool is required", r.ID)
+	}
+	if r.Pool != models.DefaultPool && !pools[r.Pool] {
+		return fmt.Errorf("route %s: unknown pool %q", r.ID, r.Pool)
+	}
+	if r.Match.PathRegex != "" {
+		if _, err := regexp.Compile(r.Match.PathRegex); err != nil {
+			return fmt.Errorf("route %s: invalid path_regex: %w", r.ID, err)
+		}
+	}
+	if r.Rewrite != nil {
+		if err := validateRewrite(r.Rewrite); err != nil {
+			return fmt.Errorf("route %s: %w", r.ID, err)
+		}
+	}
+	if err := validateHeaders(r.Headers); err != nil {
+		return fmt.Errorf("route %s: %w", r.ID, err)
+	}
+	if r.Timeouts != nil {
+		if err := validateTimeouts(*r.Timeouts); err != nil {
+			return fmt.Errorf("route %s: %w", r.ID, err)
+		}
+	}
+	if r.FlushInterval < 0 {
+		return fmt.Errorf("route %s: flush_interval must not be negative", r.ID)
+	}
+	if r.Split != nil {
+		if err := validateSplit(r.Split, r.Pool, pools); err != nil {
+			return fmt.Errorf("route %s: %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+// validateSplit checks the traffic split of a route that sends the rest of its requests to pool.
+func validateSplit(split *models.TrafficSplit, pool string, pools map[string]bool) error {
+	if split.Pool == "" {
+		return fmt.Errorf("split pool is required")
+	}
+	if split.Pool != models.DefaultPool && !pools[split.Pool] {
+		return fmt.Errorf("unknown split pool %q", split.Pool)
+	}
+	if split.Pool == pool {
+		return fmt.Errorf("split pool must differ from the route pool")
+	}
+	if split.Weight < 0 || split.Weight > 100 {
+		return fmt.Errorf("split weight must be between 0 and 100")
+	}
+	if split.StickyCookie != "" {
+		if err := (&http.Cookie{Name: split.StickyCookie}).Valid(); err != nil {
+			return fmt.Errorf("invalid sticky_cookie: %w", err)
+		}
+	}
+	switch split.State {
+	case "", models.SplitActive, models.SplitPaused, models.SplitRolledBack:
+	default:
+		return fmt.Errorf("unknown split state %q", split.State)
+	}
+	rollout := split.Rollout
+	if rollout == nil {
+		return nil
+	}
+	previous := 0
+	for _, step := range rollout.Steps {
+		if step <= previous || step > 100 {
+			return fmt.Errorf("rollout steps must increase and be between 1 and 100")
+		}
+		previous = step
+	}
+	if len(rollout.Steps) > 0 && rollout.Interval <= 0 {
+		return fmt.Errorf("rollout steps require a positive interval")
+	}
+	if rollout.Interval < 0 {
+		return fmt.Errorf("rollout interval must not be negative")
+	}
+	if rollout.MaxErrorRate < 0 || rollout.MaxErrorRate > 1 {
+		return fmt.Errorf("rollout max_error_rate must be between 0 and 1")
+	}
+	if rollout.MinRequests < 0 {
+		return fmt.Errorf("rollout min_requests must not be negative")
+	}
+	return nil
+}
+
+// validateHeaders checks header names and the placeholders used in values.
+func validateHeaders(h *models.HeadersConfig) error {
+	_, err := proxy.NewHeaders(h)
+	return err
+}
+
+// validateRewrite checks the path rewrite settings of a route.
+func validateRewrite(rw *models.RewriteConfig) error {
+	if rw.StripPrefix != "" && !strings.HasPrefix(rw.StripPrefix, "/") {
+		return fmt.Errorf("rewrite strip_prefix must start with /")
+	}
+	if rw.AddPrefix != "" && !strings.HasPrefix(rw.AddPrefix, "/") {
+		return fmt.Errorf("rewrite add_prefix must start with /")
+	}
+	if rw.Regex == "" && rw.Replacement != "" {
+		return fmt.Errorf("rewrite replacement requires regex")
+	}
+	if rw.Regex != "" {
+		if _, err := regexp.Compile(rw.Regex); err != nil {
+			return fmt.Errorf("invalid rewrite regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateRouting checks pool names for duplicates and every route against the pools.
+func validateRouting(pools []*models.Pool, routes []*models.Route) error {
+	names := make(map[string]bool, len(pools))
+	for _, p := range pools {
+		if err := ValidatePool(p); err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate pool %s", p.Name)
+		}
+		names[p.Name] = true
+	}
+	ids := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		if err := ValidateRoute(r, names); err != nil {
+			return err
+		}
+		if ids[r.ID] {
+			return fmt.Errorf("duplicate route %s", r.ID)
+		}
+		ids[r.ID] = true
+	}
+	return nil
+}
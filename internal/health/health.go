@@ -2,20 +2,32 @@ package health
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"load-balancer/internal/logger"
 	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig"
 	"load-balancer/pkg/httpclient"
 )
 
 // HealthChecker performs periodic health checks on backends.
 type HealthChecker struct {
-	client     *http.Client
-	firstCheck chan struct{} // Signal for completion of the first check (for tests)
-	once       sync.Once     // Ensures single initialization of firstCheck
+	client      *http.Client
+	tlsMu       sync.Mutex
+	tlsClients  map[models.UpstreamTLSConfig]*http.Client // Clients for backends with upstream TLS settings
+	unixClients map[string]*http.Client                   // Clients for backends on Unix domain sockets, by socket path
+	firstCheck  chan struct{}                             // Signal for completion of the first check (for tests)
+	once        sync.Once                                 // Ensures single initialization of firstCheck
+	history     *historyStore                             // Recent probe results per backend
+	heartbeat   atomic.Int64                              // Unix nanoseconds of the last check loop activity, 0 before Start
+	stallAfter  atomic.Int64                              // Heartbeat age after which the loop is considered stuck
+
+	checksMu sync.Mutex
+	checks   map[string]*sync.Mutex // Serializes the checks of each backend, by URL
 }
 
 // NewHealthChecker creates a new health checker.
@@ -23,7 +35,84 @@ func NewHealthChecker() *HealthChecker {
 	return &HealthChecker{
 		client:     httpclient.NewClient(5 * time.Second),
 		firstCheck: make(chan struct{}),
+		history:    newHistoryStore(DefaultHistorySize),
+	}
+}
+
+// SetHistorySize changes how many probe results are kept per backend.
+func (hc *HealthChecker) SetHistorySize(size int) {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	hc.history.resize(size)
+}
+
+// History returns the recent probe results for the backend, oldest first.
+func (hc *HealthChecker) History(backendURL string) []ProbeResult {
+	return hc.history.list(backendURL)
+}
+
+// Forget drops the probe history of a removed backend.
+func (hc *HealthChecker) Forget(backendURL string) {
+	hc.history.remove(backendURL)
+	hc.checksMu.Lock()
+	delete(hc.checks, backendURL)
+	hc.checksMu.Unlock()
+}
+
+// checkLock returns the mutex that serializes the checks of the backend, so an on-demand
+// check and the periodic loop never update its health status at the same time.
+func (hc *HealthChecker) checkLock(backendURL string) *sync.Mutex {
+	hc.checksMu.Lock()
+	defer hc.checksMu.Unlock()
+	mu, ok := hc.checks[backendURL]
+	if !ok {
+		if hc.checks == nil {
+			hc.checks = make(map[string]*sync.Mutex)
+		}
+		mu = &sync.Mutex{}
+		hc.checks[backendURL] = mu
 	}
+	return mu
+}
+
+// Check probes the backend once, records the result in its history and updates its health status.
+// defaultPath and defaultTLS are the settings of the backend's pool, used unless the backend has its own.
+// Checks of the same backend run one at a time.
+func (hc *HealthChecker) Check(ctx context.Context, backend *models.Backend, defaultPath string, defaultTLS *models.UpstreamTLSConfig) ProbeResult {
+	mu := hc.checkLock(backend.URL)
+	mu.Lock()
+	defer mu.Unlock()
+
+	start := time.Now()
+	err := hc.probe(ctx, backend, defaultPath, backend.UpstreamTLS(defaultTLS))
+	result := ProbeResult{
+		Time:      start,
+		Healthy:   err == nil,
+		Status:    "healthy",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "unhealthy"
+		result.Error = err.Error()
+	}
+	hc.history.add(backend.URL, result)
+
+	backend.LastChecked = time.Now()
+	if result.Healthy && !backend.Healthy {
+		backend.HealthySince = backend.LastChecked
+	}
+	backend.Healthy = result.Healthy
+	if backend.Healthy {
+		if !backend.LoggedHealthy {
+			logger.InfoKV("Backend is healthy", "url", backend.URL)
+			backend.LoggedHealthy = true
+		}
+	} else {
+		logger.WarnKV("Backend is unhealthy", "url", backend.URL, "error", err)
+		backend.LoggedHealthy = false
+	}
+	return result
 }
 
 // Client returns the HTTP client used by the HealthChecker.
@@ -31,50 +120,99 @@ func (hc *HealthChecker) Client() *http.Client {
 	return hc.client
 }
 
-// Start begins periodic health checks for the given backends.
-func (hc *HealthChecker) Start(ctx context.Context, cfg *models.Config, interval time.Duration) {
+// clientFor returns the HTTP client for probes with the given upstream TLS settings.
+// Clients are cached so probes of backends with the same settings reuse connections.
+func (hc *HealthChecker) clientFor(upstreamTLS *models.UpstreamTLSConfig) (*http.Client, error) {
+	if upstreamTLS == nil {
+		return hc.client, nil
+	}
+	hc.tlsMu.Lock()
+	defer hc.tlsMu.Unlock()
+	if c, ok := hc.tlsClients[*upstreamTLS]; ok {
+		return c, nil
+	}
+	tlsCfg, err := tlsconfig.Client(upstreamTLS)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	c := &http.Client{Timeout: hc.client.Timeout, Transport: transport}
+	if hc.tlsClients == nil {
+		hc.tlsClients = make(map[models.UpstreamTLSConfig]*http.Client)
+	}
+	hc.tlsClients[*upstreamTLS] = c
+	return c, nil
+}
+
+// unixClient returns the HTTP client for probes of a backend listening on the Unix domain socket.
+// Every socket gets its own client, since the transport dials the socket whatever the request host.
+func (hc *HealthChecker) unixClient(socket string) *http.Client {
+	hc.tlsMu.Lock()
+	defer hc.tlsMu.Unlock()
+	if c, ok := hc.unixClients[socket]; ok {
+		return c
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+	c := &http.Client{Timeout: hc.client.Timeout, Transport: transport}
+	if hc.unixClients == nil {
+		hc.unixClients = make(map[string]*http.Client)
+	}
+	hc.unixClients[socket] = c
+	return c
+}
+
+// Start begins periodic health checks for the backends and the backends of every pool.
+// Pools are checked at their own interval when one is set, otherwise at the given interval.
+// snapshot is called on every tick and must return a configuration that is not changed
+// concurrently, e.g. a copy taken under the lock of its owner, see api.Server.ConfigSnapshot.
+func (hc *HealthChecker) Start(ctx context.Context, snapshot func() *models.Config, interval time.Duration) {
 	if interval <= 0 {
 		logger.FatalKV("Health check interval must be positive", "interval", interval)
 	}
 	logger.InfoKV("Starting health checker", "interval", interval)
+	hc.stallAfter.Store(int64(3*interval + defaultProbeTimeout))
+	hc.beat()
 	ticker := time.NewTicker(interval)
 	go func() {
 		defer ticker.Stop()
+		nextCheck := make(map[string]time.Time) // Next due time per pool, keyed by pool name
 		for {
 			select {
 			case <-ctx.Done():
 				logger.Info("Health checker stopped")
+				hc.heartbeat.Store(0)
 				return
 			case <-ticker.C:
-				for _, backend := range cfg.Backends {
-					req, err := http.NewRequestWithContext(ctx, "GET", backend.URL+cfg.HealthCheckPath, nil)
-					if err != nil {
-						logger.ErrorKV("Failed to create health check request", "url", backend.URL, "error", err)
-						backend.Healthy = false
-						backend.LastChecked = time.Now()
-						logger.WarnKV("Backend is unhealthy", "url", backend.URL, "error", err)
-						continue
+				hc.beat()
+				cfg := snapshot()
+				now := time.Now()
+				tick := interval
+				if !now.Before(nextCheck[models.DefaultPool]) {
+					hc.checkAll(ctx, cfg.Backends, cfg.HealthCheckPath, nil)
+					nextCheck[models.DefaultPool] = now.Add(interval)
+				}
+				for _, pool := range cfg.Pools {
+					poolInterval := pool.HealthCheckInterval.Std()
+					if poolInterval <= 0 {
+						poolInterval = interval
 					}
-					resp, err := hc.client.Do(req)
-					backend.LastChecked = time.Now()
-					if err != nil {
-						logger.WarnKV("Health check failed", "url", backend.URL, "error", err)
-						backend.Healthy = false
-						logger.WarnKV("Backend is unhealthy", "url", backend.URL, "error", err)
+					tick = min(tick, poolInterval)
+					if now.Before(nextCheck[pool.Name]) {
 						continue
 					}
-					defer resp.Body.Close()
-					backend.Healthy = resp.StatusCode == http.StatusOK
-					if backend.Healthy {
-						if !backend.LoggedHealthy {
-							logger.InfoKV("Backend is healthy", "url", backend.URL)
-							backend.LoggedHealthy = true
-						}
-					} else {
-						logger.WarnKV("Backend is unhealthy", "url", backend.URL, "status_code", resp.StatusCode, "status_text", resp.Status)
-						backend.LoggedHealthy = false
+					path := pool.HealthCheckPath
+					if path == "" {
+						path = cfg.HealthCheckPath
 					}
+					hc.checkAll(ctx, pool.Backends, path, pool.TLS)
+					nextCheck[pool.Name] = now.Add(poolInterval)
 				}
+				ticker.Reset(tick)
 				hc.once.Do(func() {
 					close(hc.firstCheck)
 				})
@@ -83,6 +221,40 @@ func (hc *HealthChecker) Start(ctx context.Context, cfg *models.Config, interval
 	}()
 }
 
+// checkAll probes the backends one after another.
+func (hc *HealthChecker) checkAll(ctx context.Context, backends []*models.Backend, path string, tls *models.UpstreamTLSConfig) {
+	for _, backend := range backends {
+		hc.Check(ctx, backend, path, tls)
+		hc.beat()
+	}
+}
+
+// beat records check loop activity for liveness reporting.
+func (hc *HealthChecker) beat() {
+	hc.heartbeat.Store(time.Now().UnixNano())
+}
+
+// Alive reports whether the check loop is running and has made progress recently.
+// It also returns the time of the last loop activity, zero if the loop is not running.
+func (hc *HealthChecker) Alive() (bool, time.Time) {
+	last := hc.heartbeat.Load()
+	if last == 0 {
+		return false, time.Time{}
+	}
+	heartbeat := time.Unix(0, last)
+	return time.Since(heartbeat) <= time.Duration(hc.stallAfter.Load()), heartbeat
+}
+
+// FirstCheckDone reports whether the first round of health checks has completed.
+func (hc *HealthChecker) FirstCheckDone() bool {
+	select {
+	case <-hc.firstCheck:
+		return true
+	default:
+		return false
+	}
+}
+
 // WaitFirstCheck waits for the first health check to complete (for testing purposes).
 func (hc *HealthChecker) WaitFirstCheck() {
 	<-hc.firstCheck
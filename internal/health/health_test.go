@@ -1,15 +1,27 @@
 package health
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"load-balancer/internal/logger"
 	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig/tlstest"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func TestMain(m *testing.M) {
@@ -35,7 +47,7 @@ func TestHealthChecker_Start(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	healthChecker.Start(ctx, cfg, 1*time.Second)
+	healthChecker.Start(ctx, func() *models.Config { return cfg }, 1*time.Second)
 	healthChecker.WaitFirstCheck()
 
 	if !cfg.Backends[0].Healthy {
@@ -58,10 +70,362 @@ func TestHealthChecker_UnhealthyBackend(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	healthChecker.Start(ctx, cfg, 1*time.Second)
+	healthChecker.Start(ctx, func() *models.Config { return cfg }, 1*time.Second)
 	healthChecker.WaitFirstCheck()
 
 	if cfg.Backends[0].Healthy {
 		t.Error("Expected backend to be unhealthy")
 	}
 }
+
+// startTCPServer starts a line-based stand-in server that answers "PONG" to "PING".
+func startTCPServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err == nil && line == "PING\n" {
+					conn.Write([]byte("PONG\n"))
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// startUDPServer starts a UDP server that sends every datagram back to its sender.
+func startUDPServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// startGRPCServer starts a gRPC server that implements grpc.health.v1.Health.
+func startGRPCServer(t *testing.T, opts ...grpc.ServerOption) (string, *grpchealth.Server) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(opts...)
+	healthSrv := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	return ln.Addr().String(), healthSrv
+}
+
+// startUnixServer serves handler on a Unix domain socket and returns the socket path.
+func startUnixServer(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "backend.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return socket
+}
+
+func TestHealthChecker_Probe(t *testing.T) {
+	tcpAddr := startTCPServer(t)
+	grpcAddr, grpcHealth := startGRPCServer(t)
+	grpcHealth.SetServingStatus("billing", healthpb.HealthCheckResponse_SERVING)
+	grpcHealth.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	closedLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closedLn.Addr().String()
+	closedLn.Close()
+	udpAddr := startUDPServer(t)
+	unixSocket := startUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	grpcSocket := filepath.Join(t.TempDir(), "grpc.sock")
+	grpcLn, err := net.Listen("unix", grpcSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcSrv := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcSrv, grpchealth.NewServer())
+	go grpcSrv.Serve(grpcLn)
+	t.Cleanup(grpcSrv.Stop)
+
+	tests := []struct {
+		name    string
+		backend *models.Backend
+		healthy bool
+	}{
+		{
+			name:    "TCP connect",
+			backend: &models.Backend{URL: "http://" + tcpAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckTCP}},
+			healthy: true,
+		},
+		{
+			name:    "TCP send and expect",
+			backend: &models.Backend{URL: "tcp://ignored", HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckTCP, Address: tcpAddr, Send: "PING\n", Expect: "PONG"}},
+			healthy: true,
+		},
+		{
+			name:    "TCP unexpected reply",
+			backend: &models.Backend{URL: "http://" + tcpAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckTCP, Send: "HELLO\n", Expect: "PONG", Timeout: models.Duration(time.Second)}},
+			healthy: false,
+		},
+		{
+			name:    "TCP connect by default for tcp URLs",
+			backend: &models.Backend{URL: "tcp://" + tcpAddr},
+			healthy: true,
+		},
+		{
+			name:    "TCP connection refused",
+			backend: &models.Backend{URL: "http://" + closedAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckTCP}},
+			healthy: false,
+		},
+		{
+			name:    "UDP reply by default for udp URLs",
+			backend: &models.Backend{URL: "udp://" + udpAddr},
+			healthy: true,
+		},
+		{
+			name:    "UDP send and expect",
+			backend: &models.Backend{URL: "udp://" + udpAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckUDP, Send: "PING", Expect: "PING"}},
+			healthy: true,
+		},
+		{
+			name:    "UDP unexpected reply",
+			backend: &models.Backend{URL: "udp://" + udpAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckUDP, Send: "HELLO", Expect: "PONG"}},
+			healthy: false,
+		},
+		{
+			name:    "UDP without reply",
+			backend: &models.Backend{URL: "udp://" + closedAddr, HealthCheck: &models.HealthCheckConfig{Timeout: models.Duration(200 * time.Millisecond)}},
+			healthy: false,
+		},
+		{
+			name:    "gRPC overall health",
+			backend: &models.Backend{URL: "http://" + grpcAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckGRPC}},
+			healthy: true,
+		},
+		{
+			name:    "gRPC serving service",
+			backend: &models.Backend{URL: "http://" + grpcAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckGRPC, Service: "billing"}},
+			healthy: true,
+		},
+		{
+			name:    "gRPC not serving service",
+			backend: &models.Backend{URL: "http://" + grpcAddr, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckGRPC, Service: "orders"}},
+			healthy: false,
+		},
+		{
+			name:    "HTTP over a Unix socket",
+			backend: &models.Backend{URL: "unix://" + unixSocket},
+			healthy: true,
+		},
+		{
+			name:    "HTTP over a Unix socket with a missing path",
+			backend: &models.Backend{URL: "unix://" + unixSocket, HealthCheck: &models.HealthCheckConfig{Path: "/missing"}},
+			healthy: false,
+		},
+		{
+			name:    "Connect to a Unix socket",
+			backend: &models.Backend{URL: "unix://" + unixSocket, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckTCP}},
+			healthy: true,
+		},
+		{
+			name:    "Connect to a missing Unix socket",
+			backend: &models.Backend{URL: "unix://" + unixSocket + ".gone", HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckTCP}},
+			healthy: false,
+		},
+		{
+			name:    "gRPC over a Unix socket",
+			backend: &models.Backend{URL: "unix://" + grpcSocket, HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckGRPC}},
+			healthy: true,
+		},
+		{
+			name:    "Exec exit code 0",
+			backend: &models.Backend{URL: "http://localhost:8001", HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckExec, Command: []string{"sh", "-c", `test "$BACKEND_URL" = "http://localhost:8001"`}}},
+			healthy: true,
+		},
+		{
+			name:    "Exec non-zero exit code",
+			backend: &models.Backend{URL: "http://localhost:8001", HealthCheck: &models.HealthCheckConfig{Type: models.HealthCheckExec, Command: []string{"sh", "-c", "exit 2"}}},
+			healthy: false,
+		},
+	}
+
+	healthChecker := NewHealthChecker()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := healthChecker.probe(context.Background(), tt.backend, "/health", nil)
+			if (err == nil) != tt.healthy {
+				t.Errorf("Expected healthy=%v, got error %v", tt.healthy, err)
+			}
+		})
+	}
+}
+
+func TestHealthChecker_History(t *testing.T) {
+	healthy := true
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer backendServer.Close()
+
+	backend := &models.Backend{URL: backendServer.URL}
+	healthChecker := NewHealthChecker()
+	healthChecker.SetHistorySize(3)
+
+	for i := 0; i < 4; i++ {
+		healthy = i%2 == 0
+		result := healthChecker.Check(context.Background(), backend, "/health", nil)
+		if result.Healthy != healthy || backend.Healthy != healthy {
+			t.Errorf("Probe %d: expected healthy=%v, got result %+v, backend %v", i, healthy, result, backend.Healthy)
+		}
+	}
+
+	// The last recovery (probe 2) starts the slow-start window
+	if backend.HealthySince.IsZero() || backend.HealthySince.Before(backend.LastChecked.Add(-time.Second)) {
+		t.Errorf("Expected HealthySince to be set on recovery, got %v", backend.HealthySince)
+	}
+
+	history := healthChecker.History(backend.URL)
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 results in history, got %d", len(history))
+	}
+	// Oldest result (probe 0) was overwritten; probes 1..3 remain in order
+	for i, expected := range []bool{false, true, false} {
+		if history[i].Healthy != expected {
+			t.Errorf("History[%d]: expected healthy=%v, got %+v", i, expected, history[i])
+		}
+	}
+	if history[2].Error == "" || history[2].Status != "unhealthy" {
+		t.Errorf("Expected error and unhealthy status in last result, got %+v", history[2])
+	}
+	if !history[0].Time.Before(history[2].Time) {
+		t.Errorf("Expected history ordered oldest first, got %v and %v", history[0].Time, history[2].Time)
+	}
+
+	healthChecker.Forget(backend.URL)
+	if len(healthChecker.History(backend.URL)) != 0 {
+		t.Error("Expected empty history after Forget")
+	}
+}
+
+func TestHealthChecker_ConcurrentChecks(t *testing.T) {
+	var active, overlapped atomic.Int32
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if active.Add(1) > 1 {
+			overlapped.Store(1)
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+	}))
+	defer backendServer.Close()
+
+	cfg := &models.Config{
+		Backends:        []*models.Backend{{URL: backendServer.URL}},
+		HealthCheckPath: "/health",
+	}
+	backend := cfg.Backends[0]
+	healthChecker := NewHealthChecker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	healthChecker.Start(ctx, func() *models.Config { return cfg }, 5*time.Millisecond)
+
+	// On-demand checks run alongside the periodic loop
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				healthChecker.Check(ctx, backend, "/health", nil)
+			}
+		}()
+	}
+	wg.Wait()
+	healthChecker.WaitFirstCheck()
+
+	if overlapped.Load() != 0 {
+		t.Error("Expected checks of the same backend to run one at a time")
+	}
+	if result := healthChecker.Check(ctx, backend, "/health", nil); !result.Healthy || !backend.Healthy || backend.LastChecked.IsZero() {
+		t.Errorf("Expected healthy backend, got result %+v, backend %+v", result, backend)
+	}
+}
+
+func TestHealthChecker_UpstreamTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCert := tlstest.NewCert(t, dir, "backend.internal")
+	clientCert := tlstest.NewCert(t, dir, "lb.internal")
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{serverCert.TLS},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCert.Pool(),
+	}
+
+	httpServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	httpServer.TLS = serverTLS
+	httpServer.StartTLS()
+	defer httpServer.Close()
+	grpcAddr, _ := startGRPCServer(t, grpc.Creds(credentials.NewTLS(serverTLS)))
+
+	mtls := &models.UpstreamTLSConfig{CAFile: serverCert.CertFile, CertFile: clientCert.CertFile, KeyFile: clientCert.KeyFile, ServerName: "backend.internal"}
+	noClientCert := &models.UpstreamTLSConfig{CAFile: serverCert.CertFile, ServerName: "backend.internal"}
+	grpcCheck := &models.HealthCheckConfig{Type: models.HealthCheckGRPC}
+
+	tests := []struct {
+		name    string
+		backend *models.Backend
+		poolTLS *models.UpstreamTLSConfig
+		healthy bool
+	}{
+		{"HTTP without TLS settings", &models.Backend{URL: httpServer.URL}, nil, false},
+		{"HTTP with pool TLS settings", &models.Backend{URL: httpServer.URL}, mtls, true},
+		{"HTTP backend settings override the pool", &models.Backend{URL: httpServer.URL, TLS: noClientCert}, mtls, false},
+		{"gRPC with backend TLS settings", &models.Backend{URL: "https://" + grpcAddr, HealthCheck: grpcCheck, TLS: mtls}, nil, true},
+		{"gRPC over https without a client certificate", &models.Backend{URL: "https://" + grpcAddr, HealthCheck: grpcCheck}, nil, false},
+	}
+	healthChecker := NewHealthChecker()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := healthChecker.Check(context.Background(), tt.backend, "/health", tt.poolTLS)
+			if result.Healthy != tt.healthy {
+				t.Errorf("Expected healthy=%v, got %+v", tt.healthy, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of probe results kept per backend.
+const DefaultHistorySize = 20
+
+// ProbeResult is the outcome of a single health probe.
+type ProbeResult struct {
+	Time      time.Time `json:"time"`
+	Healthy   bool      `json:"healthy"`
+	Status    string    `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// history is a fixed-size ring buffer of probe results for one backend.
+type history struct {
+	results []ProbeResult
+	next    int
+	full    bool
+}
+
+// add stores a result, overwriting the oldest one when the buffer is full.
+func (h *history) add(r ProbeResult) {
+	h.results[h.next] = r
+	h.next = (h.next + 1) % len(h.results)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the stored results from oldest to newest.
+func (h *history) list() []ProbeResult {
+	if !h.full {
+		return append([]ProbeResult(nil), h.results[:h.next]...)
+	}
+	out := make([]ProbeResult, 0, len(h.results))
+	out = append(out, h.results[h.next:]...)
+	return append(out, h.results[:h.next]...)
+}
+
+// historyStore keeps probe history for all backends keyed by URL.
+type historyStore struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*history
+}
+
+func newHistoryStore(size int) *historyStore {
+	return &historyStore{size: size, entries: make(map[string]*history)}
+}
+
+func (s *historyStore) add(url string, r ProbeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.entries[url]
+	if !ok {
+		h = &history{results: make([]ProbeResult, s.size)}
+		s.entries[url] = h
+	}
+	h.add(r)
+}
+
+func (s *historyStore) list(url string) []ProbeResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.entries[url]
+	if !ok {
+		return []ProbeResult{}
+	}
+	return h.list()
+}
+
+// resize changes the buffer size, keeping the newest results.
+func (s *historyStore) resize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	for url, h := range s.entries {
+		resized := &history{results: make([]ProbeResult, size)}
+		results := h.list()
+		if len(results) > size {
+			results = results[len(results)-size:]
+		}
+		for _, r := range results {
+			resized.add(r)
+		}
+		s.entries[url] = resized
+	}
+}
+
+func (s *historyStore) remove(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, url)
+}
@@ -0,0 +1,260 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// defaultProbeTimeout bounds a single probe when the backend does not configure one.
+const defaultProbeTimeout = 5 * time.Second
+
+// maxExpectRead limits how much of a TCP reply is read when matching Expect.
+const maxExpectRead = 4096
+
+// probe runs the health check configured for the backend and returns nil if it passed.
+// upstreamTLS applies to HTTP and gRPC probes.
+func (hc *HealthChecker) probe(ctx context.Context, backend *models.Backend, defaultPath string, upstreamTLS *models.UpstreamTLSConfig) error {
+	check := backend.HealthCheck
+	if check == nil {
+		check = &models.HealthCheckConfig{}
+	}
+	timeout := check.Timeout.Std()
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	checkType := check.Type
+	if checkType == "" && strings.HasPrefix(backend.URL, "tcp://") {
+		// Backends of TCP listeners do not speak HTTP, a connect is all that can be checked by default
+		checkType = models.HealthCheckTCP
+	}
+	if checkType == "" && strings.HasPrefix(backend.URL, "udp://") {
+		checkType = models.HealthCheckUDP
+	}
+	switch checkType {
+	case "", models.HealthCheckHTTP:
+		path := check.Path
+		if path == "" {
+			path = defaultPath
+		}
+		if socket, ok := models.UnixSocket(backend.URL); ok {
+			return probeHTTP(ctx, hc.unixClient(socket), "http://localhost"+path)
+		}
+		client, err := hc.clientFor(upstreamTLS)
+		if err != nil {
+			return err
+		}
+		return probeHTTP(ctx, client, backend.URL+path)
+	case models.HealthCheckTCP:
+		if socket, ok := models.UnixSocket(backend.URL); ok && check.Address == "" {
+			return probeTCP(ctx, "unix", socket, check.Send, check.Expect)
+		}
+		addr, err := probeAddress(backend.URL, check.Address)
+		if err != nil {
+			return err
+		}
+		return probeTCP(ctx, "tcp", addr, check.Send, check.Expect)
+	case models.HealthCheckUDP:
+		addr, err := probeAddress(backend.URL, check.Address)
+		if err != nil {
+			return err
+		}
+		return probeUDP(ctx, addr, check.Send, check.Expect)
+	case models.HealthCheckGRPC:
+		addr := backend.URL // gRPC dials unix:///path/to.sock targets itself
+		if _, ok := models.UnixSocket(backend.URL); !ok || check.Address != "" {
+			var err error
+			if addr, err = probeAddress(backend.URL, check.Address); err != nil {
+				return err
+			}
+		}
+		creds, err := grpcCredentials(backend.URL, upstreamTLS)
+		if err != nil {
+			return err
+		}
+		return probeGRPC(ctx, addr, check.Service, creds)
+	case models.HealthCheckExec:
+		return probeExec(ctx, backend.URL, check.Command)
+	default:
+		return fmt.Errorf("unknown health check type %q", check.Type)
+	}
+}
+
+// probeHTTP performs a GET request and expects 200 OK.
+func probeHTTP(ctx context.Context, client *http.Client, target string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// probeTCP opens a stream connection ("tcp" or "unix"), optionally writes send and checks
+// that the reply contains expect.
+func probeTCP(ctx context.Context, network, addr, send, expect string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if send != "" {
+		if _, err := io.WriteString(conn, send); err != nil {
+			return fmt.Errorf("failed to send payload: %w", err)
+		}
+	}
+	if expect == "" {
+		return nil
+	}
+
+	buf := make([]byte, maxExpectRead)
+	var received []byte
+	for len(received) < maxExpectRead {
+		n, err := conn.Read(buf)
+		received = append(received, buf[:n]...)
+		if bytes.Contains(received, []byte(expect)) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("expected %q in reply, got %q: %w", expect, received, err)
+		}
+	}
+	return fmt.Errorf("expected %q in reply, got %q", expect, received)
+}
+
+// probeUDP sends send as a single datagram and expects a reply containing expect, or any reply
+// if expect is empty. UDP has no connection, so a reply is the only sign of a working backend;
+// a closed port usually fails at once with "connection refused".
+func probeUDP(ctx context.Context, addr, send, expect string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := io.WriteString(conn, send); err != nil {
+		return fmt.Errorf("failed to send payload: %w", err)
+	}
+	buf := make([]byte, maxExpectRead)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return fmt.Errorf("no reply: %w", err)
+	}
+	if !bytes.Contains(buf[:n], []byte(expect)) {
+		return fmt.Errorf("expected %q in reply, got %q", expect, buf[:n])
+	}
+	return nil
+}
+
+// grpcCredentials returns TLS credentials for https backends and backends with upstream TLS
+// settings, and plaintext otherwise.
+func grpcCredentials(backendURL string, upstreamTLS *models.UpstreamTLSConfig) (credentials.TransportCredentials, error) {
+	if upstreamTLS == nil && !strings.HasPrefix(backendURL, "https://") {
+		return insecure.NewCredentials(), nil
+	}
+	tlsCfg, err := tlsconfig.Client(upstreamTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		tlsCfg = &tls.Config{}
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// probeGRPC calls grpc.health.v1.Health/Check and expects SERVING.
+func probeGRPC(ctx context.Context, addr, service string, creds credentials.TransportCredentials) error {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("gRPC health status %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// probeExec runs a local command and treats exit code 0 as healthy.
+// The backend URL is passed to the command in the BACKEND_URL environment variable.
+func probeExec(ctx context.Context, backendURL string, command []string) error {
+	if len(command) == 0 {
+		return errors.New("exec health check has no command")
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), "BACKEND_URL="+backendURL)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// probeAddress returns the host:port to dial, taken from override or the backend URL.
+func probeAddress(backendURL, override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse backend URL: %w", err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("backend URL %s has no host", backendURL)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	switch u.Scheme {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	default:
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	}
+}
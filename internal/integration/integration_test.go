@@ -50,7 +50,7 @@ func TestIntegration(t *testing.T) {
 	defer cancel()
 
 	// Start health checker
-	healthChecker.Start(ctx, cfg, cfg.HealthCheckInterval)
+	healthChecker.Start(ctx, server.ConfigSnapshot, cfg.HealthCheckInterval)
 
 	// Start server in a goroutine
 	go func() {
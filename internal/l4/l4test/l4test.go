@@ -0,0 +1,82 @@
+// Package l4test запускает TCP- и UDP-бэкенды для тестов L4-проксирования.
+package l4test
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// StartTCPBackend запускает эхо-сервер, который приветствует каждое соединение своим именем,
+// а затем возвращает клиенту все полученные данные, пока тот не закроет свою сторону.
+func StartTCPBackend(t *testing.T, name string) *models.Backend {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte(name + "\n"))
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return &models.Backend{URL: "tcp://" + ln.Addr().String(), Healthy: true}
+}
+
+// StartUDPBackend запускает эхо-сервер, который отвечает на каждую датаграмму строкой
+// "<name>:<датаграмма>" через заданную задержку.
+func StartUDPBackend(t *testing.T, name string, delay time.Duration) *models.Backend {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			reply := []byte(name + ":" + string(buf[:n]))
+			go func() {
+				time.Sleep(delay)
+				conn.WriteTo(reply, addr)
+			}()
+		}
+	}()
+	return &models.Backend{URL: "udp://" + conn.LocalAddr().String(), Healthy: true}
+}
+
+// Exchange отправляет msg и возвращает имя ответившего бэкенда или "", если за время wait
+// ответа на это сообщение не пришло.
+func Exchange(conn net.Conn, msg string, wait time.Duration) string {
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return ""
+	}
+	conn.SetReadDeadline(time.Now().Add(wait))
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return ""
+	}
+	name, payload, _ := strings.Cut(string(buf[:n]), ":")
+	if payload != msg {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,418 @@
+// Package l4 проксирует соединения на транспортном уровне (TCP) к бэкендам пулов
+// без разбора передаваемых данных, например для Postgres или Redis.
+package l4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+
+	"golang.org/x/net/netutil"
+)
+
+// ErrServerClosed возвращает Serve после Shutdown или Close, как http.ErrServerClosed.
+var ErrServerClosed = errors.New("l4: server closed")
+
+// Причины закрытия соединения для журнала.
+var (
+	errIdle   = errors.New("idle timeout")
+	errClosed = errors.New("closed by the balancer")
+)
+
+// Picker выбирает бэкенд для клиента с указанным IP; nil — доступных бэкендов нет.
+type Picker func(clientIP string) *models.Backend
+
+// Limiter решает, принять ли новое соединение клиента (rate limiting).
+type Limiter func(ctx context.Context, clientIP string) bool
+
+// TCPProxy принимает TCP-соединения и соединяет каждое с бэкендом, выбранным Picker.
+type TCPProxy struct {
+	cfg   models.TCPListenerConfig
+	pick  Picker
+	allow Limiter
+
+	mu       sync.Mutex
+	ln       net.Listener
+	closed   bool // Новые соединения не принимаются
+	killed   bool // Открытые соединения закрыты, новые сессии не начинаются
+	sessions map[*tcpSession]struct{}
+	perIP    map[string]int // Открытые соединения по IP клиента
+	wg       sync.WaitGroup
+}
+
+// NewTCPProxy создает TCP-прокси с настройками листенера; allow может быть nil.
+func NewTCPProxy(cfg models.TCPListenerConfig, pick Picker, allow Limiter) *TCPProxy {
+	return &TCPProxy{
+		cfg:      cfg.WithDefaults(),
+		pick:     pick,
+		allow:    allow,
+		sessions: make(map[*tcpSession]struct{}),
+		perIP:    make(map[string]int),
+	}
+}
+
+// Name возвращает имя листенера.
+func (p *TCPProxy) Name() string {
+	return p.cfg.Name
+}
+
+// Serve принимает соединения, пока прокси не остановлен, и возвращает ErrServerClosed
+// после Shutdown или Close.
+func (p *TCPProxy) Serve(ln net.Listener) error {
+	if p.cfg.MaxConnections > 0 {
+		ln = netutil.LimitListener(ln, p.cfg.MaxConnections)
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed
+	}
+	p.ln = ln
+	p.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if p.isClosed() {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		if !p.begin() {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go p.handle(conn)
+	}
+}
+
+func (p *TCPProxy) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// begin учитывает принятое соединение в wg; после остановки — не учитывает, чтобы
+// Shutdown не ждал соединений, принятых одновременно с закрытием листенера.
+func (p *TCPProxy) begin() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.wg.Add(1)
+	return true
+}
+
+// handle проверяет лимиты клиента, выбирает бэкенд и передает данные до закрытия соединения.
+func (p *TCPProxy) handle(conn net.Conn) {
+	defer p.wg.Done()
+	defer conn.Close()
+	clientIP := hostOf(conn.RemoteAddr().String())
+
+	if !p.acquireIP(clientIP) {
+		logger.WarnKV("TCP connection rejected, too many connections from client", "listener", p.cfg.Name, "clientIP", clientIP, "limit", p.cfg.MaxConnectionsPerIP)
+		return
+	}
+	defer p.releaseIP(clientIP)
+	if p.allow != nil && !p.allow(context.Background(), clientIP) {
+		logger.WarnKV("TCP connection rejected due to rate limit", "listener", p.cfg.Name, "clientIP", clientIP)
+		return
+	}
+	backend := p.pick(clientIP)
+	if backend == nil {
+		logger.WarnKV("No healthy backends available for TCP connection", "listener", p.cfg.Name, "pool", p.cfg.Pool, "clientIP", clientIP)
+		return
+	}
+	backend.Acquire()
+	defer backend.Release()
+
+	addr, err := Address(backend.URL)
+	if err != nil {
+		logger.ErrorKV("Invalid TCP backend address", "listener", p.cfg.Name, "backend", backend.URL, "error", err)
+		return
+	}
+	dialer := net.Dialer{Timeout: p.cfg.ConnectTimeout.Std()}
+	upstream, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		logger.WarnKV("Failed to connect to TCP backend", "listener", p.cfg.Name, "backend", backend.URL, "error", err)
+		return
+	}
+
+	s := newTCPSession(conn, upstream, backend.URL, p.cfg.IdleTimeout.Std())
+	if !p.track(s) {
+		// Прокси остановлен, пока устанавливалось соединение с бэкендом
+		upstream.Close()
+		return
+	}
+	defer p.untrack(s)
+	logger.DebugKV("TCP connection opened", "listener", p.cfg.Name, "clientIP", clientIP, "backend", backend.URL)
+	start := time.Now()
+	reason := s.splice()
+	logger.InfoKV("TCP connection closed", "listener", p.cfg.Name, "clientIP", clientIP, "backend", backend.URL,
+		"bytes_sent", s.sent.Load(), "bytes_received", s.received.Load(), "duration", time.Since(start), "reason", reason)
+}
+
+// acquireIP учитывает новое соединение клиента, если лимит на IP не превышен.
+func (p *TCPProxy) acquireIP(ip string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cfg.MaxConnectionsPerIP > 0 && p.perIP[ip] >= p.cfg.MaxConnectionsPerIP {
+		return false
+	}
+	p.perIP[ip]++
+	return true
+}
+
+func (p *TCPProxy) releaseIP(ip string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.perIP[ip]--; p.perIP[ip] <= 0 {
+		delete(p.perIP, ip)
+	}
+}
+
+// track регистрирует сессию; false — прокси уже остановлен.
+func (p *TCPProxy) track(s *tcpSession) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.killed {
+		return false
+	}
+	p.sessions[s] = struct{}{}
+	return true
+}
+
+func (p *TCPProxy) untrack(s *tcpSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sessions, s)
+}
+
+// Connections возвращает число открытых соединений с бэкендом.
+func (p *TCPProxy) Connections(backendURL string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for s := range p.sessions {
+		if s.backendURL == backendURL {
+			n++
+		}
+	}
+	return n
+}
+
+// CloseConnections закрывает соединения с бэкендом, а при пустом backendURL — все,
+// и возвращает их число.
+func (p *TCPProxy) CloseConnections(backendURL string) int {
+	p.mu.Lock()
+	var sessions []*tcpSession
+	for s := range p.sessions {
+		if backendURL == "" || s.backendURL == backendURL {
+			sessions = append(sessions, s)
+		}
+	}
+	p.mu.Unlock()
+	for _, s := range sessions {
+		s.close(errClosed)
+	}
+	return len(sessions)
+}
+
+// Shutdown закрывает листенер и ждет завершения открытых соединений. Если ctx истекает
+// раньше, оставшиеся соединения закрываются, а возвращается ошибка контекста.
+func (p *TCPProxy) Shutdown(ctx context.Context) error {
+	p.stopAccepting()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.closeAll()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close закрывает листенер и все открытые соединения.
+func (p *TCPProxy) Close() error {
+	p.stopAccepting()
+	p.closeAll()
+	p.wg.Wait()
+	return nil
+}
+
+func (p *TCPProxy) closeAll() {
+	p.mu.Lock()
+	p.killed = true
+	p.mu.Unlock()
+	p.CloseConnections("")
+}
+
+func (p *TCPProxy) stopAccepting() {
+	p.mu.Lock()
+	p.closed = true
+	ln := p.ln
+	p.mu.Unlock()
+	if ln != nil {
+		ln.Close()
+	}
+}
+
+// Address возвращает host:port бэкенда из его URL, например tcp://db1:5432.
+func Address(backendURL string) (string, error) {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse backend URL: %w", err)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return "", fmt.Errorf("backend URL %s must have a host and a port", backendURL)
+	}
+	return u.Host, nil
+}
+
+// hostOf возвращает IP из адреса host:port.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// tcpSession — пара соединений клиент–бэкенд. Таймаут простоя общий для обоих направлений:
+// соединение закрывается, только если данных не было ни в одну сторону.
+type tcpSession struct {
+	client, upstream net.Conn
+	backendURL       string
+	idle             time.Duration
+	lastActivity     atomic.Int64 // UnixNano последней передачи данных
+	sent, received   atomic.Int64 // Байты к бэкенду и от него
+	reason           atomic.Pointer[error]
+}
+
+func newTCPSession(client, upstream net.Conn, backendURL string, idle time.Duration) *tcpSession {
+	s := &tcpSession{client: client, upstream: upstream, backendURL: backendURL, idle: idle}
+	s.touch()
+	return s
+}
+
+func (s *tcpSession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// splice копирует данные в обе стороны до закрытия соединения и возвращает причину закрытия.
+// Конец данных с одной стороны передается другой через CloseWrite, чтобы протоколы
+// с полузакрытием соединения работали.
+func (s *tcpSession) splice() string {
+	errs := make(chan error, 2)
+	go func() {
+		errs <- s.copy(s.upstream, s.client, &s.sent)
+	}()
+	go func() {
+		errs <- s.copy(s.client, s.upstream, &s.received)
+	}()
+	var err error
+	for i := 0; i < 2; i++ {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+			// Ошибка в одном направлении прерывает и другое
+			s.client.Close()
+			s.upstream.Close()
+		}
+	}
+	s.client.Close()
+	s.upstream.Close()
+
+	if reason := s.reason.Load(); reason != nil {
+		return (*reason).Error()
+	}
+	if errors.Is(err, errIdle) {
+		return errIdle.Error()
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return "closed by peer"
+}
+
+// copy передает данные из src в dst и закрывает dst на запись, когда src закончился.
+func (s *tcpSession) copy(dst, src net.Conn, counter *atomic.Int64) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.read(src, buf)
+		if n > 0 {
+			if s.idle > 0 {
+				dst.SetWriteDeadline(time.Now().Add(s.idle))
+			}
+			written, werr := dst.Write(buf[:n])
+			counter.Add(int64(written))
+			if werr != nil {
+				return werr
+			}
+			s.touch()
+		}
+		if err == io.EOF {
+			if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+				cw.CloseWrite()
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read читает из conn; по истечении таймаута чтение продолжается, если за это время
+// данные шли в обратную сторону. Дедлайн отсчитывается от последней передачи данных
+// в любую сторону, поэтому простой никогда не длится дольше idle_timeout.
+func (s *tcpSession) read(conn net.Conn, buf []byte) (int, error) {
+	for {
+		if s.idle > 0 {
+			conn.SetReadDeadline(s.idleDeadline())
+		}
+		n, err := conn.Read(buf)
+		if n > 0 {
+			s.touch()
+		}
+		var netErr net.Error
+		if n == 0 && errors.As(err, &netErr) && netErr.Timeout() {
+			if time.Now().Before(s.idleDeadline()) {
+				continue
+			}
+			return 0, errIdle
+		}
+		return n, err
+	}
+}
+
+// idleDeadline возвращает момент закрытия сессии по простою: idle после последней передачи данных.
+func (s *tcpSession) idleDeadline() time.Time {
+	return time.Unix(0, s.lastActivity.Load()).Add(s.idle)
+}
+
+// close прерывает сессию и запоминает причину, если она еще не задана.
+func (s *tcpSession) close(reason error) {
+	s.reason.CompareAndSwap(nil, &reason)
+	s.client.Close()
+	s.upstream.Close()
+}
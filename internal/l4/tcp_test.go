@@ -0,0 +1,246 @@
+package l4
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/l4/l4test"
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// startTCPProxy serves a TCP proxy in front of backends and returns it with its address.
+func startTCPProxy(t *testing.T, cfg models.TCPListenerConfig, strategy string, backends []*models.Backend, allow Limiter) (*TCPProxy, string) {
+	t.Helper()
+	lb := balancer.New(strategy, backends, models.SlowStartConfig{})
+	p := NewTCPProxy(cfg, func(clientIP string) *models.Backend { return balancer.Pick(lb, clientIP) }, allow)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go p.Serve(ln)
+	t.Cleanup(func() { p.Close() })
+	return p, ln.Addr().String()
+}
+
+// dial connects to the proxy and returns the connection with the greeting of its backend.
+func dial(t *testing.T, addr string) (net.Conn, *bufio.Reader, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	r := bufio.NewReader(conn)
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return conn, r, ""
+	}
+	return conn, r, strings.TrimSpace(greeting)
+}
+
+func TestTCPProxy_Splice(t *testing.T) {
+	backends := []*models.Backend{l4test.StartTCPBackend(t, "a"), l4test.StartTCPBackend(t, "b")}
+	p, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db"}, models.StrategyLeastConnections, backends, nil)
+
+	conn, r, first := dial(t, addr)
+	if first == "" {
+		t.Fatal("Expected a greeting from the backend")
+	}
+	conn.Write([]byte("ping\n"))
+	if line, _ := r.ReadString('\n'); line != "ping\n" {
+		t.Fatalf("Expected echo, got %q", line)
+	}
+
+	// Least connections sends the second connection to the other backend
+	_, _, second := dial(t, addr)
+	if second == first || second == "" {
+		t.Errorf("Expected the second connection on the other backend, got %q and %q", first, second)
+	}
+	for _, b := range backends {
+		if n := p.Connections(b.URL); n != 1 || b.InFlight() != 1 {
+			t.Errorf("Expected one connection to %s, got %d (in flight %d)", b.URL, n, b.InFlight())
+		}
+	}
+
+	// Closing the client side is passed on; the backend finishes and the session ends
+	conn.(*net.TCPConn).CloseWrite()
+	if rest, err := io.ReadAll(r); err != nil || len(rest) != 0 {
+		t.Errorf("Expected a clean close after half-close, got %q, %v", rest, err)
+	}
+	if !testutil.WaitFor(func() bool { return backends[0].InFlight()+backends[1].InFlight() == 1 }) {
+		t.Fatal("Timed out waiting for the session to end")
+	}
+
+	if closed := p.CloseConnections(""); closed != 1 {
+		t.Errorf("Expected to close one connection, closed %d", closed)
+	}
+	if !testutil.WaitFor(func() bool { return backends[0].InFlight()+backends[1].InFlight() == 0 }) {
+		t.Fatal("Timed out waiting for connections to close")
+	}
+}
+
+func TestTCPSession_IdleDeadline(t *testing.T) {
+	client, proxySide := net.Pipe()
+	defer client.Close()
+	upstream, backendSide := net.Pipe()
+	defer backendSide.Close()
+	s := newTCPSession(proxySide, upstream, "tcp://backend", 300*time.Millisecond)
+
+	// Data went the other way 200ms ago, so this direction may stay quiet only 100ms more
+	s.lastActivity.Store(time.Now().Add(-200 * time.Millisecond).UnixNano())
+	start := time.Now()
+	_, err := s.read(proxySide, make([]byte, 1))
+	if !errors.Is(err, errIdle) {
+		t.Fatalf("Expected idle timeout, got %v", err)
+	}
+	if waited := time.Since(start); waited > 250*time.Millisecond {
+		t.Errorf("Expected the idle timeout to count from the last activity, waited %v", waited)
+	}
+}
+
+func TestTCPProxy_SourceIPHash(t *testing.T) {
+	backends := []*models.Backend{l4test.StartTCPBackend(t, "a"), l4test.StartTCPBackend(t, "b"), l4test.StartTCPBackend(t, "c")}
+	_, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "cache"}, models.StrategySourceIPHash, backends, nil)
+
+	_, _, first := dial(t, addr)
+	for i := 0; i < 5; i++ {
+		if _, _, got := dial(t, addr); got != first {
+			t.Fatalf("Expected every connection from 127.0.0.1 on %q, got %q", first, got)
+		}
+	}
+}
+
+func TestTCPProxy_Limits(t *testing.T) {
+	backend := l4test.StartTCPBackend(t, "a")
+
+	t.Run("Connections per IP", func(t *testing.T) {
+		_, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db", MaxConnectionsPerIP: 2}, "", []*models.Backend{backend}, nil)
+		conn, _, _ := dial(t, addr)
+		dial(t, addr)
+		if _, _, greeting := dial(t, addr); greeting != "" {
+			t.Fatal("Expected the third connection from the same IP to be closed")
+		}
+		conn.Close()
+		if !testutil.WaitFor(func() bool {
+			_, _, greeting := dial(t, addr)
+			return greeting != ""
+		}) {
+			t.Fatal("Timed out waiting for a free slot")
+		}
+	})
+
+	t.Run("Rate limit per connection", func(t *testing.T) {
+		tokens := 2
+		allow := func(ctx context.Context, clientIP string) bool {
+			tokens--
+			return clientIP == "127.0.0.1" && tokens >= 0
+		}
+		_, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db"}, "", []*models.Backend{backend}, allow)
+		for i := 0; i < 3; i++ {
+			_, _, greeting := dial(t, addr)
+			if allowed := greeting != ""; allowed != (i < 2) {
+				t.Errorf("Connection %d: expected allowed=%v", i, i < 2)
+			}
+		}
+	})
+
+	t.Run("Idle timeout", func(t *testing.T) {
+		_, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db", IdleTimeout: models.Duration(150 * time.Millisecond)}, "", []*models.Backend{backend}, nil)
+		conn, r, _ := dial(t, addr)
+		// Traffic keeps the connection open past the timeout
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			conn.Write([]byte("ping\n"))
+			if line, err := r.ReadString('\n'); err != nil || line != "ping\n" {
+				t.Fatalf("Echo %d failed: %q, %v", i, line, err)
+			}
+		}
+		start := time.Now()
+		if _, err := r.ReadString('\n'); !errors.Is(err, io.EOF) {
+			t.Fatalf("Expected the idle connection to be closed, got %v", err)
+		}
+		if waited := time.Since(start); waited < 100*time.Millisecond {
+			t.Errorf("Connection closed after %v, before the idle timeout", waited)
+		}
+	})
+
+	t.Run("No healthy backends", func(t *testing.T) {
+		down := &models.Backend{URL: backend.URL}
+		_, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db"}, "", []*models.Backend{down}, nil)
+		if _, _, greeting := dial(t, addr); greeting != "" {
+			t.Error("Expected the connection to be closed without a healthy backend")
+		}
+	})
+}
+
+func TestTCPProxy_Shutdown(t *testing.T) {
+	backend := l4test.StartTCPBackend(t, "a")
+
+	t.Run("Waits for open connections", func(t *testing.T) {
+		p, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db"}, "", []*models.Backend{backend}, nil)
+		conn, r, _ := dial(t, addr)
+		done := make(chan error, 1)
+		go func() { done <- p.Shutdown(context.Background()) }()
+
+		if !testutil.WaitFor(func() bool {
+			c, err := net.Dial("tcp", addr)
+			if err == nil {
+				c.Close()
+			}
+			return err != nil
+		}) {
+			t.Fatal("Timed out waiting for the listener to close")
+		}
+		// The open connection keeps working while the proxy drains
+		conn.Write([]byte("ping\n"))
+		if line, err := r.ReadString('\n'); err != nil || line != "ping\n" {
+			t.Fatalf("Echo during drain failed: %q, %v", line, err)
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("Shutdown returned with an open connection: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		conn.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Expected clean shutdown, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Shutdown did not return after the connection closed")
+		}
+	})
+
+	t.Run("Closes connections at the deadline", func(t *testing.T) {
+		p, addr := startTCPProxy(t, models.TCPListenerConfig{Name: "db"}, "", []*models.Backend{backend}, nil)
+		_, r, _ := dial(t, addr)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if _, err := r.ReadString('\n'); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected the connection to be closed, got %v", err)
+		}
+		if backend.InFlight() != 0 {
+			t.Errorf("Expected no connections in flight, got %d", backend.InFlight())
+		}
+	})
+}
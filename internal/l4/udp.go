@@ -0,0 +1,334 @@
+package l4
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+// maxDatagramSize — наибольший размер UDP-датаграммы.
+const maxDatagramSize = 64 * 1024
+
+// shutdownQuiet — сколько сессия ждет ответов бэкенда после остановки прокси: новые пакеты
+// клиентов уже не принимаются, и ждать полный session_timeout незачем.
+const shutdownQuiet = time.Second
+
+// errExpired — причина закрытия сессии без пакетов в течение session_timeout.
+var errExpired = errors.New("session timeout")
+
+// UDPProxy принимает датаграммы и пересылает их бэкендам, выбранным Picker. Датаграммы
+// одного адреса клиента образуют сессию: они идут на один бэкенд через отдельный сокет,
+// а ответы бэкенда отправляются клиенту с адреса листенера.
+type UDPProxy struct {
+	cfg   models.UDPListenerConfig
+	pick  Picker
+	allow Limiter
+
+	mu       sync.Mutex
+	conn     net.PacketConn
+	closed   bool                   // Новые пакеты клиентов не принимаются и сессии не создаются
+	sessions map[string]*udpSession // По адресу клиента
+	wg       sync.WaitGroup
+}
+
+// NewUDPProxy создает прокси для листенера cfg. allow может быть nil — тогда пакеты не ограничиваются.
+func NewUDPProxy(cfg models.UDPListenerConfig, pick Picker, allow Limiter) *UDPProxy {
+	return &UDPProxy{
+		cfg:      cfg.WithDefaults(),
+		pick:     pick,
+		allow:    allow,
+		sessions: make(map[string]*udpSession),
+	}
+}
+
+// Name возвращает имя листенера.
+func (p *UDPProxy) Name() string {
+	return p.cfg.Name
+}
+
+// Serve читает датаграммы клиентов, пока прокси не остановлен, и возвращает ErrServerClosed
+// после Shutdown или Close. Сокет закрывается прокси.
+func (p *UDPProxy) Serve(conn net.PacketConn) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		conn.Close()
+		return ErrServerClosed
+	}
+	p.conn = conn
+	p.mu.Unlock()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if p.isClosed() {
+			return ErrServerClosed
+		}
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		p.forward(addr, buf[:n])
+	}
+}
+
+func (p *UDPProxy) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// forward проверяет лимит клиента и отправляет датаграмму бэкенду его сессии.
+func (p *UDPProxy) forward(addr net.Addr, data []byte) {
+	clientIP := hostOf(addr.String())
+	if p.allow != nil && !p.allow(context.Background(), clientIP) {
+		// Отказ не логируется на уровне Warn: при флуде это была бы строка на каждый пакет
+		logger.DebugKV("UDP packet dropped due to rate limit", "listener", p.cfg.Name, "clientIP", clientIP)
+		return
+	}
+	s := p.session(addr, clientIP)
+	if s == nil {
+		return
+	}
+	if _, err := s.upstream.Write(data); err != nil {
+		logger.DebugKV("Failed to send UDP packet to backend", "listener", p.cfg.Name, "backend", s.backend.URL, "error", err)
+		return
+	}
+	s.sent.Add(1)
+	s.touch()
+}
+
+// session возвращает сессию клиента, создавая ее для первого пакета; nil — пакет отбрасывается.
+func (p *UDPProxy) session(addr net.Addr, clientIP string) *udpSession {
+	key := addr.String()
+	p.mu.Lock()
+	if s, ok := p.sessions[key]; ok {
+		// Отметка под блокировкой, чтобы relay не завершил сессию, в которую сейчас пойдет пакет
+		s.touch()
+		p.mu.Unlock()
+		return s
+	}
+	full := p.cfg.MaxSessions > 0 && len(p.sessions) >= p.cfg.MaxSessions
+	p.mu.Unlock()
+	if full {
+		logger.WarnKV("UDP packet dropped, too many sessions", "listener", p.cfg.Name, "clientIP", clientIP, "limit", p.cfg.MaxSessions)
+		return nil
+	}
+
+	backend := p.pick(clientIP)
+	if backend == nil {
+		logger.WarnKV("No healthy backends available for UDP session", "listener", p.cfg.Name, "pool", p.cfg.Pool, "clientIP", clientIP)
+		return nil
+	}
+	address, err := Address(backend.URL)
+	if err != nil {
+		logger.ErrorKV("Invalid UDP backend address", "listener", p.cfg.Name, "backend", backend.URL, "error", err)
+		return nil
+	}
+	upstream, err := net.Dial("udp", address)
+	if err != nil {
+		logger.WarnKV("Failed to connect to UDP backend", "listener", p.cfg.Name, "backend", backend.URL, "error", err)
+		return nil
+	}
+
+	s := &udpSession{client: addr, upstream: upstream, backend: backend, start: time.Now()}
+	s.touch()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		upstream.Close()
+		return nil
+	}
+	p.sessions[key] = s
+	p.wg.Add(1)
+	p.mu.Unlock()
+	backend.Acquire()
+	logger.DebugKV("UDP session opened", "listener", p.cfg.Name, "client", key, "backend", backend.URL)
+	go p.relay(s)
+	return s
+}
+
+// relay отправляет клиенту ответы бэкенда, пока сессия не простаивает дольше session_timeout.
+func (p *UDPProxy) relay(s *udpSession) {
+	defer p.wg.Done()
+	buf := make([]byte, maxDatagramSize)
+	var err error
+	for {
+		remaining := p.expire(s)
+		if remaining <= 0 {
+			err = errExpired
+			break
+		}
+		s.upstream.SetReadDeadline(time.Now().Add(remaining))
+		var n int
+		n, err = s.upstream.Read(buf)
+		if n > 0 || err == nil {
+			s.received.Add(1)
+			s.touch()
+			if _, werr := p.conn.WriteTo(buf[:n], s.client); werr != nil {
+				err = werr
+				break
+			}
+			continue
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			// Срок пересчитывается: клиент мог присылать пакеты, пока ответов не было
+			continue
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			// Бэкенд отверг пакет (ICMP port unreachable); сессия остается до таймаута
+			continue
+		}
+		break
+	}
+
+	p.untrack(s)
+	s.upstream.Close()
+	s.backend.Release()
+	if reason := s.reason.Load(); reason != nil {
+		err = *reason
+	}
+	logger.DebugKV("UDP session closed", "listener", p.cfg.Name, "client", s.client.String(), "backend", s.backend.URL,
+		"packets_sent", s.sent.Load(), "packets_received", s.received.Load(), "duration", time.Since(s.start), "reason", err.Error())
+}
+
+// expire возвращает, сколько сессии осталось до истечения таймаута, а истекшую сессию
+// удаляет из таблицы, чтобы следующий пакет клиента открыл новую.
+func (p *UDPProxy) expire(s *udpSession) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	timeout := p.cfg.SessionTimeout.Std()
+	if p.closed && timeout > shutdownQuiet {
+		timeout = shutdownQuiet
+	}
+	remaining := timeout - time.Since(time.Unix(0, s.lastActivity.Load()))
+	if remaining <= 0 && p.sessions[s.client.String()] == s {
+		delete(p.sessions, s.client.String())
+	}
+	return remaining
+}
+
+func (p *UDPProxy) untrack(s *udpSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sessions[s.client.String()] == s {
+		delete(p.sessions, s.client.String())
+	}
+}
+
+// Sessions возвращает число сессий с бэкендом.
+func (p *UDPProxy) Sessions(backendURL string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, s := range p.sessions {
+		if s.backend.URL == backendURL {
+			n++
+		}
+	}
+	return n
+}
+
+// CloseSessions закрывает сессии с бэкендом, а при пустом backendURL — все, и возвращает
+// их число. Следующий пакет клиента откроет новую сессию с другим бэкендом.
+func (p *UDPProxy) CloseSessions(backendURL string) int {
+	p.mu.Lock()
+	var sessions []*udpSession
+	for key, s := range p.sessions {
+		if backendURL == "" || s.backend.URL == backendURL {
+			sessions = append(sessions, s)
+			delete(p.sessions, key)
+		}
+	}
+	p.mu.Unlock()
+	for _, s := range sessions {
+		s.close(errClosed)
+	}
+	return len(sessions)
+}
+
+// Shutdown прекращает прием пакетов клиентов и ждет, пока сессии получат последние ответы
+// бэкендов (до shutdownQuiet тишины). Если ctx истекает раньше, сессии закрываются,
+// а возвращается ошибка контекста.
+func (p *UDPProxy) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	conn := p.conn
+	sessions := make([]*udpSession, 0, len(p.sessions))
+	for _, s := range p.sessions {
+		sessions = append(sessions, s)
+	}
+	p.mu.Unlock()
+	if conn != nil {
+		// Сокет нужен для ответов клиентам, поэтому чтение прерывается дедлайном, а не закрытием
+		conn.SetReadDeadline(time.Now())
+	}
+	for _, s := range sessions {
+		// Сессии пересчитывают таймаут с учетом остановки
+		s.upstream.SetReadDeadline(time.Now())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		p.CloseSessions("")
+		<-done
+		err = ctx.Err()
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	return err
+}
+
+// Close закрывает сокет листенера и все сессии.
+func (p *UDPProxy) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	conn := p.conn
+	p.mu.Unlock()
+	p.CloseSessions("")
+	p.wg.Wait()
+	if conn != nil {
+		conn.Close()
+	}
+	return nil
+}
+
+// udpSession — датаграммы одного адреса клиента и сокет к выбранному для них бэкенду.
+type udpSession struct {
+	client         net.Addr
+	upstream       net.Conn
+	backend        *models.Backend
+	start          time.Time
+	lastActivity   atomic.Int64 // UnixNano последнего пакета в любую сторону
+	sent, received atomic.Int64 // Пакеты к бэкенду и от него
+	reason         atomic.Pointer[error]
+}
+
+func (s *udpSession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// close прерывает сессию и запоминает причину, если она еще не задана.
+func (s *udpSession) close(reason error) {
+	s.reason.CompareAndSwap(nil, &reason)
+	s.upstream.Close()
+}
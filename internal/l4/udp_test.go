@@ -0,0 +1,189 @@
+package l4
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"load-balancer/internal/balancer"
+	"load-balancer/internal/l4/l4test"
+	"load-balancer/internal/models"
+	"load-balancer/internal/testutil"
+)
+
+// startUDPProxy serves a UDP proxy in front of backends and returns it with its address.
+func startUDPProxy(t *testing.T, cfg models.UDPListenerConfig, strategy string, backends []*models.Backend, allow Limiter) (*UDPProxy, string) {
+	t.Helper()
+	lb := balancer.New(strategy, backends, models.SlowStartConfig{})
+	p := NewUDPProxy(cfg, func(clientIP string) *models.Backend { return balancer.Pick(lb, clientIP) }, allow)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go p.Serve(conn)
+	t.Cleanup(func() { p.Close() })
+	return p, conn.LocalAddr().String()
+}
+
+// udpClient opens a UDP socket to the proxy; every client has its own source port.
+func udpClient(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestUDPProxy_Relay(t *testing.T) {
+	backends := []*models.Backend{l4test.StartUDPBackend(t, "a", 0), l4test.StartUDPBackend(t, "b", 0)}
+	p, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "dns"}, models.StrategyLeastConnections, backends, nil)
+
+	client1 := udpClient(t, addr)
+	first := l4test.Exchange(client1, "query1", time.Second)
+	if first == "" {
+		t.Fatal("Expected a reply from a backend")
+	}
+	// Datagrams of one client stay in its session
+	for i := 0; i < 3; i++ {
+		if got := l4test.Exchange(client1, "again", time.Second); got != first {
+			t.Fatalf("Expected the session to stay on %q, got %q", first, got)
+		}
+	}
+
+	// Least connections counts sessions, so the second client gets the other backend
+	client2 := udpClient(t, addr)
+	if second := l4test.Exchange(client2, "query2", time.Second); second == first || second == "" {
+		t.Errorf("Expected the second client on the other backend, got %q and %q", first, second)
+	}
+	for _, b := range backends {
+		if n := p.Sessions(b.URL); n != 1 || b.InFlight() != 1 {
+			t.Errorf("Expected one session with %s, got %d (in flight %d)", b.URL, n, b.InFlight())
+		}
+	}
+
+	if closed := p.CloseSessions(""); closed != 2 {
+		t.Errorf("Expected to close two sessions, closed %d", closed)
+	}
+	if !testutil.WaitFor(func() bool { return backends[0].InFlight()+backends[1].InFlight() == 0 }) {
+		t.Fatal("Timed out waiting for sessions to close")
+	}
+	// The next datagram opens a new session
+	if got := l4test.Exchange(client1, "after close", time.Second); got == "" {
+		t.Error("Expected a reply in a new session")
+	}
+}
+
+func TestUDPProxy_SessionTimeout(t *testing.T) {
+	backend := l4test.StartUDPBackend(t, "a", 0)
+	p, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "syslog", SessionTimeout: models.Duration(150 * time.Millisecond)}, "", []*models.Backend{backend}, nil)
+
+	client := udpClient(t, addr)
+	// Traffic keeps the session open past the timeout
+	for i := 0; i < 3; i++ {
+		if l4test.Exchange(client, "ping", time.Second) == "" {
+			t.Fatalf("Datagram %d: no reply", i)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if p.Sessions(backend.URL) != 1 {
+		t.Fatalf("Expected the active session to stay open, got %d", p.Sessions(backend.URL))
+	}
+	if !testutil.WaitFor(func() bool { return p.Sessions(backend.URL) == 0 && backend.InFlight() == 0 }) {
+		t.Fatal("Timed out waiting for the idle session to expire")
+	}
+	if l4test.Exchange(client, "ping", time.Second) == "" {
+		t.Error("Expected a reply after the session expired")
+	}
+}
+
+func TestUDPProxy_Limits(t *testing.T) {
+	backend := l4test.StartUDPBackend(t, "a", 0)
+
+	t.Run("Rate limit per packet", func(t *testing.T) {
+		tokens := 2
+		allow := func(ctx context.Context, clientIP string) bool {
+			tokens--
+			return clientIP == "127.0.0.1" && tokens >= 0
+		}
+		_, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "dns"}, "", []*models.Backend{backend}, allow)
+		client := udpClient(t, addr)
+		for i := 0; i < 3; i++ {
+			replied := l4test.Exchange(client, "ping", 200*time.Millisecond) != ""
+			if replied != (i < 2) {
+				t.Errorf("Datagram %d: expected reply=%v", i, i < 2)
+			}
+		}
+	})
+
+	t.Run("Max sessions", func(t *testing.T) {
+		_, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "dns", MaxSessions: 1}, "", []*models.Backend{backend}, nil)
+		if l4test.Exchange(udpClient(t, addr), "ping", time.Second) == "" {
+			t.Fatal("Expected a reply in the first session")
+		}
+		if l4test.Exchange(udpClient(t, addr), "ping", 200*time.Millisecond) != "" {
+			t.Error("Expected the datagram of a new client to be dropped")
+		}
+	})
+
+	t.Run("No healthy backends", func(t *testing.T) {
+		down := &models.Backend{URL: backend.URL}
+		_, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "dns"}, "", []*models.Backend{down}, nil)
+		if l4test.Exchange(udpClient(t, addr), "ping", 200*time.Millisecond) != "" {
+			t.Error("Expected the datagram to be dropped without a healthy backend")
+		}
+	})
+}
+
+func TestUDPProxy_Shutdown(t *testing.T) {
+	t.Run("Relays pending replies", func(t *testing.T) {
+		backend := l4test.StartUDPBackend(t, "slow", 200*time.Millisecond)
+		p, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "dns"}, "", []*models.Backend{backend}, nil)
+		client := udpClient(t, addr)
+		client.Write([]byte("query"))
+		if !testutil.WaitFor(func() bool { return p.Sessions(backend.URL) == 1 }) {
+			t.Fatal("Timed out waiting for the session to open")
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- p.Shutdown(context.Background()) }()
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 1500)
+		if n, err := client.Read(buf); err != nil || string(buf[:n]) != "slow:query" {
+			t.Fatalf("Expected the reply during shutdown, got %q, %v", buf[:n], err)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Expected clean shutdown, got %v", err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Shutdown did not return after the session went quiet")
+		}
+		if backend.InFlight() != 0 {
+			t.Errorf("Expected no sessions in flight, got %d", backend.InFlight())
+		}
+	})
+
+	t.Run("Closes sessions at the deadline", func(t *testing.T) {
+		backend := l4test.StartUDPBackend(t, "a", 0)
+		p, addr := startUDPProxy(t, models.UDPListenerConfig{Name: "dns"}, "", []*models.Backend{backend}, nil)
+		if l4test.Exchange(udpClient(t, addr), "ping", time.Second) == "" {
+			t.Fatal("Expected a reply")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if p.Sessions(backend.URL) != 0 || backend.InFlight() != 0 {
+			t.Errorf("Expected all sessions closed, got %d", p.Sessions(backend.URL))
+		}
+		if l4test.Exchange(udpClient(t, addr), "ping", 200*time.Millisecond) != "" {
+			t.Error("Expected no replies after shutdown")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// Logger — логгер, привязанный к контексту запроса: его поля (например, request_id)
+// добавляются к каждой записи.
+type Logger struct {
+	l *zap.Logger
+}
+
+// WithContext возвращает контекст с логгером, к записям которого добавлены поля kv.
+// Поля дописываются к полям логгера, уже сохраненного в ctx.
+func WithContext(ctx context.Context, kv ...any) context.Context {
+	l := FromContext(ctx).zap().With(parseKV(kv...)...)
+	return context.WithValue(ctx, ctxKey{}, &Logger{l: l})
+}
+
+// FromContext возвращает логгер из контекста, а если его нет — глобальный логгер.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return &Logger{}
+}
+
+// zap возвращает логгер с полями контекста; глобальный берется в момент записи,
+// чтобы учитывать SetLogger.
+func (l *Logger) zap() *zap.Logger {
+	if l == nil || l.l == nil {
+		return ensureLogger()
+	}
+	return l.l
+}
+
+func (l *Logger) ErrorKV(msg string, kv ...any) {
+	l.zap().Error(msg, parseKV(kv...)...)
+}
+
+func (l *Logger) WarnKV(msg string, kv ...any) {
+	l.zap().Warn(msg, parseKV(kv...)...)
+}
+
+func (l *Logger) InfoKV(msg string, kv ...any) {
+	l.zap().Info(msg, parseKV(kv...)...)
+}
+
+func (l *Logger) DebugKV(msg string, kv ...any) {
+	l.zap().Debug(msg, parseKV(kv...)...)
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func observe(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zap.DebugLevel)
+	prev := logger.Load()
+	SetLogger(zap.New(core))
+	t.Cleanup(func() { SetLogger(prev) })
+	return logs
+}
+
+func TestInfoKV_AllPairs(t *testing.T) {
+	logs := observe(t)
+	InfoKV("message", "a", 1, "b", 2, "c", 3)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	for _, k := range []string{"a", "b", "c"} {
+		if _, ok := fields[k]; !ok {
+			t.Errorf("Expected field %q, got %v", k, fields)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	logs := observe(t)
+
+	FromContext(context.Background()).InfoKV("global", "k", "v")
+	ctx := WithContext(context.Background(), "request_id", "req-1")
+	ctx = WithContext(ctx, "route", "shop")
+	FromContext(ctx).WarnKV("scoped", "k", "v")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if fields := entries[0].ContextMap(); len(fields) != 1 {
+		t.Errorf("Expected only the call fields without a context logger, got %v", fields)
+	}
+	fields := entries[1].ContextMap()
+	if fields["request_id"] != "req-1" || fields["route"] != "shop" || fields["k"] != "v" {
+		t.Errorf("Expected context and call fields, got %v", fields)
+	}
+}
@@ -3,12 +3,15 @@ package logger
 import (
 	"fmt"
 	"os"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var logger *zap.Logger
+// logger — глобальный логгер; атомарный, потому что Init и SetLogger могут вызываться,
+// пока другие горутины пишут в лог (например, в тестах).
+var logger atomic.Pointer[zap.Logger]
 
 func Init() {
 	var err error
@@ -35,113 +38,96 @@ func Init() {
 	if err != nil {
 		panic(err)
 	}
-	logger = _logger
+	logger.Store(_logger)
 }
 
 // Проверка инициализации логгера
-func ensureLogger() {
-	if logger == nil {
-		Init()
+func ensureLogger() *zap.Logger {
+	if l := logger.Load(); l != nil {
+		return l
 	}
+	Init()
+	return logger.Load()
 }
 
 func Fatal(msg string) {
-	ensureLogger()
-	logger.Fatal(msg)
+	ensureLogger().Fatal(msg)
 }
 
 func Fatalf(msg string, args ...any) {
-	ensureLogger()
-	logger.Fatal(fmt.Sprintf(msg, args...))
+	ensureLogger().Fatal(fmt.Sprintf(msg, args...))
 }
 
 func FatalKV(msg string, kv ...any) {
-	ensureLogger()
-	logger.Fatal(msg, parseKV(kv...)...)
+	ensureLogger().Fatal(msg, parseKV(kv...)...)
 }
 
 func Panic(msg string) {
-	ensureLogger()
-	logger.Panic(msg)
+	ensureLogger().Panic(msg)
 }
 
 func Panicf(msg string, args ...any) {
-	ensureLogger()
-	logger.Panic(fmt.Sprintf(msg, args...))
+	ensureLogger().Panic(fmt.Sprintf(msg, args...))
 }
 
 func PanicKV(msg string, kv ...any) {
-	ensureLogger()
-	logger.Panic(msg, parseKV(kv...)...)
+	ensureLogger().Panic(msg, parseKV(kv...)...)
 }
 
 func Error(msg string) {
-	ensureLogger()
-	logger.Error(msg)
+	ensureLogger().Error(msg)
 }
 
 func Errorf(msg string, args ...any) {
-	ensureLogger()
-	logger.Error(fmt.Sprintf(msg, args...))
+	ensureLogger().Error(fmt.Sprintf(msg, args...))
 }
 
 func ErrorKV(msg string, kv ...any) {
-	ensureLogger()
-	logger.Error(msg, parseKV(kv...)...)
+	ensureLogger().Error(msg, parseKV(kv...)...)
 }
 
 func Warn(msg string) {
-	ensureLogger()
-	logger.Warn(msg)
+	ensureLogger().Warn(msg)
 }
 
 func Warnf(msg string, args ...any) {
-	ensureLogger()
-	logger.Warn(fmt.Sprintf(msg, args...))
+	ensureLogger().Warn(fmt.Sprintf(msg, args...))
 }
 
 func WarnKV(msg string, kv ...any) {
-	ensureLogger()
-	logger.Warn(msg, parseKV(kv...)...)
+	ensureLogger().Warn(msg, parseKV(kv...)...)
 }
 
 func Info(msg string) {
-	ensureLogger()
-	logger.Info(msg)
+	ensureLogger().Info(msg)
 }
 
 func Infof(msg string, args ...any) {
-	ensureLogger()
-	logger.Info(fmt.Sprintf(msg, args...))
+	ensureLogger().Info(fmt.Sprintf(msg, args...))
 }
 
 func InfoKV(msg string, kv ...any) {
-	ensureLogger()
-	logger.Info(msg, parseKV(kv...)...)
+	ensureLogger().Info(msg, parseKV(kv...)...)
 }
 
 func Debug(msg string) {
-	ensureLogger()
-	logger.Debug(msg)
+	ensureLogger().Debug(msg)
 }
 
 func Debugf(msg string, args ...any) {
-	ensureLogger()
-	logger.Debug(fmt.Sprintf(msg, args...))
+	ensureLogger().Debug(fmt.Sprintf(msg, args...))
 }
 
 func DebugKV(msg string, kv ...any) {
-	ensureLogger()
-	logger.Debug(msg, parseKV(kv...)...)
+	ensureLogger().Debug(msg, parseKV(kv...)...)
 }
 
 func parseKV(kv ...any) []zap.Field {
 	if len(kv)%2 != 0 {
 		Panic("kv must be pairs")
 	}
-	kvs := len(kv) / 2
-	fields := make([]zap.Field, 0, kvs)
-	for i := 0; i < kvs; i += 2 {
+	fields := make([]zap.Field, 0, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
 		k, ok := kv[i].(string)
 		if !ok {
 			Panic("kv key must be string")
@@ -172,5 +158,5 @@ func NewTestLogger() (*zap.Logger, error) {
 }
 
 func SetLogger(l *zap.Logger) {
-	logger = l
+	logger.Store(l)
 }
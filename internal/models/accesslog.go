@@ -0,0 +1,30 @@
+package models
+
+// Access log formats supported by AccessLogConfig.Format.
+const (
+	AccessLogJSON     = "json"     // One JSON object per line
+	AccessLogCombined = "combined" // Apache combined log format
+	AccessLogTemplate = "template" // AccessLogConfig.Template with {placeholders}
+)
+
+// Access log outputs supported by AccessLogConfig.Output.
+const (
+	AccessLogStdout = "stdout"
+	AccessLogFile   = "file"
+	AccessLogSyslog = "syslog"
+)
+
+// AccessLogConfig controls the access log: one record per completed request,
+// written separately from the application log.
+type AccessLogConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Format        string `json:"format,omitempty"`         // json (default), combined or template
+	Template      string `json:"template,omitempty"`       // Line for the template format, e.g. "{client_ip} {status} {latency_ms}"
+	Output        string `json:"output,omitempty"`         // stdout (default), file or syslog
+	Path          string `json:"path,omitempty"`           // Log file of the file output
+	MaxSizeMB     int    `json:"max_size_mb,omitempty"`    // File size that triggers rotation, 100 by default
+	MaxBackups    int    `json:"max_backups,omitempty"`    // Rotated files to keep, 5 by default
+	SyslogNetwork string `json:"syslog_network,omitempty"` // udp, tcp or empty for the local syslog daemon
+	SyslogAddress string `json:"syslog_address,omitempty"` // host:port of a remote syslog server
+	SyslogTag     string `json:"syslog_tag,omitempty"`     // "load-balancer" by default
+}
@@ -1,11 +1,140 @@
 package models
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Health check types supported by HealthCheckConfig.Type.
+const (
+	HealthCheckHTTP = "http"
+	HealthCheckTCP  = "tcp"
+	HealthCheckUDP  = "udp"
+	HealthCheckGRPC = "grpc"
+	HealthCheckExec = "exec"
+)
+
+// Backend states set through the admin API. An empty state means active.
+const (
+	BackendActive      = "active"
+	BackendDraining    = "draining"
+	BackendMaintenance = "maintenance"
+)
+
+// Host header choices for Backend.HostHeader. An empty value means client.
+const (
+	HostHeaderClient  = "client"  // Keep the Host the client sent
+	HostHeaderBackend = "backend" // Use the host of the backend URL
+)
+
+// Upstream protocols for Backend.Protocol. An empty value means HTTP/1.1 to http backends
+// and HTTP/2 negotiated by ALPN, falling back to HTTP/1.1, to https backends.
+const (
+	ProtocolHTTP1 = "http1" // Always HTTP/1.1, also over TLS
+	ProtocolH2C   = "h2c"   // HTTP/2 over plaintext with prior knowledge, e.g. gRPC servers without TLS
+)
+
+// HealthCheckConfig describes how a single backend is probed.
+// A nil config means an HTTP GET to Config.HealthCheckPath.
+type HealthCheckConfig struct {
+	Type    string   `json:"type,omitempty"`                         // http (default), tcp, udp, grpc or exec
+	Path    string   `json:"path,omitempty"`                         // HTTP path, overrides Config.HealthCheckPath
+	Address string   `json:"address,omitempty"`                      // host:port for tcp, udp and grpc checks, defaults to the backend URL host
+	Send    string   `json:"send,omitempty"`                         // TCP payload written after connect, or the UDP datagram
+	Expect  string   `json:"expect,omitempty"`                       // substring expected in the TCP or UDP reply
+	Service string   `json:"service,omitempty"`                      // service name for grpc.health.v1.Health/Check
+	Command []string `json:"command,omitempty"`                      // command and arguments for exec checks
+	Timeout Duration `json:"timeout,omitempty" swaggertype:"string"` // per-probe timeout, 5s by default
+}
 
 // Backend represents a backend server.
 type Backend struct {
+	ID            string // Stable identifier used by the admin API, e.g. "backend1"
 	URL           string
 	Healthy       bool
 	LastChecked   time.Time
-	LoggedHealthy bool // Tracks if healthy status was logged
+	LoggedHealthy bool               // Tracks if healthy status was logged
+	HealthCheck   *HealthCheckConfig // Optional probe settings, HTTP GET when nil
+	Weight        int                // Relative share of traffic for weighted strategies, 1 when zero
+	HealthySince  time.Time          // When the backend last became healthy, starts the slow-start window
+	HostHeader    string             // client (default) or backend, the Host header sent to the backend
+	TLS           *UpstreamTLSConfig // Upstream TLS settings, override those of the pool
+	Protocol      string             // http1, h2c or empty for the default, overrides that of the pool
+
+	inFlight atomic.Pointer[atomic.Int64] // Requests currently being proxied, shared with copies made by Copy
+	state    atomic.Pointer[string]       // active (default), draining or maintenance
+}
+
+// Available reports whether the backend may receive new requests.
+func (b *Backend) Available() bool {
+	state := b.State()
+	return b.Healthy && (state == "" || state == BackendActive)
+}
+
+// State returns the state set through the admin API: active (default), draining or maintenance.
+func (b *Backend) State() string {
+	if state := b.state.Load(); state != nil {
+		return *state
+	}
+	return ""
+}
+
+// SetState changes the state of the backend. Balancers read it on every pick without the
+// server lock, so it is stored atomically.
+func (b *Backend) SetState(state string) {
+	b.state.Store(&state)
+}
+
+// Copy returns a new backend with the same settings, health status and state that shares the
+// in-flight count of b, so requests still running on b are counted by the copy. Settings are
+// changed on a copy that replaces b, since balancers read them without the server lock.
+func (b *Backend) Copy() *Backend {
+	c := &Backend{
+		ID:            b.ID,
+		URL:           b.URL,
+		Healthy:       b.Healthy,
+		LastChecked:   b.LastChecked,
+		LoggedHealthy: b.LoggedHealthy,
+		HealthCheck:   b.HealthCheck,
+		Weight:        b.Weight,
+		HealthySince:  b.HealthySince,
+		HostHeader:    b.HostHeader,
+		TLS:           b.TLS,
+		Protocol:      b.Protocol,
+	}
+	c.inFlight.Store(b.counter())
+	c.SetState(b.State())
+	return c
+}
+
+// counter returns the in-flight counter, creating it on first use.
+func (b *Backend) counter() *atomic.Int64 {
+	if c := b.inFlight.Load(); c != nil {
+		return c
+	}
+	b.inFlight.CompareAndSwap(nil, new(atomic.Int64))
+	return b.inFlight.Load()
+}
+
+// Acquire marks the start of a request proxied to the backend.
+func (b *Backend) Acquire() {
+	b.counter().Add(1)
+}
+
+// Release marks the end of a request started with Acquire.
+func (b *Backend) Release() {
+	b.counter().Add(-1)
+}
+
+// InFlight returns the number of requests currently proxied to the backend.
+func (b *Backend) InFlight() int64 {
+	return b.counter().Load()
+}
+
+// UpstreamProtocol returns the protocol of the backend, falling back to that of its pool.
+func (b *Backend) UpstreamProtocol(pool string) string {
+	if b.Protocol != "" {
+		return b.Protocol
+	}
+	return pool
 }
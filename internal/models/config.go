@@ -15,12 +15,58 @@ type ClientConfig struct {
 	Rate     float64 `json:"rate" mapstructure:"rate"`
 }
 
+// Balancing strategies supported by Config.Strategy.
+const (
+	StrategyRoundRobin         = "round_robin"
+	StrategyWeightedRoundRobin = "weighted_round_robin"
+	StrategyLeastConnections   = "least_connections"
+	StrategySourceIPHash       = "source_ip_hash" // The same client IP goes to the same backend while it is available
+)
+
+// SlowStartConfig controls how a recovered or newly added backend ramps up to its full weight.
+type SlowStartConfig struct {
+	Window           Duration `json:"window,omitempty" swaggertype:"string"` // Ramp-up duration, disabled when zero
+	Aggression       float64  `json:"aggression,omitempty"`                  // Curve exponent, 1 is linear, larger values ramp up faster
+	MinWeightPercent float64  `json:"min_weight_percent,omitempty"`          // Weight share at the start of the window, 10 by default
+}
+
+// ShutdownConfig controls the phases of graceful shutdown.
+type ShutdownConfig struct {
+	PreStopDelay Duration `json:"pre_stop_delay,omitempty" swaggertype:"string"` // Time between failing readiness and closing the listener
+	DrainTimeout Duration `json:"drain_timeout,omitempty" swaggertype:"string"`  // Deadline for in-flight requests, 30s by default
+}
+
+// RedisConfig holds the connection settings for rate limiter persistence.
+type RedisConfig struct {
+	Addr     string `json:"addr,omitempty"`     // host:port, "redis:6379" by default
+	Required bool   `json:"required,omitempty"` // Readiness fails while Redis is unreachable
+}
+
 // Config holds the application configuration.
 type Config struct {
-	Port                string          `json:"port"`
-	Backends            []*Backend      `json:"backends"`
-	HealthCheckPath     string          `json:"health_check_path"`
-	HealthCheckInterval time.Duration   `json:"health_check_interval"`
-	RateLimit           RateLimitConfig `json:"rate_limit"`
-	ClientConfigs       []ClientConfig  `json:"client_configs"`
+	Port                string           `json:"port"`              // TCP port or unix:///path of a Unix domain socket
+	AdminPort           string           `json:"admin_port"`        // Separate listener for the admin API and probes, public port when empty
+	AdminSocketMode     string           `json:"admin_socket_mode"` // Permissions of the admin socket file when admin_port is unix:///path
+	Backends            []*Backend       `json:"backends"`
+	HealthCheckPath     string           `json:"health_check_path"`
+	HealthCheckInterval time.Duration    `json:"health_check_interval"`
+	HealthHistorySize   int              `json:"health_history_size"`
+	RateLimit           RateLimitConfig  `json:"rate_limit"`
+	ClientConfigs       []ClientConfig   `json:"client_configs"`
+	Strategy            string           `json:"strategy"`
+	SlowStart           SlowStartConfig  `json:"slow_start"`
+	Shutdown            ShutdownConfig   `json:"shutdown"`
+	Redis               RedisConfig      `json:"redis"`
+	Pools               []*Pool          `json:"pools"`
+	Routes              []*Route         `json:"routes"`
+	Headers             HeadersConfig    `json:"headers"`    // Header rules for every request, applied before the route's rules
+	Forwarding          ForwardingConfig `json:"forwarding"` // X-Forwarded-* and Forwarded headers sent to backends
+	AccessLog           AccessLogConfig  `json:"access_log"`
+	Tracing             TracingConfig    `json:"tracing"`
+	Timeouts            TimeoutsConfig   `json:"timeouts"` // Upstream timeouts, overridden per pool and per route
+	Server              ServerConfig     `json:"server"`   // Timeouts and size limits of the public listener
+	TLS                 TLSConfig        `json:"tls"`      // HTTPS listener with SNI certificates
+
+	TCPListeners []TCPListenerConfig `json:"tcp_listeners"` // Layer-4 listeners forwarding raw TCP connections to pools
+	UDPListeners []UDPListenerConfig `json:"udp_listeners"` // Layer-4 listeners forwarding UDP datagrams to pools
 }
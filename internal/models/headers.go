@@ -0,0 +1,42 @@
+package models
+
+// HeaderRules changes the headers of one leg of the proxy. Remove is applied first, then Set, then Add.
+// Values may contain the placeholders {client_ip}, {backend_url}, {request_id}, {timestamp}
+// (RFC 3339 in UTC) and {timestamp_ms} (Unix milliseconds), taken when the request was received.
+type HeaderRules struct {
+	Add    map[string]string `json:"add,omitempty"`    // Appended to the existing values
+	Set    map[string]string `json:"set,omitempty"`    // Replaces the existing values
+	Remove []string          `json:"remove,omitempty"` // Header names to delete
+}
+
+// IsZero reports whether the rules change nothing.
+func (r HeaderRules) IsZero() bool {
+	return len(r.Add) == 0 && len(r.Set) == 0 && len(r.Remove) == 0
+}
+
+// HeadersConfig holds the header rules for requests sent to backends and responses returned to clients.
+type HeadersConfig struct {
+	Request  HeaderRules `json:"request"`
+	Response HeaderRules `json:"response"`
+}
+
+// IsZero reports whether neither leg has rules.
+func (h HeadersConfig) IsZero() bool {
+	return h.Request.IsZero() && h.Response.IsZero()
+}
+
+// X-Forwarded-For modes supported by ForwardingConfig.XForwardedFor.
+const (
+	ForwardedForAppend  = "append"  // Append the client address to the incoming list
+	ForwardedForReplace = "replace" // Discard the incoming list and send only the client address
+	ForwardedForOff     = "off"     // Send the incoming header unchanged, or none
+)
+
+// ForwardingConfig controls the headers that tell backends about the original request.
+type ForwardingConfig struct {
+	XForwardedFor   string `json:"x_forwarded_for,omitempty"`   // append (default), replace or off
+	XForwardedProto *bool  `json:"x_forwarded_proto,omitempty"` // Set X-Forwarded-Proto, on by default
+	XForwardedHost  *bool  `json:"x_forwarded_host,omitempty"`  // Set X-Forwarded-Host to the client Host, on by default
+	XRealIP         *bool  `json:"x_real_ip,omitempty"`         // Set X-Real-IP to the client address, on by default
+	Forwarded       bool   `json:"forwarded,omitempty"`         // Emit an RFC 7239 Forwarded header, following the X-Forwarded-For mode
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Defaults of TCPListenerConfig, applied to zero fields.
+const (
+	DefaultTCPIdleTimeout    = 5 * time.Minute
+	DefaultTCPConnectTimeout = 5 * time.Second
+)
+
+// DefaultUDPSessionTimeout ends a UDP session without packets in either direction.
+const DefaultUDPSessionTimeout = 30 * time.Second
+
+// TCPListenerConfig is a layer-4 listener that forwards raw TCP connections, e.g. to Postgres
+// or Redis, to the backends of a pool. The pool's strategy picks the backend of each connection;
+// source_ip_hash keeps a client on the same backend. Backend URLs need a port, e.g. tcp://db1:5432.
+type TCPListenerConfig struct {
+	Name                string   `json:"name"`
+	Port                string   `json:"port"`                                           // Listen port, stored without the colon
+	Pool                string   `json:"pool"`                                           // Pool of backends, "default" for the top-level backends
+	IdleTimeout         Duration `json:"idle_timeout,omitempty" swaggertype:"string"`    // No data in either direction, 5m by default
+	ConnectTimeout      Duration `json:"connect_timeout,omitempty" swaggertype:"string"` // Connecting to a backend, 5s by default
+	MaxConnections      int      `json:"max_connections,omitempty"`                      // Open client connections, further ones wait to be accepted; unlimited when zero
+	MaxConnectionsPerIP int      `json:"max_connections_per_ip,omitempty"`               // Open connections of a single client IP, further ones are closed; unlimited when zero
+}
+
+// WithDefaults returns c with the defaults applied to zero fields.
+func (c TCPListenerConfig) WithDefaults() TCPListenerConfig {
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = Duration(DefaultTCPIdleTimeout)
+	}
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = Duration(DefaultTCPConnectTimeout)
+	}
+	return c
+}
+
+// UDPListenerConfig is a layer-4 listener that forwards UDP datagrams, e.g. DNS queries or
+// syslog messages, to the backends of a pool. Packets from one client address form a session
+// that stays with the backend chosen for its first packet; replies of the backend are sent back
+// to the client. Backend URLs need a port, e.g. udp://dns1:53.
+type UDPListenerConfig struct {
+	Name           string   `json:"name"`
+	Port           string   `json:"port"`                                           // Listen port, stored without the colon
+	Pool           string   `json:"pool"`                                           // Pool of backends, "default" for the top-level backends
+	SessionTimeout Duration `json:"session_timeout,omitempty" swaggertype:"string"` // No packets in either direction, 30s by default
+	MaxSessions    int      `json:"max_sessions,omitempty"`                         // Open sessions, packets of new clients are dropped beyond it; unlimited when zero
+}
+
+// WithDefaults returns c with the defaults applied to zero fields.
+func (c UDPListenerConfig) WithDefaults() UDPListenerConfig {
+	if c.SessionTimeout == 0 {
+		c.SessionTimeout = Duration(DefaultUDPSessionTimeout)
+	}
+	return c
+}
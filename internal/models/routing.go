@@ -0,0 +1,103 @@
+package models
+
+// DefaultPool is the name of the pool formed by Config.Backends. Requests that match no route go there.
+const DefaultPool = "default"
+
+// Pool is a named group of backends with its own balancing and health check settings.
+type Pool struct {
+	Name                string             `json:"name"`
+	Backends            []*Backend         `json:"backends"`
+	Strategy            string             `json:"strategy,omitempty"`                                   // Config.Strategy when empty
+	SlowStart           SlowStartConfig    `json:"slow_start"`                                           // Config.SlowStart when zero
+	HealthCheckPath     string             `json:"health_check_path,omitempty"`                          // Config.HealthCheckPath when empty
+	HealthCheckInterval Duration           `json:"health_check_interval,omitempty" swaggertype:"string"` // Config.HealthCheckInterval when zero
+	Timeouts            TimeoutsConfig     `json:"timeouts"`                                             // Set fields override Config.Timeouts
+	TLS                 *UpstreamTLSConfig `json:"tls,omitempty"`                                        // Upstream TLS settings of backends without their own
+	Protocol            string             `json:"protocol,omitempty"`                                   // Upstream protocol of backends without their own
+}
+
+// RouteMatch lists the conditions of a route. Every condition that is set must hold.
+type RouteMatch struct {
+	Host       string            `json:"host,omitempty"`        // Exact host or "*.example.com", port is ignored
+	PathPrefix string            `json:"path_prefix,omitempty"` // Matches at segment boundaries: "/api" matches "/api" and "/api/x" but not "/apix"
+	PathRegex  string            `json:"path_regex,omitempty"`  // RE2 expression matched against the request path
+	Methods    []string          `json:"methods,omitempty"`     // Any of the listed methods
+	Headers    map[string]string `json:"headers,omitempty"`     // Exact header values, an empty value only requires presence
+	Query      map[string]string `json:"query,omitempty"`       // Exact query parameter values, an empty value only requires presence
+}
+
+// RewriteConfig changes the request before it is sent to the backend. The steps are applied
+// in order: strip prefix, regex replace, add prefix, then the host override.
+type RewriteConfig struct {
+	StripPrefix string `json:"strip_prefix,omitempty"` // Removed at a segment boundary: "/billing" turns "/billing/v1/x" into "/v1/x"
+	AddPrefix   string `json:"add_prefix,omitempty"`   // Prepended to the path
+	Regex       string `json:"regex,omitempty"`        // RE2 expression matched against the escaped path
+	Replacement string `json:"replacement,omitempty"`  // Replacement for Regex, may reference capture groups as $1 or ${name}
+	Host        string `json:"host,omitempty"`         // Host header sent to the backend
+}
+
+// Route sends matching requests to a pool. Routes are evaluated in order and the first match wins.
+type Route struct {
+	ID       string          `json:"id"`
+	Match    RouteMatch      `json:"match"`
+	Pool     string          `json:"pool"`
+	Rewrite  *RewriteConfig  `json:"rewrite,omitempty"`
+	Headers  *HeadersConfig  `json:"headers,omitempty"`  // Applied after the global header rules
+	Timeouts *TimeoutsConfig `json:"timeouts,omitempty"` // Set fields override the timeouts of the global settings and the pool
+
+	// FlushInterval delays sending streaming responses (Server-Sent Events, chunked bodies)
+	// to the client by at most this long to batch small writes. Zero flushes every write.
+	FlushInterval Duration `json:"flush_interval,omitempty" swaggertype:"string"`
+
+	// RequireClientCert rejects requests without a verified client certificate with 403,
+	// see TLSConfig.ClientAuth. Requests on the plain port never carry one.
+	RequireClientCert bool `json:"require_client_cert,omitempty"`
+
+	// Split sends a share of the matching requests to another pool, e.g. a canary release.
+	Split *TrafficSplit `json:"split,omitempty"`
+}
+
+// States of a traffic split.
+const (
+	SplitActive     = "active"      // The rollout moves on as scheduled; the default
+	SplitPaused     = "paused"      // The weight stays as it is
+	SplitRolledBack = "rolled_back" // The canary failed, its weight stays 0 until changed through the API
+)
+
+// DefaultSplitMinRequests is the number of canary requests at the current weight after which
+// the error rate of a rollout is judged.
+const DefaultSplitMinRequests = 20
+
+// TrafficSplit sends Weight percent of a route's requests to Pool and the rest to the route's pool.
+// Without a sticky header or cookie every request is assigned anew; with one, a client stays on
+// its side, and stays in the canary as the weight grows.
+type TrafficSplit struct {
+	Pool         string         `json:"pool"`                    // Canary pool
+	Weight       int            `json:"weight"`                  // Percentage of requests sent to Pool, 0-100
+	StickyHeader string         `json:"sticky_header,omitempty"` // Request header, e.g. X-User-ID, whose value assigns the client to a side
+	StickyCookie string         `json:"sticky_cookie,omitempty"` // Cookie set on the first response that keeps the client on its side
+	Rollout      *RolloutConfig `json:"rollout,omitempty"`
+	State        string         `json:"state,omitempty"` // active, paused or rolled_back
+}
+
+// RolloutConfig raises the weight of a traffic split step by step and sets it back to 0
+// when too many canary requests fail.
+type RolloutConfig struct {
+	Steps        []int    `json:"steps,omitempty"`                         // Increasing weights applied in order, e.g. [5, 25, 50, 100]
+	Interval     Duration `json:"interval,omitempty" swaggertype:"string"` // Time at each weight before the next step
+	MaxErrorRate float64  `json:"max_error_rate,omitempty"`                // Share of canary requests (0-1) answered with 5xx or a gRPC server error that rolls the split back; no rollback when zero
+	MinRequests  int      `json:"min_requests,omitempty"`                  // Canary requests at the current weight before the error rate is judged, 20 by default
+}
+
+// NextStep returns the first rollout step above the current weight.
+func (s *TrafficSplit) NextStep() (int, bool) {
+	if s.Rollout == nil {
+		return 0, false
+	}
+	for _, step := range s.Rollout.Steps {
+		if step > s.Weight {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Defaults of ServerConfig, applied to zero fields.
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 60 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultMaxHeaderBytes    = 1 << 20  // 1 MB, same as net/http
+	DefaultMaxBodyBytes      = 10 << 20 // 10 MB
+	DefaultMaxConnections    = 10000
+)
+
+// ServerConfig limits the public listener against slow and oversized clients.
+// Zero fields take the defaults above.
+type ServerConfig struct {
+	ReadHeaderTimeout Duration `json:"read_header_timeout,omitempty" swaggertype:"string"` // Reading the request line and headers
+	ReadTimeout       Duration `json:"read_timeout,omitempty" swaggertype:"string"`        // Reading the whole request including the body
+	WriteTimeout      Duration `json:"write_timeout,omitempty" swaggertype:"string"`       // From the end of the request headers to the end of the response
+	IdleTimeout       Duration `json:"idle_timeout,omitempty" swaggertype:"string"`        // Keep-alive connection waiting for the next request
+	MaxHeaderBytes    int      `json:"max_header_bytes,omitempty"`                         // Request line and headers, larger requests get 431
+	MaxBodyBytes      int64    `json:"max_body_bytes,omitempty"`                           // Request body, larger requests get 413
+	MaxConnections    int      `json:"max_connections,omitempty"`                          // Open client connections, further ones wait to be accepted
+	H2C               bool     `json:"h2c,omitempty"`                                      // Accept HTTP/2 without TLS on the plain port, e.g. from internal gRPC clients
+	SocketMode        string   `json:"socket_mode,omitempty"`                              // Permissions of the socket file when port is unix:///path, e.g. "0660"
+}
+
+// WithDefaults returns c with the defaults applied to zero fields.
+func (c ServerConfig) WithDefaults() ServerConfig {
+	if c.ReadHeaderTimeout == 0 {
+		c.ReadHeaderTimeout = Duration(DefaultReadHeaderTimeout)
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = Duration(DefaultReadTimeout)
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = Duration(DefaultWriteTimeout)
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = Duration(DefaultIdleTimeout)
+	}
+	if c.MaxHeaderBytes == 0 {
+		c.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	if c.MaxBodyBytes == 0 {
+		c.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if c.MaxConnections == 0 {
+		c.MaxConnections = DefaultMaxConnections
+	}
+	return c
+}
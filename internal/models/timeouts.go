@@ -0,0 +1,46 @@
+package models
+
+// RequestTimeoutHeader lets a client ask for a shorter upstream deadline, e.g. "X-Request-Timeout: 2s".
+// The value is a duration such as "1.5s" or a number of milliseconds; it never extends TimeoutsConfig.Request.
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// TimeoutsConfig bounds the exchange with a backend. Zero fields are not limited, except
+// Connect and TLSHandshake, which then keep the defaults of the Go HTTP transport (30s and 10s).
+type TimeoutsConfig struct {
+	Connect        Duration `json:"connect,omitempty" swaggertype:"string"`         // Establishing the TCP connection
+	TLSHandshake   Duration `json:"tls_handshake,omitempty" swaggertype:"string"`   // TLS handshake with an https backend
+	ResponseHeader Duration `json:"response_header,omitempty" swaggertype:"string"` // From sending the request to receiving the response headers
+	IdleRead       Duration `json:"idle_read,omitempty" swaggertype:"string"`       // Longest wait for the next chunk of the response body
+	Request        Duration `json:"request,omitempty" swaggertype:"string"`         // Whole upstream exchange including the body
+
+	// Connections that switched protocols (WebSocket and other Upgrade requests) are not bound
+	// by Request and IdleRead, these two limits apply instead.
+	UpgradeIdle     Duration `json:"upgrade_idle,omitempty" swaggertype:"string"`     // Longest time without data in either direction
+	UpgradeLifetime Duration `json:"upgrade_lifetime,omitempty" swaggertype:"string"` // Maximum age of the connection
+}
+
+// Merge returns t with the non-zero fields of override applied.
+func (t TimeoutsConfig) Merge(override TimeoutsConfig) TimeoutsConfig {
+	if override.Connect != 0 {
+		t.Connect = override.Connect
+	}
+	if override.TLSHandshake != 0 {
+		t.TLSHandshake = override.TLSHandshake
+	}
+	if override.ResponseHeader != 0 {
+		t.ResponseHeader = override.ResponseHeader
+	}
+	if override.IdleRead != 0 {
+		t.IdleRead = override.IdleRead
+	}
+	if override.Request != 0 {
+		t.Request = override.Request
+	}
+	if override.UpgradeIdle != 0 {
+		t.UpgradeIdle = override.UpgradeIdle
+	}
+	if override.UpgradeLifetime != 0 {
+		t.UpgradeLifetime = override.UpgradeLifetime
+	}
+	return t
+}
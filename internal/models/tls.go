@@ -0,0 +1,76 @@
+package models
+
+// TLS versions accepted by TLSConfig.MinVersion.
+const (
+	TLSVersion10 = "1.0"
+	TLSVersion11 = "1.1"
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// CertificateConfig is a PEM certificate chain and its private key.
+type CertificateConfig struct {
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+// Client certificate modes supported by ClientAuthConfig.Mode.
+const (
+	ClientAuthNone    = "none"    // Client certificates are not requested
+	ClientAuthRequest = "request" // A certificate is requested and verified if the client sends one
+	ClientAuthRequire = "require" // Handshakes without a valid client certificate fail
+)
+
+// Rate-limit identities supported by ClientAuthConfig.Identity.
+const (
+	ClientIdentityIP      = "ip"      // Client address
+	ClientIdentitySubject = "subject" // Common name of the certificate subject
+	ClientIdentitySAN     = "san"     // First subject alternative name: DNS name, email, URI or IP
+)
+
+// ClientCertHeaders names the request headers that carry the verified client certificate to
+// backends. Empty names are not sent. Headers with these names sent by the client are removed.
+type ClientCertHeaders struct {
+	Subject     string `json:"subject,omitempty"`     // Subject distinguished name
+	Issuer      string `json:"issuer,omitempty"`      // Issuer distinguished name
+	SAN         string `json:"san,omitempty"`         // Comma-separated subject alternative names
+	Serial      string `json:"serial,omitempty"`      // Serial number in hex
+	Fingerprint string `json:"fingerprint,omitempty"` // SHA-256 of the certificate in hex
+	Cert        string `json:"cert,omitempty"`        // URL-encoded PEM certificate
+}
+
+// IsZero reports whether no header is configured.
+func (h ClientCertHeaders) IsZero() bool {
+	return h == ClientCertHeaders{}
+}
+
+// ClientAuthConfig controls client certificate authentication on the HTTPS listener.
+type ClientAuthConfig struct {
+	Mode     string            `json:"mode,omitempty"`     // none (default), request or require
+	CAFile   string            `json:"ca_file,omitempty"`  // PEM bundle of CAs that issue client certificates
+	Identity string            `json:"identity,omitempty"` // Rate-limit client ID: ip (default), subject or san
+	Headers  ClientCertHeaders `json:"headers"`            // Headers forwarding the verified certificate to backends
+}
+
+// IsZero reports whether no client authentication setting is configured.
+func (c ClientAuthConfig) IsZero() bool {
+	return c.Mode == "" && c.CAFile == "" && c.Identity == "" && c.Headers.IsZero()
+}
+
+// TLSConfig enables an HTTPS listener next to the plain public port.
+type TLSConfig struct {
+	Enabled        bool                `json:"enabled"`
+	Port           string              `json:"port"`                                           // HTTPS listener, e.g. ":8443"
+	Certificates   []CertificateConfig `json:"certificates"`                                   // Selected by SNI, the first one is served to clients without a match
+	MinVersion     string              `json:"min_version,omitempty"`                          // "1.2" by default
+	CipherSuites   []string            `json:"cipher_suites,omitempty"`                        // crypto/tls names for TLS 1.0-1.2, Go defaults when empty
+	RedirectHTTP   bool                `json:"redirect_http,omitempty"`                        // The plain port answers 308 to the HTTPS listener
+	ReloadInterval Duration            `json:"reload_interval,omitempty" swaggertype:"string"` // How often certificate files are checked for changes, 30s by default
+	ClientAuth     ClientAuthConfig    `json:"client_auth"`                                    // Client certificate authentication
+}
+
+// IsZero reports whether no TLS setting is configured.
+func (c TLSConfig) IsZero() bool {
+	return !c.Enabled && c.Port == "" && len(c.Certificates) == 0 && c.MinVersion == "" &&
+		len(c.CipherSuites) == 0 && !c.RedirectHTTP && c.ReloadInterval == 0 && c.ClientAuth.IsZero()
+}
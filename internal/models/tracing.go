@@ -0,0 +1,17 @@
+package models
+
+// TracingConfig controls OpenTelemetry tracing of proxied requests.
+// Spans are exported over OTLP/HTTP, and the W3C trace context is propagated to backends.
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled"`
+	Endpoint    string            `json:"endpoint,omitempty"`     // Collector as host:port or URL, "localhost:4318" by default
+	Insecure    bool              `json:"insecure,omitempty"`     // Use plain HTTP for a host:port endpoint
+	Headers     map[string]string `json:"headers,omitempty"`      // Extra headers sent to the collector, e.g. authorization
+	ServiceName string            `json:"service_name,omitempty"` // "load-balancer" by default
+	SampleRatio *float64          `json:"sample_ratio,omitempty"` // Share of new traces to sample, 1 by default; an incoming trace keeps its decision
+}
+
+// IsZero reports whether no tracing setting is given.
+func (t TracingConfig) IsZero() bool {
+	return !t.Enabled && t.Endpoint == "" && !t.Insecure && len(t.Headers) == 0 && t.ServiceName == "" && t.SampleRatio == nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// UnixScheme prefixes backend URLs and listen addresses of Unix domain sockets, e.g. unix:///run/app.sock.
+const UnixScheme = "unix://"
+
+// UnixSocket returns the socket path of a unix:// backend URL or listen address and whether it is one.
+func UnixSocket(addr string) (string, bool) {
+	return strings.CutPrefix(addr, UnixScheme)
+}
+
+// ParseSocketMode parses an octal file mode such as "0660". An empty mode is zero,
+// which leaves the permissions the socket was created with.
+func ParseSocketMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q, expected octal permissions such as 0660", mode)
+	}
+	return os.FileMode(m), nil
+}
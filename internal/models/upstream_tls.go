@@ -0,0 +1,18 @@
+package models
+
+// UpstreamTLSConfig controls the TLS connection to an https backend and its health probes.
+type UpstreamTLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty"`              // PEM bundle trusted instead of the system roots
+	CertFile           string `json:"cert_file,omitempty"`            // Client certificate presented for mutual TLS
+	KeyFile            string `json:"key_file,omitempty"`             // Key of CertFile
+	ServerName         string `json:"server_name,omitempty"`          // SNI and verified name, the URL host when empty
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // Accept any server certificate, for development only
+}
+
+// UpstreamTLS returns the TLS settings of the backend, falling back to those of its pool.
+func (b *Backend) UpstreamTLS(pool *UpstreamTLSConfig) *UpstreamTLSConfig {
+	if b.TLS != nil {
+		return b.TLS
+	}
+	return pool
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig"
+)
+
+// applyClientCert передает бэкенду данные проверенного сертификата клиента в заголовках
+// с настроенными именами. Одноименные заголовки клиента удаляются всегда, чтобы их нельзя
+// было подделать, в том числе в запросах без сертификата.
+func applyClientCert(req *http.Request, h models.ClientCertHeaders, cert *x509.Certificate) {
+	if h.IsZero() {
+		return
+	}
+	set := func(name string, value func() string) {
+		if name == "" {
+			return
+		}
+		req.Header.Del(name)
+		if cert != nil {
+			req.Header.Set(name, value())
+		}
+	}
+	set(h.Subject, func() string { return cert.Subject.String() })
+	set(h.Issuer, func() string { return cert.Issuer.String() })
+	set(h.SAN, func() string { return strings.Join(tlsconfig.SubjectAltNames(cert), ",") })
+	set(h.Serial, func() string { return fmt.Sprintf("%x", cert.SerialNumber) })
+	set(h.Fingerprint, func() string {
+		sum := sha256.Sum256(cert.Raw)
+		return hex.EncodeToString(sum[:])
+	})
+	set(h.Cert, func() string {
+		return url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	})
+}
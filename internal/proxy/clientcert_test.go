@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig/tlstest"
+)
+
+func TestProxy_ClientCertHeaders(t *testing.T) {
+	cert := tlstest.NewCert(t, t.TempDir(), "partner.example.com", "api.partner.example.com")
+	headers := models.ClientCertHeaders{
+		Subject:     "X-Client-Subject",
+		SAN:         "X-Client-SAN",
+		Fingerprint: "X-Client-Fingerprint",
+		Cert:        "X-Client-Cert",
+	}
+
+	t.Run("Verified certificate", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "https://lb.example.com/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert.Leaf}}}
+		req.Header.Set("X-Client-Subject", "CN=forged")
+		header, _, _ := forwardedRequest(t, req, &Options{ClientCert: headers})
+
+		sum := sha256.Sum256(cert.Leaf.Raw)
+		want := map[string]string{
+			"X-Client-Subject":     "CN=partner.example.com",
+			"X-Client-SAN":         "partner.example.com,api.partner.example.com",
+			"X-Client-Fingerprint": hex.EncodeToString(sum[:]),
+		}
+		for name, value := range want {
+			if got := header.Values(name); len(got) != 1 || got[0] != value {
+				t.Errorf("%s: expected %q, got %q", name, value, got)
+			}
+		}
+		pem, err := url.QueryUnescape(header.Get("X-Client-Cert"))
+		if err != nil || !strings.HasPrefix(pem, "-----BEGIN CERTIFICATE-----") {
+			t.Errorf("Expected URL-encoded PEM certificate, got %q", header.Get("X-Client-Cert"))
+		}
+	})
+
+	t.Run("Client headers are removed without a certificate", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Client-Subject", "CN=forged")
+		req.Header.Set("X-Client-SAN", "admin.example.com")
+		header, _, _ := forwardedRequest(t, req, &Options{ClientCert: headers})
+		for _, name := range []string{"X-Client-Subject", "X-Client-SAN", "X-Client-Fingerprint", "X-Client-Cert"} {
+			if v := header.Get(name); v != "" {
+				t.Errorf("Expected %s to be removed, got %q", name, v)
+			}
+		}
+	})
+}
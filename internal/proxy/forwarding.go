@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"load-balancer/internal/models"
+)
+
+// forwardedFor — данные исходного запроса для заголовков X-Forwarded-* и Forwarded.
+type forwardedFor struct {
+	clientIP string
+	host     string
+	proto    string
+}
+
+// newForwardedFor собирает адрес клиента, Host и схему входящего запроса.
+func newForwardedFor(r *http.Request) forwardedFor {
+	f := forwardedFor{host: r.Host, proto: "http"}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		f.clientIP = ip
+	}
+	if r.TLS != nil {
+		f.proto = "https"
+	}
+	return f
+}
+
+// applyForwarding выставляет заголовки пересылки исходящего запроса.
+// X-Forwarded-For дописывает сам ReverseProxy после Director, поэтому в режиме replace
+// заголовок здесь только удаляется, а режим off обрабатывается в ForwardWith.
+func applyForwarding(req *http.Request, cfg models.ForwardingConfig, f forwardedFor) {
+	if cfg.XForwardedFor == models.ForwardedForReplace {
+		req.Header.Del("X-Forwarded-For")
+	}
+	if enabled(cfg.XForwardedProto) {
+		req.Header.Set("X-Forwarded-Proto", f.proto)
+	}
+	if enabled(cfg.XForwardedHost) {
+		req.Header.Set("X-Forwarded-Host", f.host)
+	}
+	if enabled(cfg.XRealIP) && f.clientIP != "" {
+		req.Header.Set("X-Real-IP", f.clientIP)
+	}
+	if cfg.Forwarded && cfg.XForwardedFor != models.ForwardedForOff {
+		element := f.forwardedElement()
+		if prior := req.Header.Values("Forwarded"); cfg.XForwardedFor != models.ForwardedForReplace && len(prior) > 0 {
+			element = strings.Join(prior, ", ") + ", " + element
+		}
+		req.Header.Set("Forwarded", element)
+	}
+}
+
+// forwardedElement формирует элемент заголовка Forwarded по RFC 7239.
+func (f forwardedFor) forwardedElement() string {
+	pairs := make([]string, 0, 3)
+	if f.clientIP != "" {
+		node := f.clientIP
+		if strings.Contains(node, ":") {
+			// IPv6-адрес записывается в квадратных скобках и кавычках
+			node = `"[` + node + `]"`
+		}
+		pairs = append(pairs, "for="+node)
+	}
+	if f.host != "" {
+		pairs = append(pairs, "host="+quoteForwarded(f.host))
+	}
+	pairs = append(pairs, "proto="+f.proto)
+	return strings.Join(pairs, ";")
+}
+
+// quoteForwarded заключает значение в кавычки, если оно не является токеном RFC 7230.
+func quoteForwarded(value string) string {
+	for _, c := range value {
+		if !isTokenChar(c) {
+			return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+		}
+	}
+	return value
+}
+
+func isTokenChar(c rune) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
+
+// enabled возвращает значение флага, включенного по умолчанию.
+func enabled(flag *bool) bool {
+	return flag == nil || *flag
+}
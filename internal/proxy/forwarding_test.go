@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"load-balancer/internal/models"
+)
+
+// forwardedRequest proxies req through the given options and returns what the stand-in backend received.
+func forwardedRequest(t *testing.T, req *http.Request, opts *Options) (http.Header, string, string) {
+	t.Helper()
+	var header http.Header
+	var host string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header, host = r.Header.Clone(), r.Host
+	}))
+	defer backendServer.Close()
+
+	rr := httptest.NewRecorder()
+	if err := NewProxy().ForwardWith(rr, req, backendServer.URL, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return header, host, strings.TrimPrefix(backendServer.URL, "http://")
+}
+
+func TestProxy_ForwardingHeaders(t *testing.T) {
+	off := false
+	tests := []struct {
+		name    string
+		remote  string
+		prior   map[string]string
+		tls     bool
+		cfg     models.ForwardingConfig
+		want    map[string]string
+		missing []string
+	}{
+		{
+			name:   "Defaults append X-Forwarded-For and set the rest",
+			remote: "10.0.0.7:5555",
+			prior:  map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			want: map[string]string{
+				"X-Forwarded-For":   "203.0.113.1, 10.0.0.7",
+				"X-Forwarded-Proto": "http",
+				"X-Forwarded-Host":  "shop.example.com",
+				"X-Real-IP":         "10.0.0.7",
+			},
+			missing: []string{"Forwarded"},
+		},
+		{
+			name:   "Replace discards the incoming chain",
+			remote: "10.0.0.7:5555",
+			prior:  map[string]string{"X-Forwarded-For": "203.0.113.1", "Forwarded": "for=203.0.113.1"},
+			cfg:    models.ForwardingConfig{XForwardedFor: models.ForwardedForReplace, Forwarded: true},
+			want: map[string]string{
+				"X-Forwarded-For": "10.0.0.7",
+				"Forwarded":       "for=10.0.0.7;host=shop.example.com;proto=http",
+			},
+		},
+		{
+			name:   "Forwarded is appended with quoted IPv6 and https",
+			remote: "[2001:db8::1]:5555",
+			prior:  map[string]string{"Forwarded": "for=203.0.113.1"},
+			tls:    true,
+			cfg:    models.ForwardingConfig{Forwarded: true},
+			want: map[string]string{
+				"X-Forwarded-For":   "2001:db8::1",
+				"X-Forwarded-Proto": "https",
+				"Forwarded":         `for=203.0.113.1, for="[2001:db8::1]";host=shop.example.com;proto=https`,
+			},
+		},
+		{
+			name:   "Off keeps the incoming header and disabled headers are not set",
+			remote: "10.0.0.7:5555",
+			prior:  map[string]string{"X-Forwarded-For": "203.0.113.1", "X-Forwarded-Proto": "https"},
+			cfg:    models.ForwardingConfig{XForwardedFor: models.ForwardedForOff, XForwardedProto: &off, XForwardedHost: &off, XRealIP: &off, Forwarded: true},
+			want: map[string]string{
+				"X-Forwarded-For":   "203.0.113.1",
+				"X-Forwarded-Proto": "https",
+			},
+			missing: []string{"X-Forwarded-Host", "X-Real-IP", "Forwarded"},
+		},
+		{
+			name:    "Off without incoming header sends none",
+			remote:  "10.0.0.7:5555",
+			cfg:     models.ForwardingConfig{XForwardedFor: models.ForwardedForOff},
+			missing: []string{"X-Forwarded-For"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://shop.example.com/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tt.prior {
+				req.Header.Set(k, v)
+			}
+			header, _, _ := forwardedRequest(t, req, &Options{Forwarding: tt.cfg})
+			for k, v := range tt.want {
+				if got := header.Get(k); got != v {
+					t.Errorf("Expected %s %q, got %q", k, v, got)
+				}
+			}
+			for _, k := range tt.missing {
+				if _, ok := header[k]; ok {
+					t.Errorf("Expected no %s header, got %q", k, header.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestProxy_ForwardHostHeader(t *testing.T) {
+	t.Run("Client Host is kept by default", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://shop.example.com/", nil)
+		_, host, _ := forwardedRequest(t, req, nil)
+		if host != "shop.example.com" {
+			t.Errorf("Expected client Host, got %q", host)
+		}
+	})
+
+	t.Run("Backend Host", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://shop.example.com/", nil)
+		header, host, backendHost := forwardedRequest(t, req, &Options{BackendHost: true})
+		if host != backendHost {
+			t.Errorf("Expected backend Host %q, got %q", backendHost, host)
+		}
+		if got := header.Get("X-Forwarded-Host"); got != "shop.example.com" {
+			t.Errorf("Expected X-Forwarded-Host to keep the client Host, got %q", got)
+		}
+	})
+
+	t.Run("Rewrite host wins over backend Host", func(t *testing.T) {
+		rw, _ := NewRewrite(&models.RewriteConfig{Host: "api.internal"})
+		req := httptest.NewRequest("GET", "http://shop.example.com/", nil)
+		_, host, _ := forwardedRequest(t, req, &Options{BackendHost: true, Rewrite: rw})
+		if host != "api.internal" {
+			t.Errorf("Expected rewrite host, got %q", host)
+		}
+	})
+}
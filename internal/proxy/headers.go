@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/requestid"
+)
+
+// placeholderPattern находит подстановки вида {name} в значениях заголовков.
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// placeholders — поддерживаемые подстановки в значениях заголовков.
+var placeholders = map[string]bool{
+	"{client_ip}":    true,
+	"{backend_url}":  true,
+	"{request_id}":   true,
+	"{timestamp}":    true,
+	"{timestamp_ms}": true,
+}
+
+// headerValue — заголовок с шаблоном значения.
+type headerValue struct {
+	name  string
+	value string
+}
+
+// headerRules — скомпилированные правила одного направления.
+type headerRules struct {
+	add    []headerValue
+	set    []headerValue
+	remove []string
+}
+
+// Headers — скомпилированные правила изменения заголовков запроса и ответа.
+type Headers struct {
+	request  headerRules
+	response headerRules
+}
+
+// NewHeaders проверяет подстановки и компилирует правила. Для пустой конфигурации возвращает nil.
+func NewHeaders(cfg *models.HeadersConfig) (*Headers, error) {
+	if cfg == nil || cfg.IsZero() {
+		return nil, nil
+	}
+	request, err := newHeaderRules(cfg.Request)
+	if err != nil {
+		return nil, fmt.Errorf("request headers: %w", err)
+	}
+	response, err := newHeaderRules(cfg.Response)
+	if err != nil {
+		return nil, fmt.Errorf("response headers: %w", err)
+	}
+	return &Headers{request: request, response: response}, nil
+}
+
+// ValidateHeaderTemplate проверяет, что значение использует только известные подстановки.
+func ValidateHeaderTemplate(value string) error {
+	for _, p := range placeholderPattern.FindAllString(value, -1) {
+		if !placeholders[p] {
+			return fmt.Errorf("unknown placeholder %s", p)
+		}
+	}
+	return nil
+}
+
+func newHeaderRules(cfg models.HeaderRules) (headerRules, error) {
+	var rules headerRules
+	var err error
+	if rules.add, err = newHeaderValues(cfg.Add); err != nil {
+		return rules, err
+	}
+	if rules.set, err = newHeaderValues(cfg.Set); err != nil {
+		return rules, err
+	}
+	for _, name := range cfg.Remove {
+		if name == "" {
+			return rules, fmt.Errorf("empty header name in remove")
+		}
+		rules.remove = append(rules.remove, http.CanonicalHeaderKey(name))
+	}
+	return rules, nil
+}
+
+// newHeaderValues сортирует заголовки по имени, чтобы порядок применения не зависел от map.
+func newHeaderValues(values map[string]string) ([]headerValue, error) {
+	result := make([]headerValue, 0, len(values))
+	for name, value := range values {
+		if name == "" {
+			return nil, fmt.Errorf("empty header name")
+		}
+		if err := ValidateHeaderTemplate(value); err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		result = append(result, headerValue{name: http.CanonicalHeaderKey(name), value: value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result, nil
+}
+
+// templateData — значения подстановок для одного запроса.
+type templateData struct {
+	replacer *strings.Replacer
+}
+
+// newTemplateData собирает значения подстановок из входящего запроса.
+func newTemplateData(r *http.Request, backendURL string, received time.Time) templateData {
+	clientIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		clientIP = host
+	}
+	return templateData{replacer: strings.NewReplacer(
+		"{client_ip}", clientIP,
+		"{backend_url}", backendURL,
+		"{request_id}", r.Header.Get(requestid.Header),
+		"{timestamp}", received.UTC().Format(time.RFC3339Nano),
+		"{timestamp_ms}", strconv.FormatInt(received.UnixMilli(), 10),
+	)}
+}
+
+// apply изменяет заголовки: сначала удаление, затем замена и добавление.
+// Для запроса заголовок Host записывается в req.Host, если передан host.
+func (rules headerRules) apply(h http.Header, data templateData, host *string) {
+	for _, name := range rules.remove {
+		h.Del(name)
+	}
+	for _, hv := range rules.set {
+		value := data.replacer.Replace(hv.value)
+		if hv.name == "Host" && host != nil {
+			*host = value
+			continue
+		}
+		h.Set(hv.name, value)
+	}
+	for _, hv := range rules.add {
+		h.Add(hv.name, data.replacer.Replace(hv.value))
+	}
+}
+
+// applyRequest применяет правила к исходящему запросу.
+func (hs *Headers) applyRequest(req *http.Request, data templateData) {
+	hs.request.apply(req.Header, data, &req.Host)
+}
+
+// applyResponse применяет правила к ответу бэкенда.
+func (hs *Headers) applyResponse(resp *http.Response, data templateData) {
+	hs.response.apply(resp.Header, data, nil)
+}
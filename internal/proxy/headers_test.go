@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+func TestProxy_ForwardWithHeaders(t *testing.T) {
+	var received http.Header
+	var receivedHost string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, receivedHost = r.Header.Clone(), r.Host
+		w.Header().Set("Server", "nginx/1.27")
+		w.Header().Set("X-Powered-By", "PHP/8.3")
+		w.Header().Set("Cache-Control", "private")
+		w.Write([]byte("OK"))
+	}))
+	defer backendServer.Close()
+
+	global, err := NewHeaders(&models.HeadersConfig{
+		Request: models.HeaderRules{
+			Set:    map[string]string{"X-Request-Start": "t={timestamp_ms}", "X-Client": "{client_ip}"},
+			Remove: []string{"x-internal-auth"},
+		},
+		Response: models.HeaderRules{
+			Remove: []string{"Server", "X-Powered-By"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHeaders failed: %v", err)
+	}
+	route, err := NewHeaders(&models.HeadersConfig{
+		Request: models.HeaderRules{
+			Set: map[string]string{"Host": "api.internal", "X-Client": "route-{client_ip}"},
+			Add: map[string]string{"X-Trace": "{request_id}@{backend_url}"},
+		},
+		Response: models.HeaderRules{
+			Set: map[string]string{"Cache-Control": "no-store"},
+			Add: map[string]string{"X-Served-At": "{timestamp}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHeaders failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.7:5555"
+	req.Header.Set("X-Internal-Auth", "secret")
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Add("X-Trace", "client")
+	rr := httptest.NewRecorder()
+	before := time.Now().UnixMilli()
+	if err := NewProxy().ForwardWith(rr, req, backendServer.URL, &Options{Headers: []*Headers{global, nil, route}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if received.Get("X-Internal-Auth") != "" {
+		t.Error("Expected X-Internal-Auth to be removed before forwarding")
+	}
+	start, err := strconv.ParseInt(strings.TrimPrefix(received.Get("X-Request-Start"), "t="), 10, 64)
+	if err != nil || start < before || start > time.Now().UnixMilli() {
+		t.Errorf("Expected X-Request-Start with the receive time, got %q", received.Get("X-Request-Start"))
+	}
+	if got := received.Get("X-Client"); got != "route-10.0.0.7" {
+		t.Errorf("Expected route rules to run after global rules, got X-Client %q", got)
+	}
+	if got := received.Values("X-Trace"); len(got) != 2 || got[1] != "req-1@"+backendServer.URL {
+		t.Errorf("Expected X-Trace to be appended, got %v", got)
+	}
+	if receivedHost != "api.internal" {
+		t.Errorf("Expected Host api.internal, got %q", receivedHost)
+	}
+
+	if rr.Header().Get("Server") != "" || rr.Header().Get("X-Powered-By") != "" {
+		t.Errorf("Expected Server and X-Powered-By to be removed, got %v", rr.Header())
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Expected Cache-Control to be replaced, got %q", got)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, rr.Header().Get("X-Served-At")); err != nil {
+		t.Errorf("Expected RFC 3339 timestamp, got %q", rr.Header().Get("X-Served-At"))
+	}
+}
+
+func TestNewHeaders_Validation(t *testing.T) {
+	if hs, err := NewHeaders(&models.HeadersConfig{}); hs != nil || err != nil {
+		t.Errorf("Expected nil rules for empty config, got %v, %v", hs, err)
+	}
+	if _, err := NewHeaders(&models.HeadersConfig{Request: models.HeaderRules{Set: map[string]string{"X-User": "{user}"}}}); err == nil {
+		t.Error("Expected error for unknown placeholder")
+	}
+	if _, err := NewHeaders(&models.HeadersConfig{Response: models.HeaderRules{Remove: []string{""}}}); err == nil {
+		t.Error("Expected error for empty header name")
+	}
+	if _, err := NewHeaders(&models.HeadersConfig{Request: models.HeaderRules{Set: map[string]string{"X-Literal": "{not a placeholder}"}}}); err != nil {
+		t.Errorf("Expected braces without a placeholder name to be kept literally, got %v", err)
+	}
+}
@@ -1,48 +1,248 @@
 package proxy
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
+	"sync"
+	"time"
 
 	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/tlsconfig"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Proxy управляет проксированием запросов к бэкендам.
-type Proxy struct{}
+type Proxy struct {
+	mu           sync.Mutex
+	transports   map[transportKey]http.RoundTripper    // Транспорты с таймаутами, см. transport
+	upgrades     map[string]map[*upgradedConn]struct{} // Открытые upgrade-соединения по URL бэкенда
+	errorHandler ErrorHandler
+}
+
+// ErrorHandler формирует ответ клиенту, когда бэкенд недоступен (502), не ответил вовремя (504)
+// или тело запроса превысило лимит сервера (413).
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, status int, err error)
 
 // NewProxy создает новый экземпляр прокси.
 func NewProxy() *Proxy {
 	return &Proxy{}
 }
 
+// SetErrorHandler задает ответ при ошибке обращения к бэкенду. По умолчанию отправляется только код ответа.
+func (p *Proxy) SetErrorHandler(h ErrorHandler) {
+	p.errorHandler = h
+}
+
+// Options — настройки проксирования отдельного запроса, обычно берутся из маршрута.
+type Options struct {
+	Rewrite     *Rewrite                  // Переписывание пути и Host, nil — запрос передается без изменений
+	Headers     []*Headers                // Правила заголовков, применяются по порядку: глобальные, затем маршрута
+	Forwarding  models.ForwardingConfig   // Заголовки X-Forwarded-* и Forwarded
+	BackendHost bool                      // Отправлять Host из URL бэкенда вместо Host клиента
+	Timeouts    models.TimeoutsConfig     // Таймауты обращения к бэкенду
+	TLS         *models.UpstreamTLSConfig // TLS-соединение с https-бэкендом, nil — настройки по умолчанию
+	Protocol    string                    // Протокол бэкенда: http1, h2c или пусто — по умолчанию
+	ClientCert  models.ClientCertHeaders  // Заголовки с проверенным сертификатом клиента
+
+	// FlushInterval — наибольшая задержка отправки потокового ответа клиенту (SSE, chunked),
+	// ноль — сразу после каждой порции от бэкенда.
+	FlushInterval time.Duration
+
+	// RequireClientCert — маршрут доступен только с проверенным сертификатом клиента.
+	// Прокси флаг не проверяет: запрос без сертификата отклоняет вызывающий до выбора бэкенда.
+	RequireClientCert bool
+}
+
 // Forward проксирует запрос к указанному URL бэкенда.
 func (p *Proxy) Forward(w http.ResponseWriter, r *http.Request, backendURL string) error {
+	return p.ForwardWith(w, r, backendURL, nil)
+}
+
+// ForwardWith проксирует запрос к указанному URL бэкенда с настройками маршрута.
+// При ошибке обращения к бэкенду ответ клиенту уже отправлен, а ошибка возвращается для журнала.
+func (p *Proxy) ForwardWith(w http.ResponseWriter, r *http.Request, backendURL string, opts *Options) error {
+	received := time.Now()
+	log := logger.FromContext(r.Context())
 	u, err := url.Parse(backendURL)
 	if err != nil {
-		logger.ErrorKV("Failed to parse backend URL", "url", backendURL, "error", err)
+		log.ErrorKV("Failed to parse backend URL", "url", backendURL, "error", err)
+		p.writeError(w, r, http.StatusBadGateway, err)
 		return fmt.Errorf("failed to parse backend URL: %w", err)
 	}
+	u, socket := unixTarget(u, backendURL)
 
 	proxy := httputil.NewSingleHostReverseProxy(u)
+	if opts == nil {
+		opts = &Options{}
+	}
+	transport, err := p.transport(opts, socket)
+	if err != nil {
+		log.ErrorKV("Failed to set up upstream TLS", "url", backendURL, "error", err)
+		p.writeError(w, r, http.StatusBadGateway, err)
+		return fmt.Errorf("failed to set up upstream TLS: %w", err)
+	}
+	p.configure(proxy, r, u, backendURL, received, opts)
+	proxy.Transport = transport
+
+	// Дедлайн всего обращения к бэкенду; клиент может сократить его заголовком X-Request-Timeout.
+	// Для смены протокола вместо него действует срок жизни upgrade-соединения.
+	upgrade := isUpgrade(r)
+	timeout := requestTimeout(r, opts.Timeouts.Request.Std())
+	if upgrade {
+		timeout = opts.Timeouts.UpgradeLifetime.Std()
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(r.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(r.Context())
+	}
+	defer cancel()
+	var upgraded *upgradedConn
+	modify := proxy.ModifyResponse
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if rwc, ok := resp.Body.(io.ReadWriteCloser); ok && resp.StatusCode == http.StatusSwitchingProtocols {
+			// ReverseProxy копирует данные между клиентом и этим соединением, пока одна из сторон его не закроет
+			upgraded = newUpgradedConn(rwc, backendURL, opts.Timeouts.UpgradeIdle.Std(), cancel)
+			p.trackUpgrade(upgraded)
+			resp.Body = upgraded
+		} else {
+			if idle := opts.Timeouts.IdleRead.Std(); idle > 0 {
+				resp.Body = newIdleTimeoutBody(resp.Body, idle, cancel)
+			}
+			if isStreaming(resp) {
+				// Поток может длиться дольше write_timeout сервера; его ограничивают request и idle_read
+				if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+					log.WarnKV("Failed to clear write deadline for streaming response", "url", backendURL, "error", err)
+				}
+				log.DebugKV("Streaming response", "url", backendURL, "content_type", resp.Header.Get("Content-Type"))
+			}
+		}
+		return modify(resp)
+	}
+	r = r.WithContext(ctx)
+	if opts.Forwarding.XForwardedFor == models.ForwardedForOff {
+		// ReverseProxy дописывает X-Forwarded-For по RemoteAddr; без адреса заголовок остается как есть
+		r.RemoteAddr = ""
+	}
 	// Сохраняем исходный ResponseWriter для проверки статуса
 	var recorder *httptest.ResponseRecorder
 	if rw, ok := w.(*httptest.ResponseRecorder); ok {
 		recorder = rw
 	}
 
-	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		logger.WarnKV("Proxy error", "url", backendURL, "method", r.Method, "error", err)
-		w.WriteHeader(http.StatusBadGateway)
+	var proxyErr error
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		status := http.StatusBadGateway
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			// Тело запроса превысило лимит сервера во время передачи бэкенду
+			status = http.StatusRequestEntityTooLarge
+		case isTimeout(err):
+			status = http.StatusGatewayTimeout
+		}
+		log.WarnKV("Proxy error", "url", backendURL, "method", req.Method, "status", status, "error", err)
+		span := trace.SpanFromContext(req.Context())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "proxy error")
+		proxyErr = err
+		p.writeError(w, req, status, err)
 	}
 
+	if opts.FlushInterval > 0 && !upgrade {
+		fw := newFlushWriter(w, opts.FlushInterval)
+		defer fw.stop()
+		w = fw
+	}
 	proxy.ServeHTTP(w, r)
+	if upgraded != nil {
+		upgraded.Close()
+		p.untrackUpgrade(upgraded)
+		log.InfoKV("Upgraded connection closed", "url", backendURL, "protocol", r.Header.Get("Upgrade"), "duration", time.Since(received), "reason", upgraded.closeReason(ctx))
+	}
 
+	if proxyErr != nil {
+		return fmt.Errorf("proxy failed: %w", proxyErr)
+	}
 	// Проверяем, был ли записан код ошибки
 	if recorder != nil && recorder.Code >= 400 {
 		return fmt.Errorf("proxy failed with status %d", recorder.Code)
 	}
 	return nil
 }
+
+// writeError отправляет клиенту ответ об ошибке обращения к бэкенду.
+func (p *Proxy) writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if p.errorHandler != nil {
+		p.errorHandler(w, r, status, err)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// configure добавляет к прокси выбор Host, переписывание пути, заголовки пересылки
+// и правила заголовков маршрута.
+func (p *Proxy) configure(proxy *httputil.ReverseProxy, r *http.Request, u *url.URL, backendURL string, received time.Time, opts *Options) {
+	rw := opts.Rewrite
+	publicScheme, publicHost := "http", r.Host
+	if r.TLS != nil {
+		publicScheme = "https"
+	}
+	data := newTemplateData(r, backendURL, received)
+	forwarded := newForwardedFor(r)
+	clientCert := tlsconfig.VerifiedClientCert(r.TLS)
+
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		if opts.BackendHost {
+			req.Host = u.Host
+		}
+		// Путь переписывается до того, как Director присоединит к нему путь бэкенда
+		if rw != nil {
+			rw.apply(req)
+		}
+		director(req)
+		applyForwarding(req, opts.Forwarding, forwarded)
+		applyClientCert(req, opts.ClientCert, clientCert)
+		for _, hs := range opts.Headers {
+			if hs != nil {
+				hs.applyRequest(req, data)
+			}
+		}
+	}
+	requestID := requestid.FromContext(r.Context())
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		// Статус ответа записывается в спан обращения к бэкенду, если он есть в контексте
+		span := trace.SpanFromContext(resp.Request.Context())
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+		if requestID != "" {
+			// Клиенту возвращается ID, назначенный балансировщиком, а не ID из ответа бэкенда
+			resp.Header.Del(requestid.Header)
+		}
+		if rw != nil {
+			rw.modifyResponse(resp, u, publicScheme, publicHost)
+		}
+		for _, hs := range opts.Headers {
+			if hs != nil {
+				hs.applyResponse(resp, data)
+			}
+		}
+		return nil
+	}
+}
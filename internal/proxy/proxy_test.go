@@ -1,12 +1,20 @@
 package proxy
 
 import (
+	"crypto/tls"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig/tlstest"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func TestMain(m *testing.M) {
@@ -57,3 +65,131 @@ func TestProxy_Forward_UnavailableBackend(t *testing.T) {
 		t.Errorf("Expected status 502, got %v", rr.Code)
 	}
 }
+
+func TestProxy_ForwardUpstreamTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCert := tlstest.NewCert(t, dir, "backend.internal")
+	clientCert := tlstest.NewCert(t, dir, "lb.internal")
+
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	backendServer.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.TLS},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCert.Pool(),
+	}
+	backendServer.StartTLS()
+	defer backendServer.Close()
+
+	mtls := models.UpstreamTLSConfig{CAFile: serverCert.CertFile, CertFile: clientCert.CertFile, KeyFile: clientCert.KeyFile, ServerName: "backend.internal"}
+	withoutName := mtls
+	withoutName.ServerName = ""
+	insecureSkip := withoutName
+	insecureSkip.InsecureSkipVerify = true
+	withoutClientCert := models.UpstreamTLSConfig{CAFile: serverCert.CertFile, ServerName: "backend.internal"}
+
+	tests := []struct {
+		name string
+		tls  *models.UpstreamTLSConfig
+		want int
+	}{
+		{"Untrusted backend certificate", nil, http.StatusBadGateway},
+		{"No client certificate", &withoutClientCert, http.StatusBadGateway},
+		{"Server name does not match the URL host", &withoutName, http.StatusBadGateway},
+		{"Mutual TLS", &mtls, http.StatusOK},
+		{"Insecure skip verify", &insecureSkip, http.StatusOK},
+	}
+	p := NewProxy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			p.ForwardWith(rr, httptest.NewRequest("GET", "/", nil), backendServer.URL, &Options{TLS: tt.tls})
+			if rr.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, rr.Code)
+			}
+			if tt.want == http.StatusOK && rr.Body.String() != "lb.internal" {
+				t.Errorf("Expected backend to see client certificate lb.internal, got %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxy_ForwardProtocol(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte(r.Proto))
+		w.Header().Set("Grpc-Status", "0")
+	})
+	h2cServer := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer h2cServer.Close()
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	insecure := &models.UpstreamTLSConfig{InsecureSkipVerify: true}
+
+	tests := []struct {
+		name       string
+		backendURL string
+		opts       Options
+		want       string
+	}{
+		{"Plain HTTP by default", h2cServer.URL, Options{}, "HTTP/1.1"},
+		{"h2c with prior knowledge", h2cServer.URL, Options{Protocol: models.ProtocolH2C}, "HTTP/2.0"},
+		{"HTTP/2 negotiated over TLS", tlsServer.URL, Options{TLS: insecure}, "HTTP/2.0"},
+		{"HTTP/1.1 forced over TLS", tlsServer.URL, Options{TLS: insecure, Protocol: models.ProtocolHTTP1}, "HTTP/1.1"},
+	}
+	p := NewProxy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			if err := p.ForwardWith(rr, httptest.NewRequest("POST", "/", nil), tt.backendURL, &tt.opts); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			resp := rr.Result()
+			if rr.Body.String() != tt.want {
+				t.Errorf("Expected backend to see %s, got %q", tt.want, rr.Body.String())
+			}
+			if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+				t.Errorf("Expected trailer Grpc-Status 0 to be passed through, got %q", got)
+			}
+		})
+	}
+}
+
+func TestProxy_ForwardUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := httptest.NewUnstartedServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto + " " + r.Host + r.URL.Path))
+	}), &http2.Server{}))
+	backend.Listener = ln
+	backend.Start()
+	defer backend.Close()
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"HTTP/1.1", Options{}, "HTTP/1.1 example.com/api"},
+		{"h2c", Options{Protocol: models.ProtocolH2C}, "HTTP/2.0 example.com/api"},
+		{"Backend host header", Options{BackendHost: true}, "HTTP/1.1 localhost/api"},
+	}
+	p := NewProxy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			if err := p.ForwardWith(rr, httptest.NewRequest("GET", "http://example.com/api", nil), "unix://"+socket, &tt.opts); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rr.Body.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"load-balancer/internal/models"
+)
+
+// Rewrite — скомпилированные правила переписывания запроса для маршрута.
+type Rewrite struct {
+	stripPrefix string // Префиксы хранятся в декодированном виде без завершающего слеша
+	addPrefix   string
+	regex       *regexp.Regexp
+	replacement string
+	host        string
+}
+
+// NewRewrite компилирует правила переписывания. Для nil-конфигурации возвращает nil.
+func NewRewrite(cfg *models.RewriteConfig) (*Rewrite, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	rw := &Rewrite{
+		stripPrefix: strings.TrimSuffix(cfg.StripPrefix, "/"),
+		addPrefix:   strings.TrimSuffix(cfg.AddPrefix, "/"),
+		replacement: cfg.Replacement,
+		host:        cfg.Host,
+	}
+	if cfg.Regex != "" {
+		re, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite regex: %w", err)
+		}
+		rw.regex = re
+	}
+	return rw, nil
+}
+
+// Path переписывает экранированный путь запроса: удаляет префикс, применяет регулярное
+// выражение и добавляет префикс. Результат всегда начинается со слеша.
+func (rw *Rewrite) Path(escaped string) string {
+	p := escaped
+	if rw.stripPrefix != "" {
+		if rest, ok := cutEscapedPrefix(p, rw.stripPrefix); ok {
+			p = rest
+		}
+	}
+	if rw.regex != nil {
+		p = rw.regex.ReplaceAllString(p, rw.replacement)
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	if rw.addPrefix != "" {
+		p = escapePath(rw.addPrefix) + p
+	}
+	return p
+}
+
+// PublicPath переводит путь бэкенда обратно в публичный: убирает добавленный префикс
+// и возвращает удаленный. Регулярные выражения не обращаются, поэтому при заданном regex
+// и для путей вне добавленного префикса возвращается false.
+func (rw *Rewrite) PublicPath(escaped string) (string, bool) {
+	if rw.regex != nil || (rw.stripPrefix == "" && rw.addPrefix == "") {
+		return escaped, false
+	}
+	p := escaped
+	if rw.addPrefix != "" {
+		rest, ok := cutEscapedPrefix(p, rw.addPrefix)
+		if !ok {
+			return escaped, false
+		}
+		p = rest
+	}
+	if rw.stripPrefix != "" {
+		p = escapePath(rw.stripPrefix) + p
+	}
+	return p, true
+}
+
+// apply переписывает путь и Host исходящего запроса.
+func (rw *Rewrite) apply(req *http.Request) {
+	setEscapedPath(req.URL, rw.Path(req.URL.EscapedPath()))
+	if rw.host != "" {
+		req.Host = rw.host
+	}
+}
+
+// modifyResponse возвращает публичный префикс в заголовки Location и Set-Cookie.
+// Абсолютный Location на сам бэкенд или на переопределенный Host переводится на публичный хост.
+func (rw *Rewrite) modifyResponse(resp *http.Response, backend *url.URL, publicScheme, publicHost string) {
+	if location := resp.Header.Get("Location"); location != "" {
+		if u, err := url.Parse(location); err == nil {
+			if rw.rewriteLocation(u, backend, publicScheme, publicHost) {
+				resp.Header.Set("Location", u.String())
+			}
+		}
+	}
+
+	cookies := resp.Header.Values("Set-Cookie")
+	for i, cookie := range cookies {
+		cookies[i] = rw.rewriteCookiePath(cookie)
+	}
+}
+
+// rewriteLocation изменяет URL из заголовка Location и сообщает, был ли он изменен.
+func (rw *Rewrite) rewriteLocation(u *url.URL, backend *url.URL, publicScheme, publicHost string) bool {
+	changed := false
+	if u.IsAbs() || u.Host != "" {
+		if !strings.EqualFold(u.Host, backend.Host) && (rw.host == "" || !strings.EqualFold(u.Host, rw.host)) {
+			return false
+		}
+		u.Scheme = publicScheme
+		u.Host = publicHost
+		changed = true
+	} else if !strings.HasPrefix(u.Path, "/") {
+		// Относительный путь разрешается клиентом от публичного URL
+		return false
+	}
+	if p, ok := rw.PublicPath(u.EscapedPath()); ok {
+		setEscapedPath(u, p)
+		changed = true
+	}
+	return changed
+}
+
+// rewriteCookiePath переписывает атрибут Path в значении заголовка Set-Cookie,
+// не затрагивая остальные атрибуты.
+func (rw *Rewrite) rewriteCookiePath(cookie string) string {
+	parts := strings.Split(cookie, ";")
+	for i, part := range parts {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || !strings.EqualFold(name, "path") {
+			continue
+		}
+		if p, ok := rw.PublicPath(value); ok {
+			parts[i] = " " + name + "=" + p
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// cutEscapedPrefix удаляет из экранированного пути префикс, заданный в декодированном виде.
+// Префикс должен заканчиваться на границе сегмента; остаток начинается со слеша.
+func cutEscapedPrefix(escaped, prefix string) (string, bool) {
+	for i := 1; i <= len(escaped); i++ {
+		if i < len(escaped) && escaped[i] != '/' {
+			continue
+		}
+		head, err := url.PathUnescape(escaped[:i])
+		if err != nil {
+			return "", false
+		}
+		if head == prefix {
+			rest := escaped[i:]
+			if rest == "" {
+				rest = "/"
+			}
+			return rest, true
+		}
+		if len(head) >= len(prefix) {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// escapePath экранирует путь, заданный в конфигурации.
+func escapePath(p string) string {
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+// setEscapedPath записывает экранированный путь в URL, сохраняя закодированные символы вроде %2F.
+func setEscapedPath(u *url.URL, escaped string) {
+	p, err := url.PathUnescape(escaped)
+	if err != nil {
+		u.Path, u.RawPath = escaped, ""
+		return
+	}
+	u.Path, u.RawPath = p, escaped
+}
@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"load-balancer/internal/models"
+)
+
+func TestRewrite_Path(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  models.RewriteConfig
+		in   string
+		want string
+	}{
+		{name: "Strip prefix", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/billing/v1/x", want: "/v1/x"},
+		{name: "Strip prefix with trailing slash", cfg: models.RewriteConfig{StripPrefix: "/billing/"}, in: "/billing/v1/x", want: "/v1/x"},
+		{name: "Strip whole path", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/billing", want: "/"},
+		{name: "Strip keeps trailing slash", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/billing/", want: "/"},
+		{name: "Strip only at segment boundary", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/billingx/v1", want: "/billingx/v1"},
+		{name: "Strip keeps encoded slash", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/billing/a%2Fb", want: "/a%2Fb"},
+		{name: "Strip encoded prefix", cfg: models.RewriteConfig{StripPrefix: "/my files"}, in: "/my%20files/doc", want: "/doc"},
+		{name: "Encoded slash is not a segment boundary", cfg: models.RewriteConfig{StripPrefix: "/a"}, in: "/a%2Fb/c", want: "/a%2Fb/c"},
+		{name: "Double slash after prefix", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/billing//x", want: "//x"},
+		{name: "Add prefix", cfg: models.RewriteConfig{AddPrefix: "/api"}, in: "/v1/x", want: "/api/v1/x"},
+		{name: "Add prefix with trailing slash to root", cfg: models.RewriteConfig{AddPrefix: "/api/"}, in: "/", want: "/api/"},
+		{name: "Add prefix is escaped", cfg: models.RewriteConfig{AddPrefix: "/my files"}, in: "/x", want: "/my%20files/x"},
+		{name: "Strip and add", cfg: models.RewriteConfig{StripPrefix: "/public", AddPrefix: "/internal"}, in: "/public/x", want: "/internal/x"},
+		{name: "Regex with capture groups", cfg: models.RewriteConfig{Regex: `^/users/(\d+)/orders$`, Replacement: "/orders/by-user/$1"}, in: "/users/42/orders", want: "/orders/by-user/42"},
+		{name: "Regex with named group", cfg: models.RewriteConfig{Regex: `^/v(?P<version>\d+)/(.*)$`, Replacement: "/${2}/version-${version}"}, in: "/v2/items", want: "/items/version-2"},
+		{name: "Regex result gets leading slash", cfg: models.RewriteConfig{Regex: `^/old/`, Replacement: ""}, in: "/old/page", want: "/page"},
+		{name: "Regex after strip", cfg: models.RewriteConfig{StripPrefix: "/billing", Regex: `^/v1/`, Replacement: "/v2/"}, in: "/billing/v1/x", want: "/v2/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw, err := NewRewrite(&tt.cfg)
+			if err != nil {
+				t.Fatalf("NewRewrite failed: %v", err)
+			}
+			if got := rw.Path(tt.in); got != tt.want {
+				t.Errorf("Path(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewrite_PublicPath(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  models.RewriteConfig
+		in   string
+		want string
+		ok   bool
+	}{
+		{name: "Restore stripped prefix", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/v1/x", want: "/billing/v1/x", ok: true},
+		{name: "Restore stripped prefix at root", cfg: models.RewriteConfig{StripPrefix: "/billing"}, in: "/", want: "/billing/", ok: true},
+		{name: "Remove added prefix", cfg: models.RewriteConfig{AddPrefix: "/api"}, in: "/api/x", want: "/x", ok: true},
+		{name: "Path outside added prefix", cfg: models.RewriteConfig{AddPrefix: "/api"}, in: "/other", want: "/other", ok: false},
+		{name: "Swap prefixes back", cfg: models.RewriteConfig{StripPrefix: "/public", AddPrefix: "/internal"}, in: "/internal/a%2Fb", want: "/public/a%2Fb", ok: true},
+		{name: "Regex is not reversed", cfg: models.RewriteConfig{StripPrefix: "/x", Regex: "a", Replacement: "b"}, in: "/b", want: "/b", ok: false},
+		{name: "Host only", cfg: models.RewriteConfig{Host: "internal"}, in: "/x", want: "/x", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw, _ := NewRewrite(&tt.cfg)
+			got, ok := rw.PublicPath(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("PublicPath(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestProxy_ForwardWithRewrite(t *testing.T) {
+	var gotPath, gotRawPath, gotHost string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotRawPath, gotHost = r.URL.Path, r.URL.RawPath, r.Host
+		w.Header().Set("Location", "http://"+r.Host+"/v1/login?next=%2Fv1")
+		w.Header().Add("Set-Cookie", "session=abc; Path=/v1; HttpOnly")
+		w.Header().Add("Set-Cookie", "theme=dark; path=/")
+		w.Header().Add("Set-Cookie", "plain=1")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer backendServer.Close()
+
+	rw, err := NewRewrite(&models.RewriteConfig{StripPrefix: "/billing", Host: "billing.internal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "http://lb.example.com/billing/v1/a%2Fb", nil)
+	rr := httptest.NewRecorder()
+	if err := NewProxy().ForwardWith(rr, req, backendServer.URL, &Options{Rewrite: rw}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if gotPath != "/v1/a/b" || gotRawPath != "/v1/a%2Fb" {
+		t.Errorf("Expected backend path /v1/a%%2Fb, got path %q raw %q", gotPath, gotRawPath)
+	}
+	if gotHost != "billing.internal" {
+		t.Errorf("Expected Host billing.internal, got %q", gotHost)
+	}
+	if loc := rr.Header().Get("Location"); loc != "http://lb.example.com/billing/v1/login?next=%2Fv1" {
+		t.Errorf("Expected Location rewritten to the public prefix, got %q", loc)
+	}
+	cookies := rr.Header().Values("Set-Cookie")
+	want := []string{"session=abc; Path=/billing/v1; HttpOnly", "theme=dark; path=/billing/", "plain=1"}
+	if len(cookies) != len(want) {
+		t.Fatalf("Expected %d cookies, got %v", len(want), cookies)
+	}
+	for i := range want {
+		if cookies[i] != want[i] {
+			t.Errorf("Expected cookie %q, got %q", want[i], cookies[i])
+		}
+	}
+}
+
+func TestProxy_ForwardWithRewrite_ForeignLocation(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "https://sso.example.com/v1/login")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer backendServer.Close()
+
+	rw, _ := NewRewrite(&models.RewriteConfig{StripPrefix: "/billing"})
+	req := httptest.NewRequest("GET", "/billing/v1", nil)
+	rr := httptest.NewRecorder()
+	NewProxy().ForwardWith(rr, req, backendServer.URL, &Options{Rewrite: rw})
+
+	if loc := rr.Header().Get("Location"); loc != "https://sso.example.com/v1/login" {
+		t.Errorf("Expected redirect to another host to be left alone, got %q", loc)
+	}
+}
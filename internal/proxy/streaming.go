@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// isStreaming сообщает, что ответ бэкенда потоковый: Server-Sent Events или тело
+// без известной длины (chunked), например long polling или gRPC-поток.
+func isStreaming(resp *http.Response) bool {
+	if ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); ct == "text/event-stream" {
+		return true
+	}
+	return resp.ContentLength == -1
+}
+
+// flushWriter откладывает отправку данных клиенту не более чем на interval. ReverseProxy
+// сбрасывает потоковый ответ после каждой записи; с flushWriter частые мелкие записи
+// уходят клиенту одной порцией.
+type flushWriter struct {
+	http.ResponseWriter
+	interval time.Duration
+
+	mu      sync.Mutex // Запись и отложенный сброс не должны идти одновременно
+	timer   *time.Timer
+	pending bool // Сброс уже запланирован
+	done    bool // Обработчик завершился, писать в ResponseWriter больше нельзя
+}
+
+func newFlushWriter(w http.ResponseWriter, interval time.Duration) *flushWriter {
+	return &flushWriter{ResponseWriter: w, interval: interval}
+}
+
+func (w *flushWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush планирует сброс через interval, если он еще не запланирован.
+func (w *flushWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending || w.done {
+		return
+	}
+	w.pending = true
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.interval, w.flush)
+	} else {
+		w.timer.Reset(w.interval)
+	}
+}
+
+func (w *flushWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = false
+	if !w.done {
+		http.NewResponseController(w.ResponseWriter).Flush()
+	}
+}
+
+// stop отменяет запланированный сброс; остаток ответа отправит сам сервер после обработчика.
+func (w *flushWriter) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного writer (дедлайны).
+func (w *flushWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
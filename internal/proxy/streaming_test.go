@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startStreamingBackend starts a backend that sends events of the given content type one by one:
+// the next event is written only after the test signals on the returned channel.
+func startStreamingBackend(t *testing.T, contentType string, events int) (string, chan<- struct{}) {
+	t.Helper()
+	next := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		for i := 0; i < events; i++ {
+			if i > 0 {
+				select {
+				case <-next:
+				case <-r.Context().Done():
+					return
+				}
+			}
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(backend.Close)
+	return backend.URL, next
+}
+
+func TestProxy_ForwardStreaming(t *testing.T) {
+	const events = 3
+	const writeTimeout = 100 * time.Millisecond
+	tests := []struct {
+		name        string
+		contentType string
+		flush       time.Duration
+	}{
+		{"Server-Sent Events", "text/event-stream", 0},
+		{"Chunked response", "text/plain", 0},
+		{"Flush interval", "text/event-stream", 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backendURL, next := startStreamingBackend(t, tt.contentType, events)
+			p := NewProxy()
+			front := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p.ForwardWith(w, r, backendURL, &Options{FlushInterval: tt.flush})
+			}))
+			// The stream lasts longer than the write timeout, which must not cut it off
+			front.Config.WriteTimeout = writeTimeout
+			front.Start()
+			defer front.Close()
+
+			client := &http.Client{Timeout: 5 * time.Second}
+			resp, err := client.Get(front.URL)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			reader := bufio.NewReader(resp.Body)
+			for i := 0; i < events; i++ {
+				if i > 0 {
+					time.Sleep(writeTimeout)
+					next <- struct{}{}
+				}
+				sent := time.Now()
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					t.Fatalf("Event %d: read failed: %v", i, err)
+				}
+				if want := fmt.Sprintf("data: %d\n", i); line != want {
+					t.Fatalf("Event %d: expected %q, got %q", i, want, line)
+				}
+				reader.ReadString('\n')
+				// The last event is sent when the response ends, without waiting for the interval
+				if i > 0 && i < events-1 && time.Since(sent) < tt.flush*3/4 {
+					t.Errorf("Event %d arrived after %v, expected a delay of about %v", i, time.Since(sent), tt.flush)
+				}
+			}
+		})
+	}
+}
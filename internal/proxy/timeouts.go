@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig"
+
+	"golang.org/x/net/http2"
+)
+
+// transportKey — настройки, которыми различаются транспорты к бэкендам.
+type transportKey struct {
+	connect        time.Duration
+	tlsHandshake   time.Duration
+	responseHeader time.Duration
+	tls            models.UpstreamTLSConfig
+	customTLS      bool // Для бэкенда заданы настройки TLS, даже если все поля пустые
+	protocol       string
+	socket         string // Unix-сокет бэкенда, у каждого сокета свой пул соединений
+}
+
+// defaultConnectTimeout — таймаут подключения транспорта Go по умолчанию, он же для h2c.
+const defaultConnectTimeout = 30 * time.Second
+
+// transport возвращает транспорт с таймаутами подключения, TLS и ожидания заголовков ответа,
+// с настройками TLS и протоколом бэкенда; с непустым socket транспорт подключается к этому
+// Unix-сокету. Транспорты кэшируются, чтобы запросы с одинаковыми настройками разделяли пул соединений.
+func (p *Proxy) transport(opts *Options, socket string) (http.RoundTripper, error) {
+	t := opts.Timeouts
+	key := transportKey{connect: t.Connect.Std(), tlsHandshake: t.TLSHandshake.Std(), responseHeader: t.ResponseHeader.Std(), protocol: opts.Protocol, socket: socket}
+	if opts.TLS != nil {
+		key.tls, key.customTLS = *opts.TLS, true
+	}
+	if key == (transportKey{}) {
+		return http.DefaultTransport, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if tr, ok := p.transports[key]; ok {
+		return tr, nil
+	}
+	var tr http.RoundTripper
+	if key.protocol == models.ProtocolH2C {
+		tr = newH2CTransport(key)
+	} else {
+		httpTransport, err := newHTTPTransport(key, opts.TLS)
+		if err != nil {
+			return nil, err
+		}
+		tr = httpTransport
+	}
+	if p.transports == nil {
+		p.transports = make(map[transportKey]http.RoundTripper)
+	}
+	p.transports[key] = tr
+	return tr, nil
+}
+
+// newHTTPTransport создает транспорт HTTP/1.1, который с https-бэкендами договаривается
+// об HTTP/2 через ALPN, если протокол не ограничен http1.
+func newHTTPTransport(key transportKey, upstreamTLS *models.UpstreamTLSConfig) (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if key.connect > 0 || key.socket != "" {
+		dialer := &net.Dialer{Timeout: key.connect, KeepAlive: 30 * time.Second}
+		tr.DialContext = dialer.DialContext
+		if key.socket != "" {
+			tr.DialContext = dialUnix(dialer, key.socket)
+		}
+	}
+	if key.tlsHandshake > 0 {
+		tr.TLSHandshakeTimeout = key.tlsHandshake
+	}
+	tr.ResponseHeaderTimeout = key.responseHeader
+	if upstreamTLS != nil {
+		tlsCfg, err := tlsconfig.Client(upstreamTLS)
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = tlsCfg
+	}
+	if key.protocol == models.ProtocolHTTP1 {
+		// Непустая карта без "h2" отключает HTTP/2 в транспорте
+		tr.ForceAttemptHTTP2 = false
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return tr, nil
+}
+
+// newH2CTransport создает транспорт HTTP/2 без TLS (prior knowledge), например для gRPC-серверов.
+// Таймаута заголовков ответа у него нет: ожидание ограничивают request и idle_read.
+func newH2CTransport(key transportKey) *http2.Transport {
+	connect := key.connect
+	if connect == 0 {
+		connect = defaultConnectTimeout
+	}
+	dialer := &net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}
+	dial := dialer.DialContext
+	if key.socket != "" {
+		dial = dialUnix(dialer, key.socket)
+	}
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+}
+
+// requestTimeout возвращает дедлайн обращения к бэкенду: из политики или из заголовка
+// X-Request-Timeout клиента, если он короче. Ноль — без дедлайна.
+func requestTimeout(r *http.Request, policy time.Duration) time.Duration {
+	requested, ok := parseRequestTimeout(r.Header.Get(models.RequestTimeoutHeader))
+	if !ok {
+		return policy
+	}
+	if policy > 0 && requested > policy {
+		return policy
+	}
+	return requested
+}
+
+// parseRequestTimeout разбирает значение X-Request-Timeout: длительность ("1.5s") или миллисекунды ("1500").
+func parseRequestTimeout(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if ms <= 0 {
+			return 0, false
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// isTimeout сообщает, что обращение к бэкенду прервано по таймауту.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// idleTimeoutBody прерывает чтение тела ответа, если очередная порция данных
+// не пришла за timeout. Отмена контекста закрывает соединение с бэкендом.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	timer := time.AfterFunc(timeout, cancel)
+	timer.Stop()
+	return &idleTimeoutBody{ReadCloser: body, timeout: timeout, timer: timer}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	// Учитывается только ожидание данных от бэкенда, а не запись клиенту между чтениями
+	b.timer.Reset(b.timeout)
+	n, err := b.ReadCloser.Read(p)
+	b.timer.Stop()
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,177 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// slowBackend answers after delay or when the test ends, whichever comes first.
+func slowBackend(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-done:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte("late"))
+	}))
+	t.Cleanup(func() {
+		close(done)
+		srv.Close()
+	})
+	return srv
+}
+
+func TestProxy_ForwardTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts models.TimeoutsConfig
+		header   string
+		want     int
+	}{
+		{
+			name:     "Response header timeout",
+			timeouts: models.TimeoutsConfig{ResponseHeader: models.Duration(50 * time.Millisecond)},
+			want:     http.StatusGatewayTimeout,
+		},
+		{
+			name:     "Request deadline",
+			timeouts: models.TimeoutsConfig{Request: models.Duration(50 * time.Millisecond)},
+			want:     http.StatusGatewayTimeout,
+		},
+		{
+			name:   "Client header shortens the deadline",
+			header: "50",
+			want:   http.StatusGatewayTimeout,
+		},
+		{
+			name:     "Client header is capped by policy",
+			timeouts: models.TimeoutsConfig{Request: models.Duration(50 * time.Millisecond)},
+			header:   "1m",
+			want:     http.StatusGatewayTimeout,
+		},
+		{
+			name:     "Backend within the deadline",
+			timeouts: models.TimeoutsConfig{Request: models.Duration(5 * time.Second)},
+			header:   "invalid",
+			want:     http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay := 2 * time.Second
+			if tt.want == http.StatusOK {
+				delay = 10 * time.Millisecond
+			}
+			backend := slowBackend(t, delay)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(models.RequestTimeoutHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			start := time.Now()
+			err := NewProxy().ForwardWith(rr, req, backend.URL, &Options{Timeouts: tt.timeouts})
+			elapsed := time.Since(start)
+
+			if rr.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, rr.Code)
+			}
+			if tt.want == http.StatusOK {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if elapsed > time.Second {
+				t.Errorf("Expected the request to be cut short, took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestProxy_ForwardErrorHandler(t *testing.T) {
+	backend := slowBackend(t, 2*time.Second)
+	var gotStatus int
+	p := NewProxy()
+	p.SetErrorHandler(func(w http.ResponseWriter, r *http.Request, status int, err error) {
+		gotStatus = status
+		w.WriteHeader(status)
+		w.Write([]byte("custom"))
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	p.ForwardWith(rr, req, backend.URL, &Options{Timeouts: models.TimeoutsConfig{Request: models.Duration(50 * time.Millisecond)}})
+	if gotStatus != http.StatusGatewayTimeout {
+		t.Errorf("Expected error handler to get 504, got %d", gotStatus)
+	}
+	if rr.Body.String() != "custom" {
+		t.Errorf("Expected body from error handler, got %q", rr.Body.String())
+	}
+
+	gotStatus = 0
+	rr = httptest.NewRecorder()
+	p.Forward(rr, httptest.NewRequest(http.MethodGet, "/", nil), "http://nonexistent:9999")
+	if gotStatus != http.StatusBadGateway {
+		t.Errorf("Expected error handler to get 502, got %d", gotStatus)
+	}
+}
+
+func TestProxy_ForwardIdleReadTimeout(t *testing.T) {
+	done := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(2 * time.Second):
+		case <-done:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte("second"))
+	}))
+	defer backend.Close()
+	defer close(done)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	start := time.Now()
+	NewProxy().ForwardWith(rr, req, backend.URL, &Options{Timeouts: models.TimeoutsConfig{IdleRead: models.Duration(50 * time.Millisecond)}})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the stalled body to be cut off, took %v", elapsed)
+	}
+	if rr.Body.String() != "first" {
+		t.Errorf("Expected only the first chunk, got %q", rr.Body.String())
+	}
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"1500", 1500 * time.Millisecond, true},
+		{"2s", 2 * time.Second, true},
+		{" 250ms ", 250 * time.Millisecond, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"-5s", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRequestTimeout(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRequestTimeout(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
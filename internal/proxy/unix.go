@@ -0,0 +1,30 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/url"
+
+	"load-balancer/internal/models"
+)
+
+// unixHost — хост в URL запросов к бэкендам на Unix-сокетах: соединение открывает транспорт
+// этого сокета, а хост нужен только для Host при host_header backend.
+const unixHost = "localhost"
+
+// unixTarget возвращает для бэкенда unix:///path/to.sock адрес http://localhost и путь сокета,
+// для остальных бэкендов — u без изменений и пустой путь.
+func unixTarget(u *url.URL, backendURL string) (*url.URL, string) {
+	socket, ok := models.UnixSocket(backendURL)
+	if !ok {
+		return u, ""
+	}
+	return &url.URL{Scheme: "http", Host: unixHost}, socket
+}
+
+// dialUnix возвращает функцию подключения к сокету вместо адреса запроса.
+func dialUnix(dialer *net.Dialer, socket string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// Причины закрытия upgrade-соединения балансировщиком.
+var (
+	errUpgradeIdle     = errors.New("idle timeout")
+	errUpgradeLifetime = errors.New("max lifetime reached")
+	errUpgradeClosed   = errors.New("closed by the balancer")
+)
+
+// isUpgrade сообщает, что клиент просит сменить протокол соединения (WebSocket и т.п.).
+func isUpgrade(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" && httpguts.HeaderValuesContainsToken(r.Header["Connection"], "Upgrade")
+}
+
+// upgradedConn — соединение с бэкендом после смены протокола. Учитывает активность
+// в обе стороны для таймаута простоя и позволяет закрыть соединение извне через cancel:
+// отмена контекста запроса закрывает соединение с бэкендом, а ReverseProxy — и с клиентом.
+type upgradedConn struct {
+	io.ReadWriteCloser
+	backendURL   string
+	idle         time.Duration
+	lastActivity atomic.Int64 // UnixNano последней передачи данных
+	timer        *time.Timer
+	cancel       context.CancelFunc
+	reason       atomic.Pointer[error]
+}
+
+func newUpgradedConn(rwc io.ReadWriteCloser, backendURL string, idle time.Duration, cancel context.CancelFunc) *upgradedConn {
+	c := &upgradedConn{ReadWriteCloser: rwc, backendURL: backendURL, idle: idle, cancel: cancel}
+	c.touch()
+	if idle > 0 {
+		c.timer = time.AfterFunc(idle, c.checkIdle)
+	}
+	return c
+}
+
+func (c *upgradedConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	if n > 0 {
+		c.touch()
+	}
+	return n, err
+}
+
+func (c *upgradedConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	if n > 0 {
+		c.touch()
+	}
+	return n, err
+}
+
+func (c *upgradedConn) Close() error {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	return c.ReadWriteCloser.Close()
+}
+
+func (c *upgradedConn) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// checkIdle закрывает соединение, если данных не было дольше idle, иначе ждет остаток.
+// Таймер перезапускает только сам обработчик, поэтому Read и Write его не трогают.
+func (c *upgradedConn) checkIdle() {
+	quiet := time.Since(time.Unix(0, c.lastActivity.Load()))
+	if quiet >= c.idle {
+		c.close(errUpgradeIdle)
+		return
+	}
+	c.timer.Reset(c.idle - quiet)
+}
+
+// close прерывает соединение и запоминает причину, если она еще не задана.
+func (c *upgradedConn) close(reason error) {
+	c.reason.CompareAndSwap(nil, &reason)
+	c.cancel()
+}
+
+// closeReason возвращает причину закрытия соединения для журнала; ctx — контекст запроса,
+// дедлайн которого ограничивает срок жизни соединения.
+func (c *upgradedConn) closeReason(ctx context.Context) string {
+	if reason := c.reason.Load(); reason != nil {
+		return (*reason).Error()
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errUpgradeLifetime.Error()
+	}
+	return "closed by peer"
+}
+
+// trackUpgrade регистрирует upgrade-соединение с бэкендом.
+func (p *Proxy) trackUpgrade(c *upgradedConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.upgrades == nil {
+		p.upgrades = make(map[string]map[*upgradedConn]struct{})
+	}
+	conns := p.upgrades[c.backendURL]
+	if conns == nil {
+		conns = make(map[*upgradedConn]struct{})
+		p.upgrades[c.backendURL] = conns
+	}
+	conns[c] = struct{}{}
+}
+
+// untrackUpgrade удаляет завершенное upgrade-соединение из учета.
+func (p *Proxy) untrackUpgrade(c *upgradedConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.upgrades[c.backendURL], c)
+	if len(p.upgrades[c.backendURL]) == 0 {
+		delete(p.upgrades, c.backendURL)
+	}
+}
+
+// Upgraded возвращает число открытых upgrade-соединений (WebSocket и т.п.) с бэкендом.
+func (p *Proxy) Upgraded(backendURL string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.upgrades[backendURL])
+}
+
+// CloseUpgraded закрывает upgrade-соединения с бэкендом, а при пустом backendURL — со всеми
+// бэкендами, и возвращает их число. Соединения закрываются асинхронно: обработчики запросов
+// завершаются, когда копирование данных прервется.
+func (p *Proxy) CloseUpgraded(backendURL string) int {
+	p.mu.Lock()
+	var conns []*upgradedConn
+	for u, set := range p.upgrades {
+		if backendURL != "" && u != backendURL {
+			continue
+		}
+		for c := range set {
+			conns = append(conns, c)
+		}
+	}
+	p.mu.Unlock()
+	for _, c := range conns {
+		c.close(errUpgradeClosed)
+	}
+	return len(conns)
+}
@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/testutil"
+
+	"golang.org/x/net/websocket"
+)
+
+// startEchoBackend starts an in-process WebSocket server that echoes every message back.
+func startEchoBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			if err := websocket.Message.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+// startUpgradeProxy starts a front server that proxies to backendURL with opts. Its read and
+// write timeouts are much shorter than the tests, so upgraded connections must not inherit them.
+func startUpgradeProxy(t *testing.T, p *Proxy, backendURL string, opts *Options) string {
+	t.Helper()
+	front := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.ForwardWith(w, r, backendURL, opts)
+	}))
+	front.Config.ReadTimeout = 50 * time.Millisecond
+	front.Config.WriteTimeout = 50 * time.Millisecond
+	front.Start()
+	t.Cleanup(front.Close)
+	return "ws://" + strings.TrimPrefix(front.URL, "http://") + "/"
+}
+
+// echo sends msg over ws and returns the reply.
+func echo(ws *websocket.Conn, msg string) (string, error) {
+	ws.SetDeadline(time.Now().Add(2 * time.Second))
+	if err := websocket.Message.Send(ws, msg); err != nil {
+		return "", err
+	}
+	var reply string
+	err := websocket.Message.Receive(ws, &reply)
+	return reply, err
+}
+
+func TestProxy_ForwardWebSocket(t *testing.T) {
+	backend := startEchoBackend(t)
+	p := NewProxy()
+	// The request timeouts must not cut off upgraded connections
+	url := startUpgradeProxy(t, p, backend.URL, &Options{Timeouts: models.TimeoutsConfig{
+		Request:  models.Duration(100 * time.Millisecond),
+		IdleRead: models.Duration(50 * time.Millisecond),
+	}})
+
+	ws, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Failed to open WebSocket: %v", err)
+	}
+	if reply, err := echo(ws, "hello"); err != nil || reply != "hello" {
+		t.Fatalf("Expected echo of hello, got %q, %v", reply, err)
+	}
+	if n := p.Upgraded(backend.URL); n != 1 {
+		t.Errorf("Expected 1 upgraded connection, got %d", n)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if reply, err := echo(ws, "still there"); err != nil || reply != "still there" {
+		t.Fatalf("Expected connection to outlive server and request timeouts, got %q, %v", reply, err)
+	}
+
+	ws.Close()
+	if !testutil.WaitFor(func() bool { return p.Upgraded(backend.URL) == 0 }) {
+		t.Errorf("Expected closed connection to be untracked, got %d", p.Upgraded(backend.URL))
+	}
+}
+
+func TestProxy_UpgradeLimits(t *testing.T) {
+	backend := startEchoBackend(t)
+
+	tests := []struct {
+		name     string
+		timeouts models.TimeoutsConfig
+		// activity runs while the limit would expire; the connection must then be closed
+		activity func(ws *websocket.Conn) error
+		closed   bool
+	}{
+		{
+			name:     "Idle timeout",
+			timeouts: models.TimeoutsConfig{UpgradeIdle: models.Duration(100 * time.Millisecond)},
+			activity: func(*websocket.Conn) error { time.Sleep(300 * time.Millisecond); return nil },
+			closed:   true,
+		},
+		{
+			name:     "Traffic keeps an idle connection open",
+			timeouts: models.TimeoutsConfig{UpgradeIdle: models.Duration(150 * time.Millisecond)},
+			activity: func(ws *websocket.Conn) error {
+				for i := 0; i < 6; i++ {
+					time.Sleep(50 * time.Millisecond)
+					if _, err := echo(ws, "ping"); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name:     "Max lifetime",
+			timeouts: models.TimeoutsConfig{UpgradeLifetime: models.Duration(200 * time.Millisecond)},
+			activity: func(ws *websocket.Conn) error {
+				for i := 0; i < 6; i++ {
+					time.Sleep(50 * time.Millisecond)
+					if _, err := echo(ws, "ping"); err != nil {
+						return nil // Closed mid-way, checked below
+					}
+				}
+				return nil
+			},
+			closed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := websocket.Dial(startUpgradeProxy(t, NewProxy(), backend.URL, &Options{Timeouts: tt.timeouts}), "", "http://localhost/")
+			if err != nil {
+				t.Fatalf("Failed to open WebSocket: %v", err)
+			}
+			defer ws.Close()
+			if err := tt.activity(ws); err != nil {
+				t.Fatalf("Unexpected error during activity: %v", err)
+			}
+			_, err = echo(ws, "after")
+			if closed := err != nil; closed != tt.closed {
+				t.Errorf("Expected closed=%v, got error %v", tt.closed, err)
+			}
+		})
+	}
+}
+
+func TestProxy_CloseUpgraded(t *testing.T) {
+	backend := startEchoBackend(t)
+	p := NewProxy()
+	url := startUpgradeProxy(t, p, backend.URL, nil)
+
+	var conns []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		ws, err := websocket.Dial(url, "", "http://localhost/")
+		if err != nil {
+			t.Fatalf("Failed to open WebSocket: %v", err)
+		}
+		defer ws.Close()
+		if _, err := echo(ws, "hello"); err != nil {
+			t.Fatalf("Echo failed: %v", err)
+		}
+		conns = append(conns, ws)
+	}
+
+	if n := p.CloseUpgraded("http://other-backend"); n != 0 {
+		t.Errorf("Expected no connections to another backend, closed %d", n)
+	}
+	if n := p.CloseUpgraded(backend.URL); n != 2 {
+		t.Errorf("Expected 2 closed connections, got %d", n)
+	}
+	for i, ws := range conns {
+		if _, err := echo(ws, "after"); err == nil {
+			t.Errorf("Connection %d: expected to be closed", i)
+		}
+	}
+	if !testutil.WaitFor(func() bool { return p.Upgraded(backend.URL) == 0 }) {
+		t.Errorf("Expected no upgraded connections, got %d", p.Upgraded(backend.URL))
+	}
+}
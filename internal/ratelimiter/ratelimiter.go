@@ -2,6 +2,7 @@ package ratelimiter
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -14,10 +15,18 @@ import (
 // RateLimiterInterface определяет методы для управления ограничением скорости запросов.
 type RateLimiterInterface interface {
 	Allow(clientID string) bool
+	AllowContext(ctx context.Context, clientID string) bool
+	AllowPacket(clientID string) bool
 	Update(capacity, rate float64)
 	UpdateClient(clientID string, capacity, rate float64)
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
 }
 
+// packetSaveInterval — как часто AllowPacket сохраняет бакет клиента в Redis: при потоке
+// датаграмм запись на каждый пакет перегружала бы Redis.
+const packetSaveInterval = time.Second
+
 // RateLimiter управляет ограничением скорости запросов на основе токен-бакета.
 type RateLimiter struct {
 	buckets         map[string]*TokenBucket
@@ -26,7 +35,8 @@ type RateLimiter struct {
 	clientConfigs   []models.ClientConfig
 	redisClient     *redis.Client
 	mu              sync.Mutex
-	syncRedis       bool // Флаг для синхронного сохранения в тестах
+	syncRedis       bool           // Флаг для синхронного сохранения в тестах
+	pending         sync.WaitGroup // Незавершенные асинхронные записи в Redis
 }
 
 // TokenBucket представляет токен-бакет для клиента.
@@ -35,6 +45,7 @@ type TokenBucket struct {
 	lastRefill time.Time
 	capacity   float64
 	rate       float64
+	savedAt    time.Time // Последнее сохранение в Redis из AllowPacket
 	mu         sync.Mutex
 }
 
@@ -66,75 +77,128 @@ func (rl *RateLimiter) SetSyncRedis(sync bool) {
 
 // Allow проверяет, разрешен ли запрос для указанного клиента.
 func (rl *RateLimiter) Allow(clientID string) bool {
-	rl.mu.Lock()
-	bucket, exists := rl.buckets[clientID]
-	if !exists {
-		capacity, rate := rl.defaultCapacity, rl.defaultRate
-		for _, cfg := range rl.clientConfigs {
-			if cfg.ClientID == clientID {
-				logger.InfoKV("Using client-specific rate limit", "clientID", clientID, "capacity", cfg.Capacity)
-				capacity, rate = float64(cfg.Capacity), cfg.Rate
-				break
-			}
-		}
-		bucket = &TokenBucket{
-			tokens:     capacity,
-			lastRefill: time.Now(),
-			capacity:   capacity,
-			rate:       rate,
-		}
-		rl.buckets[clientID] = bucket
-		logger.InfoKV("Creating new bucket", "clientID", clientID, "capacity", capacity)
-	}
-	rl.mu.Unlock()
+	return rl.AllowContext(context.Background(), clientID)
+}
+
+// AllowContext проверяет, разрешен ли запрос клиента, и пишет логи через логгер запроса из ctx.
+func (rl *RateLimiter) AllowContext(ctx context.Context, clientID string) bool {
+	log := logger.FromContext(ctx)
+	bucket := rl.bucket(log, clientID)
 
 	bucket.mu.Lock()
 	defer bucket.mu.Unlock()
 
 	// Пополняем токены
-	elapsed := time.Since(bucket.lastRefill).Seconds()
-	newTokens := elapsed * bucket.rate
-	bucket.tokens = min(bucket.capacity, bucket.tokens+newTokens)
-	bucket.lastRefill = time.Now()
-	logger.DebugKV("Refilled tokens", "clientID", clientID, "tokens", bucket.tokens, "elapsed", elapsed, "newTokens", newTokens)
+	elapsed, newTokens := bucket.refill()
+	log.DebugKV("Refilled tokens", "clientID", clientID, "tokens", bucket.tokens, "elapsed", elapsed, "newTokens", newTokens)
 
 	// Проверяем доступность токена
 	if bucket.tokens < 1 {
-		logger.WarnKV("Rate limit exceeded", "clientID", clientID, "tokens", bucket.tokens)
+		log.WarnKV("Rate limit exceeded", "clientID", clientID, "tokens", bucket.tokens)
 		return false
 	}
 
 	bucket.tokens--
-	logger.InfoKV("Token consumed", "clientID", clientID, "remaining_tokens", bucket.tokens)
+	log.InfoKV("Token consumed", "clientID", clientID, "remaining_tokens", bucket.tokens)
 
-	// Сохраняем в Redis
-	if rl.redisClient != nil {
-		saveToRedis := func() {
-			ctx := context.Background()
-			err := rl.redisClient.HSet(ctx, "ratelimit:"+clientID, map[string]interface{}{
-				"tokens":      bucket.tokens,
-				"last_refill": bucket.lastRefill.UnixNano(),
-				"capacity":    bucket.capacity,
-				"rate":        bucket.rate,
-			}).Err()
-			if err != nil {
-				logger.ErrorKV("Failed to save to Redis", "clientID", clientID, "error", err)
-			} else {
-				logger.DebugKV("Successfully saved to Redis", "clientID", clientID, "tokens", bucket.tokens)
-			}
+	rl.save(log, clientID, bucket)
+	return true
+}
+
+// AllowPacket проверяет, разрешена ли датаграмма клиента. В отличие от AllowContext, решение
+// не логируется, а бакет сохраняется в Redis не чаще раза в packetSaveInterval: при флуде
+// строка лога и запись в Redis на каждый пакет стоили бы дороже самого пакета.
+func (rl *RateLimiter) AllowPacket(clientID string) bool {
+	log := logger.FromContext(context.Background())
+	bucket := rl.bucket(log, clientID)
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	bucket.refill()
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+
+	if time.Since(bucket.savedAt) >= packetSaveInterval {
+		bucket.savedAt = time.Now()
+		rl.save(log, clientID, bucket)
+	}
+	return true
+}
+
+// bucket возвращает бакет клиента, создавая его при первом обращении.
+func (rl *RateLimiter) bucket(log *logger.Logger, clientID string) *TokenBucket {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	bucket, exists := rl.buckets[clientID]
+	if exists {
+		return bucket
+	}
+	capacity, rate := rl.defaultCapacity, rl.defaultRate
+	for _, cfg := range rl.clientConfigs {
+		if cfg.ClientID == clientID {
+			log.InfoKV("Using client-specific rate limit", "clientID", clientID, "capacity", cfg.Capacity)
+			capacity, rate = float64(cfg.Capacity), cfg.Rate
+			break
 		}
+	}
+	bucket = &TokenBucket{
+		tokens:     capacity,
+		lastRefill: time.Now(),
+		capacity:   capacity,
+		rate:       rate,
+	}
+	rl.buckets[clientID] = bucket
+	log.InfoKV("Creating new bucket", "clientID", clientID, "capacity", capacity)
+	return bucket
+}
 
-		rl.mu.Lock()
-		syncRedis := rl.syncRedis
-		rl.mu.Unlock()
+// refill пополняет токены за время с прошлого пополнения. Вызывается под bucket.mu.
+func (b *TokenBucket) refill() (elapsed, newTokens float64) {
+	elapsed = time.Since(b.lastRefill).Seconds()
+	newTokens = elapsed * b.rate
+	b.tokens = min(b.capacity, b.tokens+newTokens)
+	b.lastRefill = time.Now()
+	return elapsed, newTokens
+}
 
-		if syncRedis {
-			saveToRedis()
+// save сохраняет состояние бакета в Redis. Вызывается под bucket.mu; значения копируются,
+// чтобы асинхронная запись не читала бакет без блокировки.
+func (rl *RateLimiter) save(log *logger.Logger, clientID string, bucket *TokenBucket) {
+	if rl.redisClient == nil {
+		return
+	}
+	tokens, lastRefill, capacity, rate := bucket.tokens, bucket.lastRefill, bucket.capacity, bucket.rate
+	saveToRedis := func() {
+		ctx := context.Background()
+		err := rl.redisClient.HSet(ctx, "ratelimit:"+clientID, map[string]interface{}{
+			"tokens":      tokens,
+			"last_refill": lastRefill.UnixNano(),
+			"capacity":    capacity,
+			"rate":        rate,
+		}).Err()
+		if err != nil {
+			log.ErrorKV("Failed to save to Redis", "clientID", clientID, "error", err)
 		} else {
-			go saveToRedis()
+			log.DebugKV("Successfully saved to Redis", "clientID", clientID, "tokens", tokens)
 		}
 	}
-	return true
+
+	rl.mu.Lock()
+	syncRedis := rl.syncRedis
+	rl.mu.Unlock()
+
+	if syncRedis {
+		saveToRedis()
+	} else {
+		rl.pending.Add(1)
+		go func() {
+			defer rl.pending.Done()
+			saveToRedis()
+		}()
+	}
 }
 
 // Update обновляет глобальные параметры rate-limiting.
@@ -175,6 +239,43 @@ func (rl *RateLimiter) UpdateClient(clientID string, capacity, rate float64) {
 	logger.InfoKV("Updated client rate limit", "clientID", clientID, "capacity", capacity)
 }
 
+// ErrRedisNotConfigured возвращается Ping, если адрес Redis не задан.
+var ErrRedisNotConfigured = errors.New("redis is not configured")
+
+// Ping проверяет доступность Redis.
+func (rl *RateLimiter) Ping(ctx context.Context) error {
+	if rl.redisClient == nil {
+		return ErrRedisNotConfigured
+	}
+	return rl.redisClient.Ping(ctx).Err()
+}
+
+// Close дожидается завершения незаписанных в Redis изменений и закрывает соединение с Redis.
+// Если ctx истекает раньше, возвращается его ошибка, а соединение все равно закрывается.
+func (rl *RateLimiter) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		rl.pending.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		logger.Info("Pending Redis writes flushed")
+	case <-ctx.Done():
+		err = ctx.Err()
+		logger.WarnKV("Timed out waiting for pending Redis writes", "error", err)
+	}
+
+	if rl.redisClient != nil {
+		if cerr := rl.redisClient.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 func min(a, b float64) float64 {
 	if a < b {
 		return a
@@ -9,6 +9,8 @@ import (
 	"load-balancer/internal/logger"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestMain(m *testing.M) {
@@ -87,6 +89,26 @@ func TestRateLimiter_UpdateClient(t *testing.T) {
 	}
 }
 
+func TestRateLimiter_AllowPacket(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger.SetLogger(zap.New(core))
+	t.Cleanup(logger.Init)
+
+	rl := NewRateLimiter(2, 0, nil, "")
+	clientID := "192.168.1.1"
+	rl.bucket(logger.FromContext(context.Background()), clientID)
+	before := logs.Len()
+
+	for i := 0; i < 100; i++ {
+		if allowed := rl.AllowPacket(clientID); allowed != (i < 2) {
+			t.Errorf("Packet %d: expected allowed=%v", i, i < 2)
+		}
+	}
+	if n := logs.Len() - before; n != 0 {
+		t.Errorf("Expected no log entries per packet, got %d", n)
+	}
+}
+
 func TestRateLimiter_Redis(t *testing.T) {
 	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
 	ctx := context.Background()
@@ -0,0 +1,81 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"load-balancer/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+	if !uuidV4.MatchString(a) {
+		t.Errorf("Expected a UUID v4, got %q", a)
+	}
+	if a == b {
+		t.Errorf("Expected distinct IDs, got %q twice", a)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger.SetLogger(zap.New(core))
+	defer logger.Init()
+
+	var seenHeader, seenContext string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenHeader, seenContext = r.Header.Get(Header), FromContext(r.Context())
+		logger.FromContext(r.Context()).InfoKV("Handling request")
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Incoming ID is kept", incoming: "client-trace-42", keep: true},
+		{name: "Missing ID is generated"},
+		{name: "ID with spaces is replaced", incoming: "bad id"},
+		{name: "Overlong ID is replaced", incoming: strings.Repeat("a", maxLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(Header, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(Header)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("Expected incoming ID %q, got %q", tt.incoming, id)
+			}
+			if !tt.keep && !uuidV4.MatchString(id) {
+				t.Errorf("Expected a generated UUID, got %q", id)
+			}
+			if seenHeader != id || seenContext != id {
+				t.Errorf("Expected handler to see %q, got header %q and context %q", id, seenHeader, seenContext)
+			}
+			entries := logs.TakeAll()
+			if len(entries) != 1 || entries[0].ContextMap()["request_id"] != id {
+				t.Errorf("Expected log entry with request_id %q, got %v", id, entries)
+			}
+		})
+	}
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("Expected no ID, got %q", id)
+	}
+}
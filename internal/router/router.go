@@ -0,0 +1,129 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"load-balancer/internal/models"
+)
+
+// compiledRoute is a route with its path expression compiled.
+type compiledRoute struct {
+	route *models.Route
+	regex *regexp.Regexp
+}
+
+// Router matches requests against an ordered list of routes.
+// It is immutable; build a new one when the routes change.
+type Router struct {
+	routes []compiledRoute
+}
+
+// New compiles the routes in order.
+func New(routes []*models.Route) (*Router, error) {
+	r := &Router{routes: make([]compiledRoute, 0, len(routes))}
+	for _, route := range routes {
+		compiled := compiledRoute{route: route}
+		if route.Match.PathRegex != "" {
+			re, err := regexp.Compile(route.Match.PathRegex)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: invalid path_regex: %w", route.ID, err)
+			}
+			compiled.regex = re
+		}
+		r.routes = append(r.routes, compiled)
+	}
+	return r, nil
+}
+
+// Match returns the first route that matches the request, or nil.
+func (r *Router) Match(req *http.Request) *models.Route {
+	if r == nil {
+		return nil
+	}
+	for _, c := range r.routes {
+		if c.matches(req) {
+			return c.route
+		}
+	}
+	return nil
+}
+
+func (c compiledRoute) matches(req *http.Request) bool {
+	m := c.route.Match
+	if m.Host != "" && !matchHost(m.Host, req.Host) {
+		return false
+	}
+	if m.PathPrefix != "" && !MatchPathPrefix(m.PathPrefix, req.URL.Path) {
+		return false
+	}
+	if c.regex != nil && !c.regex.MatchString(req.URL.Path) {
+		return false
+	}
+	if len(m.Methods) > 0 && !matchMethod(m.Methods, req.Method) {
+		return false
+	}
+	for name, value := range m.Headers {
+		values, ok := req.Header[http.CanonicalHeaderKey(name)]
+		if !ok || (value != "" && !contains(values, value)) {
+			return false
+		}
+	}
+	if len(m.Query) > 0 {
+		query := req.URL.Query()
+		for name, value := range m.Query {
+			values, ok := query[name]
+			if !ok || (value != "" && !contains(values, value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchPathPrefix reports whether path starts with prefix at a segment boundary.
+func MatchPathPrefix(prefix, path string) bool {
+	if prefix == "/" {
+		return true
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
+}
+
+// matchHost compares hosts case-insensitively, ignoring the port.
+// A pattern of the form "*.example.com" matches any subdomain of example.com.
+func matchHost(pattern, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == pattern
+}
+
+func matchMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"load-balancer/internal/models"
+)
+
+func TestRouter_Match(t *testing.T) {
+	routes := []*models.Route{
+		{ID: "canary", Match: models.RouteMatch{PathPrefix: "/api", Headers: map[string]string{"X-Canary": "1"}}, Pool: "canary"},
+		{ID: "api", Match: models.RouteMatch{PathPrefix: "/api/", Methods: []string{"get", "POST"}}, Pool: "api"},
+		{ID: "static", Match: models.RouteMatch{Host: "*.example.com", PathRegex: `\.(css|js)$`}, Pool: "static"},
+		{ID: "admin", Match: models.RouteMatch{Host: "admin.local", Query: map[string]string{"debug": ""}}, Pool: "admin"},
+	}
+	r, err := New(routes)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		host    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "Header match wins by order", method: "GET", target: "/api/users", headers: map[string]string{"X-Canary": "1"}, want: "canary"},
+		{name: "Header value must match", method: "GET", target: "/api/users", headers: map[string]string{"X-Canary": "0"}, want: "api"},
+		{name: "Prefix at segment boundary", method: "GET", target: "/api", want: "api"},
+		{name: "Prefix does not match partial segment", method: "GET", target: "/apix", want: ""},
+		{name: "Method not listed", method: "DELETE", target: "/api/users", want: ""},
+		{name: "Wildcard host with regex", method: "GET", target: "/assets/app.js", host: "cdn.example.com:8087", want: "static"},
+		{name: "Wildcard host does not match apex", method: "GET", target: "/assets/app.js", host: "example.com", want: ""},
+		{name: "Host is case-insensitive", method: "GET", target: "/?debug", host: "ADMIN.local", want: "admin"},
+		{name: "Query parameter required", method: "GET", target: "/", host: "admin.local", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			got := ""
+			if route := r.Match(req); route != nil {
+				got = route.ID
+			}
+			if got != tt.want {
+				t.Errorf("Expected route %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRouter_InvalidRegex(t *testing.T) {
+	if _, err := New([]*models.Route{{ID: "bad", Match: models.RouteMatch{PathRegex: "("}, Pool: "x"}}); err == nil {
+		t.Error("Expected error for invalid path_regex")
+	}
+}
+
+func TestMatchPathPrefix(t *testing.T) {
+	tests := []struct {
+		prefix, path string
+		want         bool
+	}{
+		{"/", "/anything", true},
+		{"/api", "/api", true},
+		{"/api", "/api/v1", true},
+		{"/api/", "/api", true},
+		{"/api", "/apiv1", false},
+		{"/api/v1", "/api", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPathPrefix(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("MatchPathPrefix(%q, %q) = %v, want %v", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
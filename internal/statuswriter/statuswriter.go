@@ -0,0 +1,70 @@
+// Package statuswriter запоминает итоговый код ответа и число записанных байт. Его используют
+// access-лог, трассировка и учет canary-запросов.
+package statuswriter
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// Writer оборачивает http.ResponseWriter и запоминает код ответа и размер тела.
+type Writer struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// New оборачивает w.
+func New(w http.ResponseWriter) *Writer {
+	return &Writer{ResponseWriter: w}
+}
+
+// Status возвращает итоговый код ответа или 0, если обработчик еще ничего не записал.
+func (w *Writer) Status() int {
+	return w.status
+}
+
+// Bytes возвращает число байт тела, переданных клиенту.
+func (w *Writer) Bytes() int64 {
+	return w.bytes
+}
+
+func (w *Writer) WriteHeader(code int) {
+	// Информационные ответы 1xx не являются итоговым статусом
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush передает буферизованные данные клиенту, если исходный writer это поддерживает.
+func (w *Writer) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack передает соединение обработчику смены протокола (WebSocket). Ответ 101 ReverseProxy
+// пишет уже в захваченное соединение, поэтому такой запрос получает статус 101.
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного writer.
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,42 @@
+package statuswriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	t.Run("Implicit OK", func(t *testing.T) {
+		w := New(httptest.NewRecorder())
+		if w.Status() != 0 {
+			t.Errorf("Expected no status before the response, got %d", w.Status())
+		}
+		w.Write([]byte("hello"))
+		w.Write([]byte(" world"))
+		if w.Status() != http.StatusOK || w.Bytes() != 11 {
+			t.Errorf("Expected 200 and 11 bytes, got %d and %d", w.Status(), w.Bytes())
+		}
+	})
+
+	t.Run("Informational responses are skipped", func(t *testing.T) {
+		w := New(httptest.NewRecorder())
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusBadGateway)
+		w.WriteHeader(http.StatusOK)
+		if w.Status() != http.StatusBadGateway {
+			t.Errorf("Expected status 502, got %d", w.Status())
+		}
+	})
+
+	t.Run("Controller reaches the underlying writer", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		w := New(rr)
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		if !rr.Flushed {
+			t.Error("Expected the recorder to be flushed")
+		}
+	})
+}
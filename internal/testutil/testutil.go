@@ -0,0 +1,19 @@
+// Package testutil содержит помощники, общие для тестов разных пакетов.
+package testutil
+
+import "time"
+
+// WaitFor опрашивает cond, пока условие не выполнится или не пройдет секунда. Условие
+// не вызывается повторно после успеха, поэтому может иметь побочные эффекты.
+func WaitFor(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
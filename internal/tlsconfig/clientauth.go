@@ -0,0 +1,101 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"load-balancer/internal/models"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// clientAuthTypes сопоставляет режимы client_auth.mode с политиками crypto/tls.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                       tls.NoClientCert,
+	models.ClientAuthNone:    tls.NoClientCert,
+	models.ClientAuthRequest: tls.VerifyClientCertIfGiven,
+	models.ClientAuthRequire: tls.RequireAndVerifyClientCert,
+}
+
+// validateClientAuth проверяет настройки клиентских сертификатов без чтения файла CA.
+func validateClientAuth(cfg models.ClientAuthConfig) error {
+	authType, ok := clientAuthTypes[cfg.Mode]
+	if !ok {
+		return fmt.Errorf("unknown tls client_auth mode %q", cfg.Mode)
+	}
+	if authType != tls.NoClientCert && cfg.CAFile == "" {
+		return fmt.Errorf("tls client_auth mode %s requires ca_file", cfg.Mode)
+	}
+	switch cfg.Identity {
+	case "", models.ClientIdentityIP, models.ClientIdentitySubject, models.ClientIdentitySAN:
+	default:
+		return fmt.Errorf("unknown tls client_auth identity %q", cfg.Identity)
+	}
+	h := cfg.Headers
+	for _, name := range []string{h.Subject, h.Issuer, h.SAN, h.Serial, h.Fingerprint, h.Cert} {
+		if name != "" && !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid tls client_auth header name %q", name)
+		}
+	}
+	return nil
+}
+
+// applyClientAuth включает запрос клиентских сертификатов и загружает доверенные CA.
+func applyClientAuth(tlsCfg *tls.Config, cfg models.ClientAuthConfig) error {
+	tlsCfg.ClientAuth = clientAuthTypes[cfg.Mode]
+	if tlsCfg.ClientAuth == tls.NoClientCert {
+		return nil
+	}
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates found in client CA file %s", cfg.CAFile)
+	}
+	tlsCfg.ClientCAs = pool
+	return nil
+}
+
+// VerifiedClientCert возвращает проверенный сертификат клиента соединения или nil,
+// если соединение без TLS или клиент не предъявил сертификат.
+func VerifiedClientCert(state *tls.ConnectionState) *x509.Certificate {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// ClientIdentity возвращает идентификатор клиента для rate-limiting по сертификату:
+// CN субъекта или первое альтернативное имя. Пустая строка — идентификатор берется из адреса.
+func ClientIdentity(cert *x509.Certificate, identity string) string {
+	if cert == nil {
+		return ""
+	}
+	switch identity {
+	case models.ClientIdentitySubject:
+		return cert.Subject.CommonName
+	case models.ClientIdentitySAN:
+		if names := SubjectAltNames(cert); len(names) > 0 {
+			return names[0]
+		}
+	}
+	return ""
+}
+
+// SubjectAltNames перечисляет альтернативные имена сертификата: DNS-имена, адреса почты, URI и IP.
+func SubjectAltNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs)+len(cert.IPAddresses))
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
@@ -0,0 +1,113 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+)
+
+// Store хранит сертификаты публичного порта и перечитывает их с диска при изменении
+// файлов, без перезапуска. Проверка выполняется при TLS-рукопожатии не чаще reloadInterval.
+type Store struct {
+	files          []models.CertificateConfig
+	reloadInterval time.Duration
+
+	mu        sync.RWMutex
+	certs     []*tls.Certificate
+	modTimes  []time.Time // Время изменения файлов при последней загрузке: cert, key, cert, key...
+	lastCheck time.Time
+}
+
+// NewStore загружает сертификаты. Ошибка чтения любого из них возвращается сразу.
+func NewStore(cfg models.TLSConfig) (*Store, error) {
+	interval := cfg.ReloadInterval.Std()
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	s := &Store{files: cfg.Certificates, reloadInterval: interval}
+	if len(s.files) == 0 {
+		return nil, fmt.Errorf("tls requires at least one certificate")
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload перечитывает все сертификаты. При ошибке остаются загруженные ранее.
+func (s *Store) Reload() error {
+	certs := make([]*tls.Certificate, len(s.files))
+	modTimes := make([]time.Time, 0, 2*len(s.files))
+	for i, f := range s.files {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate %s: %w", f.CertFile, err)
+		}
+		certs[i] = &cert
+		modTimes = append(modTimes, modTime(f.CertFile), modTime(f.KeyFile))
+	}
+	s.mu.Lock()
+	s.certs, s.modTimes, s.lastCheck = certs, modTimes, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// GetCertificate выбирает сертификат по SNI клиента; без совпадения отдается первый.
+// Подходит для tls.Config.GetCertificate.
+func (s *Store) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.reloadIfChanged()
+	s.mu.RLock()
+	certs := s.certs
+	s.mu.RUnlock()
+	for _, cert := range certs {
+		if hello.ServerName != "" && hello.SupportsCertificate(cert) == nil {
+			return cert, nil
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates loaded")
+	}
+	return certs[0], nil
+}
+
+// reloadIfChanged перечитывает сертификаты, если с прошлой проверки прошло reloadInterval
+// и время изменения какого-либо файла поменялось.
+func (s *Store) reloadIfChanged() {
+	s.mu.Lock()
+	if time.Since(s.lastCheck) < s.reloadInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCheck = time.Now()
+	changed := false
+	for i, f := range s.files {
+		if !modTime(f.CertFile).Equal(s.modTimes[2*i]) || !modTime(f.KeyFile).Equal(s.modTimes[2*i+1]) {
+			changed = true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if !changed {
+		return
+	}
+	if err := s.Reload(); err != nil {
+		// Файлы могут быть записаны не полностью; следующая проверка попробует снова
+		logger.ErrorKV("Failed to reload TLS certificates, keeping the previous ones", "error", err)
+		return
+	}
+	logger.Info("TLS certificates reloaded")
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,103 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// defaultReloadInterval — как часто проверяются изменения файлов сертификатов по умолчанию.
+const defaultReloadInterval = 30 * time.Second
+
+// versions сопоставляет значения min_version с константами crypto/tls.
+var versions = map[string]uint16{
+	models.TLSVersion10: tls.VersionTLS10,
+	models.TLSVersion11: tls.VersionTLS11,
+	models.TLSVersion12: tls.VersionTLS12,
+	models.TLSVersion13: tls.VersionTLS13,
+}
+
+// ParseVersion возвращает минимальную версию TLS; пустое значение — TLS 1.2.
+func ParseVersion(v string) (uint16, error) {
+	if v == "" {
+		return tls.VersionTLS12, nil
+	}
+	version, ok := versions[v]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q", v)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites возвращает идентификаторы наборов шифров по именам crypto/tls.
+// Допускаются только наборы из tls.CipherSuites(), то есть без известных уязвимостей.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Validate проверяет настройки TLS без чтения файлов сертификатов.
+func Validate(cfg models.TLSConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Port == "" {
+		return fmt.Errorf("tls port is required")
+	}
+	if len(cfg.Certificates) == 0 {
+		return fmt.Errorf("tls requires at least one certificate")
+	}
+	for i, c := range cfg.Certificates {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return fmt.Errorf("tls certificate %d requires cert_file and key_file", i)
+		}
+	}
+	if _, err := ParseVersion(cfg.MinVersion); err != nil {
+		return err
+	}
+	if _, err := ParseCipherSuites(cfg.CipherSuites); err != nil {
+		return err
+	}
+	if cfg.ReloadInterval < 0 {
+		return fmt.Errorf("tls reload_interval must not be negative")
+	}
+	return validateClientAuth(cfg.ClientAuth)
+}
+
+// Server собирает tls.Config публичного порта: сертификаты выбираются по SNI из store,
+// версия, шифры и проверка клиентских сертификатов берутся из настроек.
+func Server(cfg models.TLSConfig, store *Store) (*tls.Config, error) {
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	ciphers, err := ParseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   ciphers,
+		GetCertificate: store.GetCertificate,
+	}
+	if err := applyClientAuth(tlsCfg, cfg.ClientAuth); err != nil {
+		return nil, err
+	}
+	return tlsCfg, nil
+}
@@ -0,0 +1,173 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"load-balancer/internal/logger"
+	"load-balancer/internal/models"
+	"load-balancer/internal/tlsconfig/tlstest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// served returns the leaf certificate the store picks for the SNI name.
+func served(t *testing.T, store *Store, serverName string) *x509.Certificate {
+	t.Helper()
+	cert, err := store.GetCertificate(&tls.ClientHelloInfo{
+		ServerName:        serverName,
+		SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:   []tls.CurveID{tls.CurveP256},
+		SupportedVersions: []uint16{tls.VersionTLS13, tls.VersionTLS12},
+		CipherSuites:      []uint16{tls.TLS_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	})
+	if err != nil {
+		t.Fatalf("GetCertificate(%q) failed: %v", serverName, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf
+}
+
+func TestStore_SNI(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(models.TLSConfig{Certificates: []models.CertificateConfig{
+		tlstest.NewCert(t, dir, "shop.example.com").Config(),
+		tlstest.NewCert(t, dir, "api.example.com", "*.api.example.com").Config(),
+	}})
+	if err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+
+	tests := []struct {
+		serverName string
+		want       string
+	}{
+		{"shop.example.com", "shop.example.com"},
+		{"api.example.com", "api.example.com"},
+		{"v2.api.example.com", "api.example.com"},
+		{"unknown.example.com", "shop.example.com"},
+		{"", "shop.example.com"},
+	}
+	for _, tt := range tests {
+		if got := served(t, store, tt.serverName).Subject.CommonName; got != tt.want {
+			t.Errorf("SNI %q: expected certificate %s, got %s", tt.serverName, tt.want, got)
+		}
+	}
+}
+
+func TestStore_Reload(t *testing.T) {
+	dir := t.TempDir()
+	old := tlstest.NewCert(t, dir, "site.example.com")
+	store, err := NewStore(models.TLSConfig{
+		Certificates:   []models.CertificateConfig{old.Config()},
+		ReloadInterval: models.Duration(10 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+	if got := served(t, store, "site.example.com"); !got.Equal(old.Leaf) {
+		t.Fatal("Expected the initial certificate")
+	}
+
+	// A broken file keeps the previous certificate in service
+	os.WriteFile(old.CertFile, []byte("garbage"), 0600)
+	time.Sleep(20 * time.Millisecond)
+	if got := served(t, store, "site.example.com"); !got.Equal(old.Leaf) {
+		t.Error("Expected the previous certificate after a failed reload")
+	}
+
+	renewed := tlstest.NewCert(t, dir, "site.example.com")
+	time.Sleep(20 * time.Millisecond)
+	if got := served(t, store, "site.example.com"); !got.Equal(renewed.Leaf) {
+		t.Error("Expected the renewed certificate")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cert := []models.CertificateConfig{{CertFile: "site.crt", KeyFile: "site.key"}}
+	tests := []struct {
+		name    string
+		cfg     models.TLSConfig
+		wantErr bool
+	}{
+		{"Disabled", models.TLSConfig{MinVersion: "bogus"}, false},
+		{"Valid", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, MinVersion: "1.3", CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}, false},
+		{"Missing port", models.TLSConfig{Enabled: true, Certificates: cert}, true},
+		{"No certificates", models.TLSConfig{Enabled: true, Port: "8443"}, true},
+		{"Missing key", models.TLSConfig{Enabled: true, Port: "8443", Certificates: []models.CertificateConfig{{CertFile: "site.crt"}}}, true},
+		{"Unknown version", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, MinVersion: "1.4"}, true},
+		{"Insecure cipher", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}, true},
+		{"Client auth", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, ClientAuth: models.ClientAuthConfig{Mode: "request", CAFile: "ca.pem", Identity: "san", Headers: models.ClientCertHeaders{Subject: "X-Client-Subject"}}}, false},
+		{"Unknown client auth mode", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, ClientAuth: models.ClientAuthConfig{Mode: "optional", CAFile: "ca.pem"}}, true},
+		{"Client auth without CA", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, ClientAuth: models.ClientAuthConfig{Mode: "require"}}, true},
+		{"Unknown client identity", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, ClientAuth: models.ClientAuthConfig{Identity: "email"}}, true},
+		{"Invalid client header name", models.TLSConfig{Enabled: true, Port: "8443", Certificates: cert, ClientAuth: models.ClientAuthConfig{Headers: models.ClientCertHeaders{SAN: "X Client SAN"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCert(t, dir, "ca.internal")
+	client := tlstest.NewCert(t, dir, "lb.internal")
+
+	cfg, err := Client(&models.UpstreamTLSConfig{CAFile: ca.CertFile, CertFile: client.CertFile, KeyFile: client.KeyFile, ServerName: "billing.internal"})
+	if err != nil {
+		t.Fatalf("Client() failed: %v", err)
+	}
+	if cfg.ServerName != "billing.internal" || cfg.RootCAs == nil || len(cfg.Certificates) != 1 || cfg.InsecureSkipVerify {
+		t.Errorf("Unexpected client TLS config: %+v", cfg)
+	}
+	if cfg, err := Client(nil); cfg != nil || err != nil {
+		t.Errorf("Expected nil config for nil settings, got %v, %v", cfg, err)
+	}
+
+	for name, bad := range map[string]*models.UpstreamTLSConfig{
+		"Missing CA file":   {CAFile: dir + "/missing.pem"},
+		"CA file is no PEM": {CAFile: client.KeyFile},
+		"Key without cert":  {KeyFile: client.KeyFile},
+		"Mismatched key":    {CertFile: client.CertFile, KeyFile: ca.KeyFile},
+	} {
+		if err := ValidateUpstream(bad); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestClientIdentity(t *testing.T) {
+	cert := tlstest.NewCert(t, t.TempDir(), "partner.example.com", "api.partner.example.com").Leaf
+	tests := []struct {
+		identity string
+		cert     *x509.Certificate
+		want     string
+	}{
+		{models.ClientIdentitySubject, cert, "partner.example.com"},
+		{models.ClientIdentitySAN, cert, "partner.example.com"},
+		{models.ClientIdentityIP, cert, ""},
+		{"", cert, ""},
+		{models.ClientIdentitySubject, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := ClientIdentity(tt.cert, tt.identity); got != tt.want {
+			t.Errorf("ClientIdentity(%q): expected %q, got %q", tt.identity, tt.want, got)
+		}
+	}
+	if got := VerifiedClientCert(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}); got != nil {
+		t.Error("Expected an unverified peer certificate to be ignored")
+	}
+}
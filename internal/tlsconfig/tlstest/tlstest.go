@@ -0,0 +1,88 @@
+// Package tlstest создает самоподписанные сертификаты для тестов.
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"load-balancer/internal/models"
+)
+
+// Cert — сертификат, записанный в PEM-файлы.
+type Cert struct {
+	CertFile string
+	KeyFile  string
+	Leaf     *x509.Certificate
+	TLS      tls.Certificate
+}
+
+// Config возвращает пару файлов для настроек models.TLSConfig.
+func (c Cert) Config() models.CertificateConfig {
+	return models.CertificateConfig{CertFile: c.CertFile, KeyFile: c.KeyFile}
+}
+
+// Pool возвращает пул, доверяющий сертификату. Самоподписанный сертификат
+// в пуле проверяется как собственный корневой.
+func (c Cert) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.Leaf)
+	return pool
+}
+
+// NewCert создает самоподписанный сертификат с именем name (CommonName и первое DNS-имя),
+// пригодный и для сервера, и для клиента, и записывает его в dir как name.crt и name.key.
+// Повторный вызов с тем же name перезаписывает файлы.
+func NewCert(t testing.TB, dir, name string, dnsNames ...string) Cert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     append([]string{name}, dnsNames...),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	c := Cert{CertFile: filepath.Join(dir, name+".crt"), KeyFile: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(c.CertFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.KeyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if c.Leaf, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	if c.TLS, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
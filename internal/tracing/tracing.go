@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"load-balancer/internal/models"
+	"load-balancer/internal/requestid"
+	"load-balancer/internal/statuswriter"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Имена дочерних спанов обработки запроса.
+const (
+	SpanRateLimit     = "rate_limit"
+	SpanSelectBackend = "select_backend"
+	SpanUpstream      = "upstream"
+)
+
+const (
+	tracerName         = "load-balancer"
+	defaultServiceName = "load-balancer"
+)
+
+// Tracer создает спаны запросов и передает контекст трассировки бэкендам
+// в заголовках traceparent и tracestate. Nil Tracer ничего не делает.
+type Tracer struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// New создает трассировку с экспортом по OTLP/HTTP. Для выключенной трассировки возвращает nil.
+// Соединение с коллектором устанавливается при первой отправке спанов.
+func New(ctx context.Context, cfg models.TracingConfig) (*Tracer, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	opts := []otlptracehttp.Option{}
+	switch {
+	case strings.Contains(cfg.Endpoint, "://"):
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return newTracer(cfg, sdktrace.WithBatcher(exporter)), nil
+}
+
+// NewWithExporter создает трассировку, синхронно отправляющую спаны в exporter.
+// Используется в тестах с tracetest.InMemoryExporter.
+func NewWithExporter(cfg models.TracingConfig, exporter sdktrace.SpanExporter) *Tracer {
+	return newTracer(cfg, sdktrace.WithSyncer(exporter))
+}
+
+func newTracer(cfg models.TracingConfig, export sdktrace.TracerProviderOption) *Tracer {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	provider := sdktrace.NewTracerProvider(
+		export,
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	return &Tracer{
+		provider:   provider,
+		tracer:     provider.Tracer(tracerName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// Validate проверяет настройки трассировки.
+func Validate(cfg models.TracingConfig) error {
+	if cfg.SampleRatio != nil && (*cfg.SampleRatio < 0 || *cfg.SampleRatio > 1) {
+		return fmt.Errorf("tracing sample_ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// Start начинает дочерний спан текущего запроса.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	return t.tracer.Start(ctx, name, opts...)
+}
+
+// Inject записывает traceparent и tracestate спана из ctx в заголовки запроса к бэкенду.
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	if t == nil {
+		return
+	}
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Shutdown отправляет накопленные спаны и останавливает экспорт.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// Middleware создает серверный спан для каждого запроса, продолжая трассу из входящих
+// traceparent и tracestate. Спан помечается ошибкой при ответе 5xx.
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("server.address", r.Host),
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			attrs = append(attrs, attribute.String("client.address", host))
+		}
+		if id := requestid.FromContext(ctx); id != "" {
+			attrs = append(attrs, attribute.String("request.id", id))
+		}
+		ctx, span := t.tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		rw := statuswriter.New(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
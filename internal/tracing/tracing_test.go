@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"load-balancer/internal/models"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func TestMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := NewWithExporter(models.TracingConfig{Enabled: true}, exporter)
+
+	var injected http.Header
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), SpanUpstream)
+		injected = http.Header{}
+		tracer.Inject(ctx, injected)
+		span.End()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	req.Header.Set("tracestate", "vendor=abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	upstream, server := spans[0], spans[1]
+	if server.SpanKind != trace.SpanKindServer || server.Parent.SpanID().String() != parentSpanID || server.SpanContext.TraceID().String() != parentTraceID {
+		t.Errorf("Expected server span to continue the incoming trace, got parent %s in trace %s", server.Parent.SpanID(), server.SpanContext.TraceID())
+	}
+	if server.Status.Code != codes.Error {
+		t.Errorf("Expected error status for 502, got %v", server.Status)
+	}
+	if upstream.Name != SpanUpstream || upstream.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("Expected upstream span to be a child of the server span, got %q with parent %s", upstream.Name, upstream.Parent.SpanID())
+	}
+	want := "00-" + parentTraceID + "-" + upstream.SpanContext.SpanID().String() + "-01"
+	if got := injected.Get("traceparent"); got != want {
+		t.Errorf("Expected traceparent %q, got %q", want, got)
+	}
+	if got := injected.Get("tracestate"); got != "vendor=abc" {
+		t.Errorf("Expected tracestate to be kept, got %q", got)
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	ratio := 0.0
+	tracer := NewWithExporter(models.TracingConfig{Enabled: true, SampleRatio: &ratio}, exporter)
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("Expected new traces not to be sampled, got %d spans", n)
+	}
+
+	// A sampled incoming trace is followed regardless of the ratio
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("Expected the sampled parent to be followed, got %d spans", n)
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), SpanRateLimit)
+	span.End()
+	header := http.Header{}
+	tracer.Inject(ctx, header)
+	if len(header) != 0 {
+		t.Errorf("Expected no headers from a disabled tracer, got %v", header)
+	}
+	if err := tracer.Shutdown(ctx); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if tr, err := New(ctx, models.TracingConfig{}); tr != nil || err != nil {
+		t.Errorf("Expected nil tracer for disabled tracing, got %v, %v", tr, err)
+	}
+}
@@ -8,6 +8,6 @@ import (
 // NewClient creates a new HTTP client with the specified timeout.
 func NewClient(timeout time.Duration) *http.Client {
 	return &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: timeout,
 	}
 }